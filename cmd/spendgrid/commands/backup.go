@@ -0,0 +1,286 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/backup"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/validator"
+	"spendgrid/internal/vault"
+)
+
+// BackupCmd represents the backup command
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Write a backup archive of the ledger",
+	Long: `Write a timestamped tar.gz of _config, _pool, every year directory and .spendgrid.
+Every archive holds a MANIFEST.sha256 with the checksum of each file, which
+'spendgrid restore' and 'spendgrid backup verify' check.
+
+Archives are written to .spendgrid/backups unless --dir is given. Migrations and
+bulk changes take an automatic backup there first; the newest 10 are kept.
+
+Examples:
+  spendgrid backup
+  spendgrid backup --dir ~/Backups --encrypt --rates
+  spendgrid backup --keep 10 --keep-days 90
+  spendgrid backup list
+  spendgrid restore ~/Backups/spendgrid-20261018-210000.tar.gz --to ~/restored`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		dir, _ := cmd.Flags().GetString("dir")
+		rates, _ := cmd.Flags().GetBool("rates")
+		encrypt, _ := cmd.Flags().GetBool("encrypt")
+
+		opts := backup.Options{Dir: dir, Rates: rates}
+		if encrypt {
+			passphrase, err := newPassphrase()
+			if err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			opts.Passphrase = passphrase
+		}
+
+		archive, err := backup.Create(opts)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		removed, err := backup.Prune(backupDir(cmd), retention(cmd))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("backup", archive); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+		color.Green("✓ Backup written: %s (%d files, %s)", archive.Path, archive.Files, formatBytes(archive.Size))
+		if len(removed) > 0 {
+			fmt.Printf("Removed %d old backups\n", len(removed))
+		}
+	},
+}
+
+// backupListCmd lists the archives
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List backup archives",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		archives, err := backup.List(backupDir(cmd))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("backups", archives); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		if len(archives) == 0 {
+			color.Yellow("No backups in %s", backupDir(cmd))
+			return
+		}
+
+		fmt.Println()
+		fmt.Printf("%-20s %-10s %-10s %10s  %s\n", "Time", "Reason", "Encrypted", "Size", "File")
+		fmt.Println("--------------------------------------------------------------------------------")
+		for _, a := range archives {
+			reason := a.Reason
+			if reason == "" {
+				reason = "manual"
+			}
+			encrypted := ""
+			if a.Encrypted {
+				encrypted = "yes"
+			}
+			fmt.Printf("%-20s %-10s %-10s %10s  %s\n", a.Time.Format("2006-01-02 15:04:05"), reason, encrypted, formatBytes(a.Size), a.Path)
+		}
+		fmt.Println()
+	},
+}
+
+// backupPruneCmd applies the retention policy without writing a backup
+var backupPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove backups outside the retention policy",
+	Long: `Remove old backup archives. The newest archive is always kept.
+
+Examples:
+  spendgrid backup prune --keep 5
+  spendgrid backup prune --keep-days 30`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		r := retention(cmd)
+		if r.Keep == 0 && r.Days == 0 {
+			color.Yellow("Nothing to do: give --keep or --keep-days")
+			return
+		}
+
+		removed, err := backup.Prune(backupDir(cmd), r)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		for _, a := range removed {
+			fmt.Printf("  - %s\n", a.Path)
+		}
+		color.Green("✓ Removed %d backups", len(removed))
+	},
+}
+
+// backupVerifyCmd checks an archive against its manifest
+var backupVerifyCmd = &cobra.Command{
+	Use:   "verify <archive>",
+	Short: "Check the checksums and rows of a backup archive",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		passphrase, err := archivePassphrase(args[0])
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		files, err := backup.Open(args[0], passphrase)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		color.Green("✓ %s is intact (%d files)", args[0], len(files))
+
+		// The archived month files are checked like the ledger in place
+		l, err := backup.Ledger(files)
+		if err != nil {
+			fmt.Printf("  Rows not checked: %v\n", err)
+			return
+		}
+		result, err := validator.Validate(l)
+		if err != nil {
+			fmt.Printf("  Rows not checked: %v\n", err)
+			return
+		}
+		fmt.Printf("  Rows: %d parsed, %d unparsed\n", result.ParsedCount, result.UnparsedCount)
+		for _, line := range result.UnparsedLines {
+			color.Yellow("  %s:%d | %s", line.File, line.LineNum, line.Content)
+		}
+	},
+}
+
+// RestoreCmd represents the restore command
+var RestoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore the ledger from a backup archive",
+	Long: `Verify a backup archive against its checksums and restore it.
+
+Without --to, the ledger in the current directory is replaced by the archive;
+a backup of it is taken first. With --to, the archive is extracted to a new
+directory and the current ledger is not touched.
+
+Examples:
+  spendgrid restore .spendgrid/backups/spendgrid-20261018-210000.tar.gz
+  spendgrid restore ~/Backups/spendgrid-20261018-210000.tar.gz.enc --to ~/restored`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		to, _ := cmd.Flags().GetString("to")
+		rates, _ := cmd.Flags().GetBool("rates")
+
+		if to == "" {
+			if err := ledger.EnsureInitialized(); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+		}
+
+		passphrase, err := archivePassphrase(args[0])
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		count, err := backup.Restore(args[0], passphrase, to, rates)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if to != "" {
+			color.Green("✓ Restored %d files to %s", count, to)
+			return
+		}
+		color.Green("✓ Restored %d files", count)
+		commitLedger("restore " + filepath.Base(args[0]))
+	},
+}
+
+// backupDir returns the archive directory given with --dir
+func backupDir(cmd *cobra.Command) string {
+	if dir, _ := cmd.Flags().GetString("dir"); dir != "" {
+		return dir
+	}
+	return storage.Path(backup.DefaultDir)
+}
+
+// retention returns the retention policy given with --keep and --keep-days
+func retention(cmd *cobra.Command) backup.Retention {
+	keep, _ := cmd.Flags().GetInt("keep")
+	days, _ := cmd.Flags().GetInt("keep-days")
+	return backup.Retention{Keep: keep, Days: days}
+}
+
+// archivePassphrase returns the passphrase of an encrypted archive, or "" for a plain one
+func archivePassphrase(path string) (string, error) {
+	if !backup.IsEncrypted(path) {
+		return "", nil
+	}
+	if passphrase := os.Getenv(vault.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	return vault.Prompt("Backup passphrase: ")
+}
+
+// formatBytes returns a size like 12.3 KB
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func init() {
+	BackupCmd.PersistentFlags().String("dir", "", "Backup directory (default: .spendgrid/backups)")
+	BackupCmd.Flags().Bool("rates", false, "Include the exchange rate cache")
+	BackupCmd.Flags().Bool("encrypt", false, "Encrypt the archive with a passphrase")
+	for _, cmd := range []*cobra.Command{BackupCmd, backupPruneCmd} {
+		cmd.Flags().Int("keep", 0, "Keep only the newest N backups")
+		cmd.Flags().Int("keep-days", 0, "Remove backups older than N days")
+	}
+
+	BackupCmd.AddCommand(backupListCmd)
+	BackupCmd.AddCommand(backupPruneCmd)
+	BackupCmd.AddCommand(backupVerifyCmd)
+
+	RestoreCmd.Flags().String("to", "", "Extract to this directory instead of replacing the current ledger")
+	RestoreCmd.Flags().Bool("rates", false, "Also restore the exchange rate cache")
+}
@@ -0,0 +1,32 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/budget"
+)
+
+// BudgetCmd represents the budget command
+var BudgetCmd = &cobra.Command{
+	Use:   "budget [period]",
+	Short: "Show spent and remaining amounts per budget",
+	Long: `Show spent, remaining and percentage per budget defined in _config/budgets.yml.
+Period can be MM or YYYY-MM (default: current month).
+
+Examples:
+  spendgrid budget
+  spendgrid budget 09
+  spendgrid budget 2026-09`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		period := ""
+		if len(args) > 0 {
+			period = args[0]
+		}
+
+		if err := budget.ShowBudgets(period); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/bulk"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/output"
+)
+
+// BulkCmd represents the bulk command
+var BulkCmd = &cobra.Command{
+	Use:   "bulk",
+	Short: "Change many rows at once",
+	Long: `Change every row matching a filter. The filter uses the query language
+(see 'spendgrid query --help'), group by and aggregates are not allowed.
+
+A diff of the changes is shown before anything is written. All month files
+are written together, and the change can be reverted with 'spendgrid undo'.`,
+}
+
+var bulkRetagCmd = &cobra.Command{
+	Use:   "retag <filter>",
+	Short: "Add and remove tags",
+	Long: `Add and remove tags on matching rows. Removing a tag also removes its sub-tags.
+
+Examples:
+  spendgrid bulk retag 'desc ~ migros' --add market --remove diger
+  spendgrid bulk retag '#yemek date in 2026' --remove yemek --add mutfak`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		add, _ := cmd.Flags().GetStringSlice("add")
+		remove, _ := cmd.Flags().GetStringSlice("remove")
+		if len(add) == 0 && len(remove) == 0 {
+			color.Red("Error: use --add and/or --remove")
+			return
+		}
+		runBulk(cmd, "retag", args[0], bulk.Retag(add, remove))
+	},
+}
+
+var bulkSetProjectCmd = &cobra.Command{
+	Use:   "set-project <filter> <project>",
+	Short: "Set the project of matching rows",
+	Long: `Set the project of matching rows, replacing existing projects. Use - to clear it.
+
+Examples:
+  spendgrid bulk set-project 'date in 2026-08 desc ~ otel' tatil
+  spendgrid bulk set-project '@eski' -`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		project := args[1]
+		if project == "-" {
+			project = ""
+		}
+		runBulk(cmd, "set-project", args[0], bulk.SetProject(project))
+	},
+}
+
+var bulkSetMetaCmd = &cobra.Command{
+	Use:   "set-meta <filter> <KEY=VALUE>...",
+	Short: "Set meta fields of matching rows",
+	Long: `Set meta fields of matching rows. KEY= removes the field.
+
+Examples:
+  spendgrid bulk set-meta '&garanti date in 2026-Q3' card=bonus
+  spendgrid bulk set-meta 'meta.ref' ref=`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		values := make(map[string]string)
+		for _, arg := range args[1:] {
+			key, value, ok := strings.Cut(arg, "=")
+			key = strings.TrimSpace(key)
+			if !ok || key == "" || strings.ContainsAny(key+value, ",:[]") {
+				color.Red("Error: invalid meta field %q, use KEY=VALUE without , : [ ]", arg)
+				return
+			}
+			values[key] = strings.TrimSpace(value)
+		}
+		runBulk(cmd, "set-meta", args[0], bulk.SetMeta(values))
+	},
+}
+
+var bulkShiftDayCmd = &cobra.Command{
+	Use:   "shift-day <filter> <days>",
+	Short: "Move matching rows by a number of days",
+	Long: `Move matching rows by a number of days. Rows cannot leave their month.
+
+Examples:
+  spendgrid bulk shift-day '#kira date in 2026' -- -1
+  spendgrid bulk shift-day 'desc ~ maas' 2`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days == 0 {
+			color.Red("Error: invalid number of days: %s", args[1])
+			return
+		}
+		runBulk(cmd, "shift-day", args[0], bulk.ShiftDay(days))
+	},
+}
+
+var bulkConvertCurrencyCmd = &cobra.Command{
+	Use:   "convert-currency <filter> <currency>",
+	Short: "Rewrite amounts in another currency",
+	Long: `Rewrite amounts of matching rows in another currency, using the manual rate
+of the row or the exchange rate of its date. The original amount is kept in
+the ORIG meta field.
+
+Examples:
+  spendgrid bulk convert-currency 'currency = EUR date in 2026-06' TRY`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runBulk(cmd, "convert-currency", args[0], bulk.ConvertCurrency(args[1]))
+	},
+}
+
+var bulkDeleteCmd = &cobra.Command{
+	Use:   "delete <filter>",
+	Short: "Delete matching rows",
+	Long: `Delete matching rows from their month files.
+
+Examples:
+  spendgrid bulk delete 'meta.import = bank-2026-05'`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runBulk(cmd, "delete", args[0], bulk.Delete())
+	},
+}
+
+// runBulk previews a bulk change, asks for confirmation and applies it
+func runBulk(cmd *cobra.Command, name, filter string, op bulk.Operation) {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	plan, err := bulk.NewPlan(filter, op)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	if output.IsStructured() {
+		if err := output.Write("bulk", plan); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		// There is no prompt with structured output, --yes is required to apply
+		if dryRun || !yes || plan.Rows() == 0 {
+			return
+		}
+	} else {
+		if plan.Rows() == 0 {
+			fmt.Println(i18n.T("bulk.no_changes"))
+			return
+		}
+
+		fmt.Println()
+		plan.Preview()
+		if dryRun {
+			return
+		}
+
+		if !yes {
+			fmt.Printf(i18n.T("bulk.confirm")+" ", plan.Rows(), len(plan.Files))
+			reader := bufio.NewReader(os.Stdin)
+			response, _ := reader.ReadString('\n')
+			response = strings.TrimSpace(response)
+			if response != i18n.T("common.yes") && response != "y" && response != "Y" {
+				fmt.Println(i18n.T("common.cancel"))
+				return
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("bulk %s %s (%d rows)", name, filter, plan.Rows())
+	if _, err := plan.Apply("bulk "+name, summary); err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+
+	color.Green(i18n.T("bulk.applied"), plan.Rows(), len(plan.Files))
+}
+
+func init() {
+	BulkCmd.PersistentFlags().Bool("dry-run", false, "Only show the changes")
+	BulkCmd.PersistentFlags().BoolP("yes", "y", false, "Apply without asking")
+
+	bulkRetagCmd.Flags().StringSlice("add", nil, "Tags to add")
+	bulkRetagCmd.Flags().StringSlice("remove", nil, "Tags to remove, including sub-tags")
+
+	BulkCmd.AddCommand(bulkRetagCmd)
+	BulkCmd.AddCommand(bulkSetProjectCmd)
+	BulkCmd.AddCommand(bulkSetMetaCmd)
+	BulkCmd.AddCommand(bulkShiftDayCmd)
+	BulkCmd.AddCommand(bulkConvertCurrencyCmd)
+	BulkCmd.AddCommand(bulkDeleteCmd)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"spendgrid/internal/parser"
+	"spendgrid/internal/storage"
 )
 
 // CompleteCmd represents the complete command
@@ -150,7 +151,7 @@ func getAllCompletedRules() ([]RuleInfo, error) {
 
 // getRecentRulesWithFilter gets rules from current month with completion filter
 func getRecentRulesWithFilter(limit int, completed bool) ([]RuleInfo, error) {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return nil, fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -159,7 +160,7 @@ func getRecentRulesWithFilter(limit int, completed bool) ([]RuleInfo, error) {
 	yearDir := strconv.Itoa(now.Year())
 	filePath := filepath.Join(yearDir, monthFile)
 
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read month file: %v", err)
 	}
@@ -372,7 +373,7 @@ func truncateString(s string, maxLen int) string {
 
 // toggleRuleCompletion finds and toggles the completion status of a rule in month files
 func toggleRuleCompletion(ruleID string, completed bool) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -385,14 +386,14 @@ func toggleRuleCompletion(ruleID string, completed bool) error {
 		yearDir := strconv.Itoa(year)
 		filePath := filepath.Join(yearDir, monthFile)
 
-		content, err := os.ReadFile(filePath)
+		content, err := storage.ReadFile(filePath)
 		if err != nil {
 			continue // Skip if file doesn't exist
 		}
 
 		updated, found := updateRuleInContent(string(content), ruleID, completed)
 		if found {
-			if err := os.WriteFile(filePath, []byte(updated), 0644); err != nil {
+			if err := storage.WriteFile(filePath, []byte(updated), 0644); err != nil {
 				return fmt.Errorf("failed to update file: %v", err)
 			}
 			return nil
@@ -428,7 +429,7 @@ func updateRuleInContent(content string, ruleID string, completed bool) (string,
 
 // completeAllRulesInMonth marks all uncompleted rules in a month as completed
 func completeAllRulesInMonth(yearMonth string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -452,7 +453,7 @@ func completeAllRulesInMonth(yearMonth string) error {
 	yearDir := strconv.Itoa(year)
 	filePath := filepath.Join(yearDir, monthFile)
 
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("month file not found: %v", err)
 	}
@@ -462,7 +463,7 @@ func completeAllRulesInMonth(yearMonth string) error {
 		return fmt.Errorf("no uncompleted rules found in %s", yearMonth)
 	}
 
-	if err := os.WriteFile(filePath, []byte(updated), 0644); err != nil {
+	if err := storage.WriteFile(filePath, []byte(updated), 0644); err != nil {
 		return fmt.Errorf("failed to update file: %v", err)
 	}
 
@@ -27,6 +27,7 @@ var ConfigListCmd = &cobra.Command{
 		color.Cyan("Configuration:")
 		fmt.Println()
 		fmt.Printf("  Language: %s\n", cfg.Language)
+		fmt.Printf("  Key timeout: %s\n", config.GetKeyTimeout())
 		fmt.Println()
 
 		color.Yellow("Recent directories stored in: ~/.config/spendgrid/")
@@ -46,9 +47,11 @@ var ConfigGetCmd = &cobra.Command{
 		switch key {
 		case "language", "lang":
 			fmt.Println(cfg.Language)
+		case "key-timeout", "key_timeout":
+			fmt.Println(config.GetKeyTimeout())
 		default:
 			color.Yellow("Unknown config key: %s", key)
-			color.Yellow("Supported keys: language (lang)")
+			color.Yellow("Supported keys: language (lang), key-timeout")
 		}
 	},
 }
@@ -61,9 +64,11 @@ var ConfigSetCmd = &cobra.Command{
 
 Supported keys:
   - language (or lang): Set the display language (en, tr)
+  - key-timeout: How long the passphrase of an encrypted ledger is remembered (e.g. 30m, 0 to always ask)
 
 Examples:
-  spendgrid config set language tr    Set language to Turkish`,
+  spendgrid config set language tr    Set language to Turkish
+  spendgrid config set key-timeout 1h Remember the passphrase for an hour`,
 	Args: cobra.ExactArgs(2),
 	Run: func(cmd *cobra.Command, args []string) {
 		key := strings.ToLower(args[0])
@@ -76,9 +81,15 @@ Examples:
 				return
 			}
 			color.Green("✓ Language set to: %s", value)
+		case "key-timeout", "key_timeout":
+			if err := config.SetKeyTimeout(value); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			color.Green("✓ Key timeout set to: %s", config.GetKeyTimeout())
 		default:
 			color.Yellow("Unknown config key: %s", key)
-			color.Yellow("Supported keys: language (lang)")
+			color.Yellow("Supported keys: language (lang), key-timeout")
 		}
 	},
 }
@@ -0,0 +1,237 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/backup"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/search"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// EncryptCmd represents the encrypt command
+var EncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the ledger with a passphrase",
+	Long: `Encrypt the Markdown files, the _config files and the journal of the ledger
+with AES-256-GCM, using a key derived from a passphrase with scrypt.
+
+Files are stored as .md.enc and .yml.enc and every command reads and writes
+them as before. The passphrase is asked once and remembered for the key timeout
+(spendgrid config set key-timeout 30m), or taken from SPENDGRID_PASSPHRASE.
+Running encrypt on an encrypted ledger encrypts the files still kept plain,
+e.g. _config files of a ledger encrypted by an older version.
+
+Backups in .spendgrid/backups taken before encryption are encrypted with the
+same passphrase; on a ledger that is already encrypted they are only listed.
+Backups written elsewhere with --dir are not changed.
+
+There is no way to recover a ledger whose passphrase is lost.
+
+Examples:
+  spendgrid encrypt
+  spendgrid decrypt --export ~/plain-copy
+  spendgrid lock`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		// An encrypted ledger keeps its passphrase, only the plain files left are encrypted
+		passphrase := ""
+		if root, err := storage.Root(); err != nil || !vault.Enabled(root) {
+			if passphrase, err = newPassphrase(); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+		}
+
+		count, err := storage.EncryptLedger(passphrase)
+		if err != nil {
+			color.Red("Error: %v", err)
+			if passphrase == "" {
+				sealBackups(passphrase)
+			}
+			return
+		}
+
+		// The search index holds the text of every row, it is rebuilt in memory from now on
+		if root, err := storage.Root(); err == nil {
+			os.Remove(search.GetIndexPath(root))
+		}
+
+		color.Green("✓ Encrypted %d files", count)
+		sealBackups(passphrase)
+		color.Yellow("Keep the passphrase safe, the ledger cannot be opened without it")
+		if commitLedger("encrypt ledger") {
+			color.Yellow("Earlier git commits still hold the plain files")
+		}
+	},
+}
+
+// DecryptCmd represents the decrypt command
+var DecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Turn an encrypted ledger back into plain Markdown",
+	Long: `Decrypt every file of an encrypted ledger and turn encryption off.
+
+With --export, a plain copy of the month files, _config and _pool is written
+to another directory and the ledger itself stays encrypted.
+
+Examples:
+  spendgrid decrypt
+  spendgrid decrypt --export ~/plain-copy`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if dir, _ := cmd.Flags().GetString("export"); dir != "" {
+			count, err := exportPlain(dir)
+			if err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			color.Green("✓ Exported %d files to %s", count, dir)
+			return
+		}
+
+		count, err := storage.DecryptLedger()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Decrypted %d files", count)
+		commitLedger("decrypt ledger")
+	},
+}
+
+// LockCmd represents the lock command
+var LockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Forget the remembered passphrase of an encrypted ledger",
+	Long: `Remove the key of the ledger from the key cache,
+the next command asks for the passphrase again.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		root, err := storage.Root()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if !vault.Enabled(root) {
+			color.Yellow("Ledger is not encrypted")
+			return
+		}
+
+		vault.Forget(root)
+		color.Green("✓ Passphrase forgotten")
+	},
+}
+
+// sealBackups encrypts the plain archives in the backup directory with the new passphrase
+// Without one, e.g. when the ledger was already encrypted, the plain archives are listed
+func sealBackups(passphrase string) {
+	dir := storage.Path(backup.DefaultDir)
+	if passphrase != "" {
+		sealed, err := backup.SealPlain(dir, passphrase)
+		if len(sealed) > 0 {
+			color.Green("✓ Encrypted %d backups with the same passphrase", len(sealed))
+		}
+		if err != nil {
+			color.Red("Error: %v", err)
+		}
+		return
+	}
+
+	plain, err := backup.PlainArchives(dir)
+	if err != nil {
+		color.Red("Error: %v", err)
+		return
+	}
+	if len(plain) > 0 {
+		color.Yellow("These backups were taken before encryption and are not encrypted:")
+		for _, a := range plain {
+			fmt.Printf("  %s\n", a.Path)
+		}
+	}
+}
+
+// newPassphrase returns SPENDGRID_PASSPHRASE or asks for a new passphrase twice
+func newPassphrase() (string, error) {
+	if passphrase := os.Getenv(vault.PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+
+	passphrase, err := vault.Prompt("New passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", fmt.Errorf("passphrase cannot be empty")
+	}
+	again, err := vault.Prompt("Repeat passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if again != passphrase {
+		return "", fmt.Errorf("passphrases do not match")
+	}
+	return passphrase, nil
+}
+
+// exportPlain writes the ledger files in plain text to dir, which must be empty or missing
+// Returns the number of files written
+func exportPlain(dir string) (int, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, fmt.Errorf("invalid directory: %v", err)
+	}
+	root, err := storage.Root()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current directory: %v", err)
+	}
+	if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+		return 0, fmt.Errorf("cannot export into the ledger itself")
+	}
+	if entries, err := os.ReadDir(abs); err == nil && len(entries) > 0 {
+		return 0, fmt.Errorf("%s is not empty", dir)
+	}
+
+	snap, err := journal.TakeSnapshot()
+	if err != nil {
+		return 0, err
+	}
+	versionPath := filepath.Join(ledger.StateDir, ledger.VersionFile)
+	version, err := os.ReadFile(storage.Path(versionPath))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read version file: %v", err)
+	}
+	snap[versionPath] = string(version)
+
+	for path, content := range snap {
+		target := filepath.Join(abs, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return 0, fmt.Errorf("failed to create %s: %v", filepath.Dir(target), err)
+		}
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			return 0, fmt.Errorf("failed to write %s: %v", target, err)
+		}
+	}
+	return len(snap), nil
+}
+
+func init() {
+	DecryptCmd.Flags().String("export", "", "Write a plain copy to this directory and keep the ledger encrypted")
+}
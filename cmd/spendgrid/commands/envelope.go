@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/envelope"
+)
+
+// EnvelopeCmd represents the envelope command
+var EnvelopeCmd = &cobra.Command{
+	Use:   "envelope [period]",
+	Short: "Zero-based envelope budgeting",
+	Long: `Assign income to envelopes and track what is available in each one.
+Envelopes are matched to rows by tag; expenses tagged with an envelope draw it down.
+Available amounts carry over to the next month.
+
+Examples:
+  spendgrid envelope
+  spendgrid envelope 2026-09
+  spendgrid envelope assign market 3000
+  spendgrid envelope move eglence market 200`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		period := ""
+		if len(args) > 0 {
+			period = args[0]
+		}
+
+		if err := envelope.ShowEnvelopes(period); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+var envelopeAssignCmd = &cobra.Command{
+	Use:   "assign <envelope> <amount>",
+	Short: "Assign income to an envelope",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		month, _ := cmd.Flags().GetString("month")
+		if err := envelope.Assign(args[0], args[1], month); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+var envelopeMoveCmd = &cobra.Command{
+	Use:   "move <from> <to> <amount>",
+	Short: "Move money between envelopes",
+	Args:  cobra.ExactArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		month, _ := cmd.Flags().GetString("month")
+		if err := envelope.Move(args[0], args[1], args[2], month); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+func init() {
+	envelopeAssignCmd.Flags().String("month", "", "Month to assign in (MM or YYYY-MM, default: current month)")
+	envelopeMoveCmd.Flags().String("month", "", "Month to move in (MM or YYYY-MM, default: current month)")
+
+	EnvelopeCmd.AddCommand(envelopeAssignCmd)
+	EnvelopeCmd.AddCommand(envelopeMoveCmd)
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"spendgrid/internal/exchange"
+	"spendgrid/internal/output"
 )
 
 // ExchangeCmd represents the exchange command
@@ -21,6 +22,17 @@ var ExchangeShowCmd = &cobra.Command{
 	Short: "Show current exchange rates",
 	Long:  `Display the current cached exchange rates.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			data, err := exchange.TodayRates()
+			if err == nil {
+				err = output.Write("exchange.rates", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := exchange.ShowRates(); err != nil {
 			color.Red("Error: %v", err)
 			return
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/forecast"
+)
+
+// ForecastCmd represents the forecast command
+var ForecastCmd = &cobra.Command{
+	Use:   "forecast",
+	Short: "Project cash flow and running balance for the coming months",
+	Long: `Combine actual rows, planned rule lines, rules not synced yet and dated pool items
+into a month-by-month projection in the base currency. The first month whose
+running balance goes negative is highlighted.
+
+Examples:
+  spendgrid forecast
+  spendgrid forecast --months 6
+  spendgrid forecast --opening 25000`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		months, _ := cmd.Flags().GetInt("months")
+
+		var opening *float64
+		if cmd.Flags().Changed("opening") {
+			value, _ := cmd.Flags().GetFloat64("opening")
+			opening = &value
+		}
+
+		if err := forecast.ShowForecast(months, opening); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+func init() {
+	ForecastCmd.Flags().Int("months", 12, "Number of months to project")
+	ForecastCmd.Flags().Float64("opening", 0, "Opening balance in base currency (default: computed from history)")
+}
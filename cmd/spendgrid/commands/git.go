@@ -0,0 +1,224 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/vcs"
+)
+
+// GitCmd represents the git command
+var GitCmd = &cobra.Command{
+	Use:   "git",
+	Short: "Version the ledger with git",
+	Long: `Keep the ledger in a git repository. Once enabled, every command that changes
+the ledger (add, sync, complete, rules, bulk, undo, ...) commits the files it
+changed with a message listing the rows added, removed and changed.
+
+The journal, lock and backups in .spendgrid are ignored.
+
+Examples:
+  spendgrid git enable
+  spendgrid git status
+  spendgrid log
+  spendgrid diff HEAD~3`,
+}
+
+// gitEnableCmd turns on automatic commits
+var gitEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Commit every change of the ledger",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		committed, err := vcs.Enable()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		if committed {
+			fmt.Println("Committed the current ledger files")
+		}
+		color.Green("✓ Changes of the ledger are committed to git")
+	},
+}
+
+// gitDisableCmd turns off automatic commits
+var gitDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Stop committing changes, the repository is kept",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := vcs.Disable(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Changes of the ledger are no longer committed")
+	},
+}
+
+// gitStatusCmd shows the git state of the ledger
+var gitStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether changes are committed and what is not committed yet",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		state, err := vcs.Status()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("git-status", state); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		if !state.Repository {
+			color.Yellow("Not a git repository, run 'spendgrid git enable'")
+			return
+		}
+		if state.AutoCommit {
+			color.Green("Automatic commits: on")
+		} else {
+			color.Yellow("Automatic commits: off")
+		}
+		if state.Head != "" {
+			fmt.Printf("Last commit: %s\n", state.Head)
+		}
+		if len(state.Uncommitted) == 0 {
+			fmt.Println("Nothing to commit")
+			return
+		}
+		fmt.Println("Not committed:")
+		for _, path := range state.Uncommitted {
+			fmt.Printf("  %s\n", path)
+		}
+	},
+}
+
+// gitMergeDriverCmd sets up the merge driver without turning on automatic commits
+var gitMergeDriverCmd = &cobra.Command{
+	Use:   "merge-driver",
+	Short: "Let git merge month files with 'spendgrid merge'",
+	Long: `Set 'spendgrid merge' as the git merge driver for month files.
+'spendgrid git enable' does this too; run it once in every clone of the ledger.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !vcs.IsRepo() {
+			color.Red("Error: not a git repository")
+			return
+		}
+		if err := vcs.InstallMergeDriver(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Month files are merged by spendgrid, commit .gitattributes to share it")
+	},
+}
+
+// LogCmd represents the log command
+var LogCmd = &cobra.Command{
+	Use:   "log [file]",
+	Short: "Show the committed change history of the ledger",
+	Long: `Show the commits that changed the ledger, newest first, with the rows added,
+removed and changed and the net amount per file. Give a file to see only its history.
+
+Examples:
+  spendgrid log
+  spendgrid log 2026/03.md -n 50
+  spendgrid log -o csv`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		path := ""
+		if len(args) == 1 {
+			path = args[0]
+		}
+
+		log, err := vcs.History(path, limit)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("log", log); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		vcs.ShowLog(log)
+	},
+}
+
+// DiffCmd represents the diff command
+var DiffCmd = &cobra.Command{
+	Use:   "diff [rev] [rev2]",
+	Short: "Show the transactions added, removed and changed since a commit",
+	Long: `Compare the ledger with a commit and list transactions instead of lines.
+A row whose amount, tags or state changed is shown as changed.
+
+Without a revision the working tree is compared with the last commit. With two
+revisions (or rev..rev2) the two commits are compared.
+
+Examples:
+  spendgrid diff
+  spendgrid diff HEAD~5
+  spendgrid diff v2026-q1 HEAD
+  spendgrid diff HEAD~2..HEAD -o csv`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		var from, to string
+		if len(args) > 0 {
+			from = args[0]
+		}
+		if len(args) > 1 {
+			to = args[1]
+		}
+
+		diff, err := vcs.DiffRevs(from, to)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("diff", diff); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		vcs.ShowDiff(diff)
+	},
+}
+
+// commitLedger commits the whole ledger after a change made outside the journal
+// Returns true if a commit was made
+func commitLedger(subject string) bool {
+	committed, err := vcs.CommitAll(subject)
+	if err != nil {
+		color.Yellow("Warning: git commit failed: %v", err)
+	}
+	return committed
+}
+
+func init() {
+	GitCmd.AddCommand(gitEnableCmd)
+	GitCmd.AddCommand(gitDisableCmd)
+	GitCmd.AddCommand(gitStatusCmd)
+	GitCmd.AddCommand(gitMergeDriverCmd)
+
+	LogCmd.Flags().IntP("limit", "n", 20, "Number of commits to show (0 for all)")
+}
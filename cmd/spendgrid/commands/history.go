@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/output"
+)
+
+// HistoryCmd represents the history command
+var HistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recorded changes",
+	Long: `Show the changes recorded in .spendgrid/journal, newest first.
+Undone changes can be applied again with 'spendgrid redo'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		items, err := journal.History(limit)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("history", items); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		journal.ShowHistory(items)
+	},
+}
+
+func init() {
+	HistoryCmd.Flags().IntP("limit", "n", 20, "Number of entries to show (0 for all)")
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"spendgrid/internal/investment"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 )
 
 // InvestmentsCmd represents the investments command
@@ -12,6 +14,17 @@ var InvestmentsCmd = &cobra.Command{
 	Short: "Show investment portfolio",
 	Long:  `Display your investment portfolio summary.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			data, err := investment.CalculatePortfolioFromTransactions(ledger.Current())
+			if err == nil {
+				err = output.Write("investments", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := investment.GenerateInvestmentReport(); err != nil {
 			color.Red("Error: %v", err)
 			return
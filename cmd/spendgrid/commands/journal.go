@@ -0,0 +1,30 @@
+package commands
+
+import "github.com/spf13/cobra"
+
+// journalAnnotation marks commands whose changes are recorded in the journal
+const journalAnnotation = "journal"
+
+// Journaled returns true if cmd or one of its parents records its changes in the journal
+func Journaled(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c.Annotations[journalAnnotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	// Commands that change the ledger, subcommands are included
+	// bulk records its own changes
+	for _, cmd := range []*cobra.Command{
+		AddCmd, QuickCmd, EditCmd, RemoveCmd, SyncCmd, RulesCmd, PoolCmd,
+		CompleteCmd, UncompleteCmd, CompleteMonthCmd, ReconcileCmd, EnvelopeCmd, TagsCmd,
+	} {
+		if cmd.Annotations == nil {
+			cmd.Annotations = make(map[string]string)
+		}
+		cmd.Annotations[journalAnnotation] = "true"
+	}
+}
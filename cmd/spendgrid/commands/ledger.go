@@ -0,0 +1,153 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/config"
+	"spendgrid/internal/output"
+	"spendgrid/internal/status"
+)
+
+// LedgerCmd represents the ledger command
+var LedgerCmd = &cobra.Command{
+	Use:   "ledger",
+	Short: "Manage named ledgers",
+	Long: `Register ledger directories under a name and switch between them.
+
+Any command can run on a named ledger with -L:
+  spendgrid -L work report
+
+Outside a ledger directory commands use the default ledger.`,
+}
+
+// LedgerAddCmd registers a ledger
+var LedgerAddCmd = &cobra.Command{
+	Use:   "add <name> <path>",
+	Short: "Register a ledger directory under a name",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.AddLedger(args[0], args[1]); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Ledger '%s' added: %s", args[0], config.ResolveLedger(args[0]))
+	},
+}
+
+// LedgerRemoveCmd unregisters a ledger
+var LedgerRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Unregister a ledger, its files are kept",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := config.RemoveLedger(args[0]); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Ledger '%s' removed", args[0])
+	},
+}
+
+// LedgerDefaultCmd shows or sets the default ledger
+var LedgerDefaultCmd = &cobra.Command{
+	Use:   "default [name]",
+	Short: "Show or set the ledger used outside a ledger directory",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		clear, _ := cmd.Flags().GetBool("clear")
+		switch {
+		case clear:
+			if err := config.SetDefaultLedger(""); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			color.Green("✓ Default ledger cleared")
+		case len(args) == 1:
+			if err := config.SetDefaultLedger(args[0]); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			color.Green("✓ Default ledger set to: %s", args[0])
+		default:
+			named, ok := config.GetDefaultLedger()
+			if !ok {
+				color.Yellow("No default ledger set")
+				return
+			}
+			fmt.Printf("%s  %s\n", named.Name, named.Path)
+		}
+	},
+}
+
+// LedgerListCmd lists the registered ledgers
+var LedgerListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered ledgers",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ledgers := config.GetLedgers()
+
+		if output.IsStructured() {
+			if err := output.Write("ledgers", ledgers); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		if len(ledgers) == 0 {
+			color.Yellow("No ledgers registered. Add one with: spendgrid ledger add <name> <path>")
+			return
+		}
+
+		fmt.Println()
+		for _, l := range ledgers {
+			marker := " "
+			if l.Default {
+				marker = "*"
+			}
+			fmt.Printf("%s %-14s %s\n", marker, l.Name, l.Path)
+		}
+		fmt.Println()
+	},
+}
+
+// LedgerSummaryCmd shows all registered ledgers side by side
+var LedgerSummaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "Show this month and year of every registered ledger",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		ledgers := config.GetLedgers()
+		if len(ledgers) == 0 {
+			color.Yellow("No ledgers registered. Add one with: spendgrid ledger add <name> <path>")
+			return
+		}
+
+		statuses, err := status.BuildLedgerStatuses(ledgers)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("ledger.summary", statuses); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		status.ShowLedgerStatuses(statuses)
+	},
+}
+
+func init() {
+	LedgerDefaultCmd.Flags().Bool("clear", false, "Clear the default ledger")
+
+	LedgerCmd.AddCommand(LedgerAddCmd)
+	LedgerCmd.AddCommand(LedgerRemoveCmd)
+	LedgerCmd.AddCommand(LedgerDefaultCmd)
+	LedgerCmd.AddCommand(LedgerListCmd)
+	LedgerCmd.AddCommand(LedgerSummaryCmd)
+}
@@ -3,6 +3,7 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/output"
 	"spendgrid/internal/transaction"
 )
 
@@ -18,6 +19,17 @@ var ListCmd = &cobra.Command{
 			month = args[0]
 		}
 
+		if output.IsStructured() {
+			data, err := transaction.LoadMonthListing(month)
+			if err == nil {
+				err = output.Write("list", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := transaction.ListTransactions(month); err != nil {
 			color.Red("Error: %v", err)
 			return
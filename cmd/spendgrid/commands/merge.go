@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/merge"
+	"spendgrid/internal/output"
+)
+
+// MergeCmd represents the merge command
+var MergeCmd = &cobra.Command{
+	Use:   "merge <ours> <theirs> [base]",
+	Short: "Merge two versions of a month file",
+	Long: `Merge two versions of a month file that were edited on different devices.
+The result is written to <ours>.
+
+Rows are matched by what they are instead of where they are: rule lines by rule
+ID, envelope lines by envelope, rows with an ID meta by ID and other rows by
+content. Rows added on either side, rules completed on either side and rows
+changed on one side merge on their own. Only a line changed differently on both
+sides, or changed on one side and removed on the other, is a conflict; it is
+written between <<<<<<< and >>>>>>> markers and the command exits with status 1.
+
+base is the version both started from. Without it nothing counts as removed,
+which suits conflict copies of a shared folder.
+
+'spendgrid git enable' sets this up as the git merge driver for month files,
+'spendgrid git merge-driver' does so for a repository without automatic commits.
+
+Examples:
+  spendgrid merge 2026/03.md 2026/03.sync-conflict.md
+  spendgrid merge ours.md theirs.md base.md --stdout`,
+	Args: cobra.RangeArgs(2, 3),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, _ := cmd.Flags().GetString("name")
+		stdout, _ := cmd.Flags().GetBool("stdout")
+
+		ours, sealed, err := merge.Load(args[0], name)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		theirs, _, err := merge.Load(args[1], name)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+		base := ""
+		if len(args) == 3 {
+			if base, _, err = merge.Load(args[2], name); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(2)
+			}
+		}
+
+		result := merge.Month(base, ours, theirs, merge.Labels{Ours: args[0], Theirs: args[1]})
+
+		if stdout {
+			fmt.Print(result.Content)
+		} else if err := merge.Store(args[0], name, result.Content, sealed); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(2)
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("merge", result); err != nil {
+				color.Red("Error: %v", err)
+			}
+		} else if !stdout {
+			label := args[0]
+			if name != "" {
+				label = name
+			}
+			fmt.Printf("%s: %d added, %d removed, %d changed from %s\n", label, result.Added, result.Removed, result.Changed, args[1])
+		}
+
+		if len(result.Conflicts) > 0 {
+			color.New(color.FgRed).Fprintf(os.Stderr, "%d conflicts, fix the lines between the markers\n", len(result.Conflicts))
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	MergeCmd.Flags().String("name", "", "Path of the file in the ledger, needed for encrypted files (git passes %P)")
+	MergeCmd.Flags().BoolP("stdout", "p", false, "Print the result instead of writing it to <ours>")
+}
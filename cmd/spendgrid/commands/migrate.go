@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/migrate"
+	"spendgrid/internal/output"
+)
+
+// MigrateCmd represents the migrate command
+var MigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the ledger to the current file format",
+	Long: `Upgrade the ledger files to the schema version of this spendgrid,
+or take them back to an older one with --to.
+
+A backup archive of the ledger is written to .spendgrid/backups first,
+and the migration can be undone with 'spendgrid undo'.
+
+Examples:
+  spendgrid migrate --dry-run
+  spendgrid migrate
+  spendgrid migrate --to 2`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		target, _ := cmd.Flags().GetInt("to")
+		if target == 0 {
+			target = ledger.SchemaVersion
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		plan, err := migrate.NewPlan(target)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("migrate", plan); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+		} else {
+			plan.Preview()
+		}
+		if dryRun || plan.Empty() {
+			return
+		}
+
+		backup, err := plan.Apply()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green("✓ Migrated to schema %d, backup: %s", plan.To, backup)
+	},
+}
+
+func init() {
+	MigrateCmd.Flags().Bool("dry-run", false, "Show the changes without writing them")
+	MigrateCmd.Flags().Int("to", 0, "Schema version to migrate to (default: the newest)")
+}
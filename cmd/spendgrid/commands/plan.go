@@ -7,6 +7,8 @@ import (
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/rules"
 )
 
@@ -24,6 +26,18 @@ var PlanCmd = &cobra.Command{
 			}
 		}
 
+		if output.IsStructured() {
+			year, month, incomeRules, expenseRules, err := loadPlanRules(month)
+			if err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			if err := output.Write("plan", newPlanReport(year, month, incomeRules, expenseRules)); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := showPlanReport(month); err != nil {
 			color.Red("Error: %v", err)
 			return
@@ -31,7 +45,60 @@ var PlanCmd = &cobra.Command{
 	},
 }
 
-func showPlanReport(month int) error {
+// planEntry is the progress of a single rule
+type planEntry struct {
+	ID        string  `json:"id" yaml:"id"`
+	Name      string  `json:"name" yaml:"name"`
+	Type      string  `json:"type" yaml:"type"`
+	Currency  string  `json:"currency" yaml:"currency"`
+	Planned   float64 `json:"planned" yaml:"planned"`
+	Actual    float64 `json:"actual" yaml:"actual"`
+	Remaining float64 `json:"remaining" yaml:"remaining"`
+}
+
+// planReport is the planned vs actual comparison of a month
+type planReport struct {
+	Year       int         `json:"year" yaml:"year"`
+	Month      int         `json:"month" yaml:"month"`
+	Rules      []planEntry `json:"rules" yaml:"rules"`
+	PlannedNet float64     `json:"planned_net" yaml:"planned_net"`
+	ActualNet  float64     `json:"actual_net" yaml:"actual_net"`
+}
+
+// Table returns the rules for CSV output
+func (r *planReport) Table() interface{} {
+	return r.Rules
+}
+
+func newPlanReport(year, month int, incomeRules, expenseRules []rules.Rule) *planReport {
+	report := &planReport{Year: year, Month: month, Rules: []planEntry{}}
+	for _, list := range [][]rules.Rule{incomeRules, expenseRules} {
+		for _, rule := range list {
+			entry := planEntry{
+				ID:        rule.ID,
+				Name:      rule.Name,
+				Type:      rule.Type,
+				Currency:  rule.Currency,
+				Planned:   rule.Amount,
+				Actual:    rule.Amount - rule.RemainingAmount,
+				Remaining: rule.RemainingAmount,
+			}
+			report.Rules = append(report.Rules, entry)
+
+			if rule.Type == "income" {
+				report.PlannedNet += entry.Planned
+				report.ActualNet += entry.Actual
+			} else {
+				report.PlannedNet -= entry.Planned
+				report.ActualNet -= entry.Actual
+			}
+		}
+	}
+	return report
+}
+
+// loadPlanRules updates the remaining amounts of the month and returns its rules by type
+func loadPlanRules(month int) (int, int, []rules.Rule, []rules.Rule, error) {
 	// Get current date
 	now := time.Now()
 	year := now.Year()
@@ -40,14 +107,14 @@ func showPlanReport(month int) error {
 	}
 
 	// Update remaining amounts
-	if err := rules.UpdateRemainingAmounts(year, month); err != nil {
-		return err
+	if err := rules.UpdateRemainingAmounts(ledger.Current(), year, month); err != nil {
+		return 0, 0, nil, nil, err
 	}
 
 	// Load all active rules
-	allRules, err := rules.GetActiveRules()
+	allRules, err := rules.GetActiveRules(ledger.Current())
 	if err != nil {
-		return err
+		return 0, 0, nil, nil, err
 	}
 
 	// Separate rules by type
@@ -60,6 +127,15 @@ func showPlanReport(month int) error {
 		}
 	}
 
+	return year, month, incomeRules, expenseRules, nil
+}
+
+func showPlanReport(month int) error {
+	year, month, incomeRules, expenseRules, err := loadPlanRules(month)
+	if err != nil {
+		return err
+	}
+
 	// Print header
 	color.Cyan("\n📊 Planlanan vs Gerçekleşen - %s %d", time.Month(month), year)
 	fmt.Println()
@@ -3,6 +3,8 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/pool"
 )
 
@@ -17,6 +19,17 @@ var PoolListCmd = &cobra.Command{
 	Short: "List all pool items",
 	Long:  `Display all items currently in the pool/backlog.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			items, err := pool.LoadItems(ledger.Current())
+			if err == nil {
+				err = output.Write("pool", items)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := pool.ShowPool(); err != nil {
 			color.Red("Error: %v", err)
 			return
@@ -45,7 +58,7 @@ var PoolMoveCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		lineNum := args[0]
 		month := args[1]
-		if err := pool.MovePoolItem(lineNum, month); err != nil {
+		if err := pool.MovePoolItem(ledger.Current(), lineNum, month); err != nil {
 			color.Red("Error: %v", err)
 			return
 		}
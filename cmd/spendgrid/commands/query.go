@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/config"
+	"spendgrid/internal/output"
+	"spendgrid/internal/query"
+)
+
+// QueryCmd represents the query command
+var QueryCmd = &cobra.Command{
+	Use:   "query <expr>",
+	Short: "Filter and aggregate rows across all years",
+	Long: `Run a query over every month file. Conditions are joined with and/or/not and
+parentheses; adjacent conditions are joined with and.
+
+Fields:
+  date, year, month, day      date = 2026-Q3, date >= 2026-07-01, month in (1, 2)
+  amount, abs                 amount < -500, abs > 500TRY (converted to TRY)
+  currency                    currency = USD
+  tag, project, account       #market, @tatil, &garanti, tag ~ "^yemek"
+  desc                        desc ~ "amazon|refund"
+  meta.<key>                  meta.ref, meta.installment >= 3
+  rule, completed, planned, income, expense
+
+Group and aggregate at the end, amounts are converted to the base currency:
+  group by tag, month
+  sum avg count min max
+
+Examples:
+  spendgrid query '#market abs > 500TRY date in 2026-Q3 currency = USD'
+  spendgrid query 'expense and not planned group by tag sum count'
+  spendgrid query 'desc ~ amazon' -o csv`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		q, err := query.Parse(strings.Join(args, " "))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		cur, _ := cmd.Flags().GetString("currency")
+		if cur == "" {
+			cur = config.GetBaseCurrency()
+		}
+
+		result, err := query.Run(q, strings.ToUpper(cur))
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("query", result); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		query.Show(result)
+	},
+}
+
+func init() {
+	QueryCmd.Flags().String("currency", "", "Currency of aggregated amounts (default: base currency)")
+}
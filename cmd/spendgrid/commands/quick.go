@@ -0,0 +1,29 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/transaction"
+)
+
+// QuickCmd represents the quick input command
+var QuickCmd = &cobra.Command{
+	Use:   "quick <input>",
+	Short: "Add a transaction from natural language input",
+	Long: `Add a transaction to the current month from a single line.
+The same input can be given without the command name.
+
+Examples:
+  spendgrid quick "-100TL market alışverişi #mutfak @ev"
+  spendgrid "-100TL market alışverişi #mutfak @ev"`,
+	Args:               cobra.MinimumNArgs(1),
+	DisableFlagParsing: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := transaction.AddQuickTransaction(strings.Join(args, " ")); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/reconcile"
+)
+
+// ReconcileCmd represents the reconcile command
+var ReconcileCmd = &cobra.Command{
+	Use:   "reconcile <&account>",
+	Short: "Reconcile an account against a statement",
+	Long: `Walk the unreconciled rows of an account, tick them off against a bank statement
+and store the result as a checkpoint.
+
+Examples:
+  spendgrid reconcile &garanti --statement-date 2026-09-30 --balance 12345.67TRY`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		statementDate, _ := cmd.Flags().GetString("statement-date")
+		balance, _ := cmd.Flags().GetString("balance")
+
+		if statementDate == "" || balance == "" {
+			color.Red("Error: --statement-date and --balance are required")
+			return
+		}
+
+		if err := reconcile.Reconcile(args[0], statementDate, balance); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+func init() {
+	ReconcileCmd.Flags().String("statement-date", "", "Statement closing date (YYYY-MM-DD)")
+	ReconcileCmd.Flags().String("balance", "", "Statement balance with currency (e.g. 12345.67TRY)")
+}
@@ -1,10 +1,18 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/config"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/reports"
 )
 
@@ -30,7 +38,20 @@ var ReportMonthlyCmd = &cobra.Command{
 			}
 		}
 
-		if err := reports.GenerateMonthlyReport(month); err != nil {
+		if output.IsStructured() {
+			data, err := reports.LoadMonthlyReport(ledger.Current(), month)
+			if err == nil {
+				err = output.Write("report.monthly", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		depth, _ := cmd.Flags().GetInt("depth")
+
+		if err := reports.GenerateMonthlyReport(month, depth); err != nil {
 			color.Red("Error: %v", err)
 			return
 		}
@@ -43,18 +64,124 @@ var ReportYearlyCmd = &cobra.Command{
 	Short: "Generate yearly report",
 	Long:  `Generate report for the entire year.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := reports.GenerateYearlyReport(); err != nil {
+		if output.IsStructured() {
+			data, err := reports.LoadYearlyReport(ledger.Current())
+			if err == nil {
+				err = output.Write("report.yearly", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		depth, _ := cmd.Flags().GetInt("depth")
+
+		if err := reports.GenerateYearlyReport(depth); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
+
+// ReportHouseholdCmd combines several ledgers into one report
+var ReportHouseholdCmd = &cobra.Command{
+	Use:   "household [ledger...]",
+	Short: "Generate a combined report of several ledgers",
+	Long: `Combine named ledgers (or ledger directories) into one monthly or yearly report.
+Without arguments all registered ledgers are combined.
+
+Rows tagged #transfer (or with [TRANSFER:<ledger>] meta) that have a matching
+opposite row in another ledger, e.g. an owner's draw, are left out of the totals.
+Base currency and categories come from the current ledger.
+
+Examples:
+  spendgrid report household
+  spendgrid report household personal work --year
+  spendgrid -L personal report household personal work --month 9`,
+	Run: func(cmd *cobra.Command, args []string) {
+		sources, err := householdSources(args)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		tags, _ := cmd.Flags().GetStringSlice("transfer-tag")
+		days, _ := cmd.Flags().GetInt("days")
+		base, _ := cmd.Flags().GetString("base")
+		if base == "" {
+			base = config.GetBaseCurrency()
+		}
+		opts := reports.HouseholdOptions{BaseCurrency: strings.ToUpper(base), TransferTags: tags, MaxDays: days}
+
+		yearly, _ := cmd.Flags().GetBool("year")
+		depth, _ := cmd.Flags().GetInt("depth")
+		now := time.Now()
+
+		if yearly {
+			data, err := reports.BuildHouseholdYearlyReport(sources, now.Year(), opts)
+			if err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+			if output.IsStructured() {
+				if err := output.Write("report.household.yearly", data); err != nil {
+					color.Red("Error: %v", err)
+				}
+				return
+			}
+			reports.PrintHouseholdYearlyReport(data, depth)
+			return
+		}
+
+		month, _ := cmd.Flags().GetInt("month")
+		if month < 1 || month > 12 {
+			month = int(now.Month())
+		}
+
+		data, err := reports.BuildHouseholdMonthlyReport(sources, now.Year(), month, opts)
+		if err != nil {
 			color.Red("Error: %v", err)
 			return
 		}
+		if output.IsStructured() {
+			if err := output.Write("report.household.monthly", data); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+		reports.PrintHouseholdMonthlyReport(data, depth)
 	},
 }
 
+// householdSources opens the given ledger names or directories, or all registered ledgers
+func householdSources(args []string) ([]reports.HouseholdSource, error) {
+	if len(args) == 0 {
+		for _, named := range config.GetLedgers() {
+			args = append(args, named.Name)
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("no ledgers registered. Add them with: spendgrid ledger add <name> <path>")
+		}
+	}
+
+	sources := make([]reports.HouseholdSource, 0, len(args))
+	for _, arg := range args {
+		path := config.ResolveLedger(arg)
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("unknown ledger '%s'", arg)
+		}
+		sources = append(sources, reports.HouseholdSource{Name: filepath.Base(arg), Ledger: ledger.Open(path)})
+	}
+	return sources, nil
+}
+
 // ReportWebCmd generates HTML report
 var ReportWebCmd = &cobra.Command{
 	Use:   "web",
 	Short: "Generate HTML report",
-	Long:  `Generate interactive HTML report for web browser.`,
+	Long: `Generate a self-contained HTML report in _share with inline SVG charts,
+base currency totals, planned rule rows and client-side filters.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		yearly, _ := cmd.Flags().GetBool("year")
 
@@ -70,6 +197,16 @@ func init() {
 	ReportCmd.AddCommand(ReportMonthlyCmd)
 	ReportCmd.AddCommand(ReportYearlyCmd)
 	ReportCmd.AddCommand(ReportWebCmd)
+	ReportCmd.AddCommand(ReportHouseholdCmd)
 
+	ReportMonthlyCmd.Flags().Int("depth", 0, "Category levels to show (0 = all)")
+	ReportYearlyCmd.Flags().Int("depth", 0, "Category levels to show (0 = all)")
 	ReportWebCmd.Flags().BoolP("year", "y", false, "Generate yearly HTML report")
+
+	ReportHouseholdCmd.Flags().BoolP("year", "y", false, "Combine the whole year")
+	ReportHouseholdCmd.Flags().IntP("month", "m", 0, "Month to combine (1-12, default current month)")
+	ReportHouseholdCmd.Flags().Int("depth", 0, "Category levels to show (0 = all)")
+	ReportHouseholdCmd.Flags().StringSlice("transfer-tag", []string{reports.DefaultTransferTag}, "Tags of rows that move money between the ledgers")
+	ReportHouseholdCmd.Flags().Int("days", 3, "Days a transfer may take to show up in the other ledger")
+	ReportHouseholdCmd.Flags().String("base", "", "Base currency of the combined report (default: the current ledger's)")
 }
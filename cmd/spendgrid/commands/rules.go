@@ -3,6 +3,8 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/rules"
 )
 
@@ -18,6 +20,17 @@ var RulesListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all rules",
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			ruleSet, err := rules.LoadRules(ledger.Current())
+			if err == nil {
+				err = output.Write("rules", ruleSet.Rules)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := rules.ListRules(); err != nil {
 			color.Red("Error: %v", err)
 			return
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/output"
+	"spendgrid/internal/search"
+)
+
+// SearchCmd represents the search command
+var SearchCmd = &cobra.Command{
+	Use:   "search <text>",
+	Short: "Search descriptions, tags, projects and meta across all years",
+	Long: `Fuzzy search every month file and the pool. Every word must match the description,
+a tag, a project, the account or a meta field; small typos and missing Turkish
+characters are tolerated. #word, @word and &word only match tags, projects and accounts.
+
+Hits are listed as file:line, best match first. The search index is kept under the
+XDG data directory and refreshed when a file changes.
+
+Examples:
+  spendgrid search amazon iade
+  spendgrid search "#market" migros
+  spendgrid search kira --limit 5`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		limit, _ := cmd.Flags().GetInt("limit")
+		rebuild, _ := cmd.Flags().GetBool("reindex")
+		text := strings.Join(args, " ")
+
+		hits, err := search.Search(text, limit, rebuild)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if output.IsStructured() {
+			if err := output.Write("search", hits); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
+		search.ShowResults(text, hits)
+	},
+}
+
+func init() {
+	SearchCmd.Flags().Int("limit", 20, "Maximum number of hits (0 = all)")
+	SearchCmd.Flags().Bool("reindex", false, "Rebuild the search index before searching")
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/api"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/web"
+)
+
+// tokenEnv holds the API token when --token is not given
+const tokenEnv = "SPENDGRID_API_TOKEN"
+
+// ServeCmd represents the serve command
+var ServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the ledger as a JSON API and a web dashboard",
+	Long: `Serve a JSON REST API of the ledger under /api/v1 for dashboards and phone
+shortcuts: transactions, rules, the pool, reports, forecasts, exchange rates and
+validation.
+
+A web dashboard is served at / with monthly and yearly charts, category drill-down,
+inline editing, rule management and the forecast. It is embedded in the binary and
+works offline. With a random token the printed URL passes it to the page, otherwise
+the page asks for the token once.
+
+Every API request needs the token as "Authorization: Bearer <token>". It is taken from
+--token or ` + tokenEnv + `; without either a random token is printed at start.
+
+Changes go through the same lock as the CLI and are journaled, so 'spendgrid undo'
+reverts them and git versioning commits them.
+
+Examples:
+  spendgrid serve
+  spendgrid serve --addr 127.0.0.1:9000 --token secret
+  curl -H "Authorization: Bearer secret" localhost:9000/api/v1/transactions`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		addr, _ := cmd.Flags().GetString("addr")
+		token, _ := cmd.Flags().GetString("token")
+		if token == "" {
+			token = os.Getenv(tokenEnv)
+		}
+		generated := token == ""
+		if generated {
+			var err error
+			if token, err = api.GenerateToken(); err != nil {
+				color.Red("Error: %v", err)
+				return
+			}
+		}
+
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+				color.Yellow("Warning: %s can be reached from other machines, anyone with the token can change the ledger", addr)
+			}
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle(api.Prefix+"/", api.New(ledger.Current(), token))
+		mux.Handle("/", web.Handler())
+
+		server := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			server.Shutdown(shutdown)
+		}()
+
+		dir, _ := storage.Root()
+		color.Green("✓ Serving %s on http://%s%s", dir, listener.Addr(), api.Prefix)
+		if generated {
+			fmt.Printf("Token: %s\n", token)
+			fmt.Printf("Dashboard: http://%s/#token=%s\n", listener.Addr(), url.QueryEscape(token))
+		} else {
+			// A token of the user is not printed, the dashboard asks for it
+			fmt.Printf("Dashboard: http://%s/\n", listener.Addr())
+		}
+		fmt.Println("Press Ctrl+C to stop")
+
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			color.Red("Error: %v", err)
+		}
+	},
+}
+
+func init() {
+	ServeCmd.Flags().String("addr", "127.0.0.1:8080", "Address to listen on")
+	ServeCmd.Flags().String("token", "", "Token clients must send (default $"+tokenEnv+" or a random one)")
+}
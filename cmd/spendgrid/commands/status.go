@@ -3,6 +3,8 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/status"
 )
 
@@ -12,6 +14,17 @@ var StatusCmd = &cobra.Command{
 	Short: "Show database status",
 	Long:  `Display the current status of the SpendGrid database including transaction counts, rules, and more.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			data, err := status.BuildStatus(ledger.Current())
+			if err == nil {
+				err = output.Write("status", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := status.ShowStatus(); err != nil {
 			color.Red("Error: %v", err)
 			return
@@ -3,6 +3,8 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/rules"
 )
 
@@ -12,14 +14,23 @@ var SyncCmd = &cobra.Command{
 	Short: "Sync rules to month files",
 	Long:  `Synchronize all active rules to the current and future month files.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		color.Yellow("Synchronizing rules...")
+		if !output.IsStructured() {
+			color.Yellow("Synchronizing rules...")
+		}
 
-		result, err := rules.SyncRules()
+		result, err := rules.SyncRules(ledger.Current())
 		if err != nil {
 			color.Red("Error: %v", err)
 			return
 		}
 
+		if output.IsStructured() {
+			if err := output.Write("sync", result); err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		color.Green("✓ Sync completed!")
 		color.White("  Added: %d", result.Added)
 		color.White("  Updated: %d", result.Updated)
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/category"
+	"spendgrid/internal/i18n"
+)
+
+// TagsCmd represents the tags command
+var TagsCmd = &cobra.Command{
+	Use:   "tags",
+	Short: "Manage tags",
+	Long:  `Manage the tags defined in _config/categories.yml.`,
+}
+
+var tagsMergeCmd = &cobra.Command{
+	Use:   "merge <old> <new>",
+	Short: "Rewrite a tag across all month files, rules and the pool",
+	Long: `Rewrite a tag and its sub-tags to a new name in every month file,
+rules.yml, the pool and categories.yml.
+
+Examples:
+  spendgrid tags merge mutfk mutfak
+  spendgrid tags merge restoran yemek:restoran`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		result, err := category.MergeTags(args[0], args[1])
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		color.Green(i18n.T("tags.merged"), args[0], args[1])
+		fmt.Println(i18n.Tfmt("tags.merged_files", result.Files, result.Lines))
+		fmt.Println(i18n.Tfmt("tags.merged_rules", result.Rules))
+		fmt.Println(i18n.Tfmt("tags.merged_pool", result.PoolLines))
+	},
+}
+
+func init() {
+	TagsCmd.AddCommand(tagsMergeCmd)
+}
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/tui"
+)
+
+// TuiCmd represents the tui command
+var TuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and edit the ledger in a full-screen terminal app",
+	Long: `Open a full-screen view of a month with its rows and rule lines, the pool and a
+sidebar with the totals of the month and the state of the budgets.
+
+Move between months with ←/→ and between years with PgUp/PgDn, add rows as quick
+input, edit a row as it is written in the month file, complete rule lines with
+Space and move pool items into the month on screen. Press ? for all keys.
+
+Every change is journaled on its own, so 'spendgrid undo' (or u in the app)
+reverts it.
+
+Examples:
+  spendgrid tui
+  spendgrid -L home tui`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ledger.EnsureInitialized(); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+
+		if err := tui.Run(ledger.Current()); err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+	},
+}
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/journal"
+)
+
+// UndoCmd represents the undo command
+var UndoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Revert the last recorded change",
+	Long: `Revert the last change recorded in .spendgrid/journal. Run it again to
+revert older changes, see 'spendgrid history'.
+
+Files edited since the change are not overwritten; undo stops with an error instead.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := journal.Undo()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green(i18n.T("undo.done"), entry.Summary)
+	},
+}
+
+// RedoCmd represents the redo command
+var RedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Apply the last undone change again",
+	Long: `Apply the last change reverted with 'spendgrid undo' again.
+
+Redo is only possible until a new change is made.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		entry, err := journal.Redo()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
+		color.Green(i18n.T("undo.redone"), entry.Summary)
+	},
+}
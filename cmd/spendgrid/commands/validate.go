@@ -3,6 +3,8 @@ package commands
 import (
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
 	"spendgrid/internal/validator"
 )
 
@@ -12,6 +14,17 @@ var ValidateCmd = &cobra.Command{
 	Short: "Validate all files",
 	Long:  `Validate all SpendGrid files for errors and inconsistencies.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if output.IsStructured() {
+			data, err := validator.Validate(ledger.Current())
+			if err == nil {
+				err = output.Write("validate", data)
+			}
+			if err != nil {
+				color.Red("Error: %v", err)
+			}
+			return
+		}
+
 		if err := validator.ValidateAll(); err != nil {
 			color.Red("Error: %v", err)
 			return
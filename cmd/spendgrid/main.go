@@ -3,13 +3,22 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 	"spendgrid/cmd/spendgrid/commands"
 	"spendgrid/internal/config"
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/migrate"
+	"spendgrid/internal/output"
 	"spendgrid/internal/rules"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/transaction"
+	"spendgrid/internal/vault"
+	"spendgrid/internal/vcs"
 )
 
 // version is set during build using -ldflags
@@ -32,11 +41,79 @@ Track your income, expenses, and budgets with simple markdown files.
 			os.Exit(1)
 		}
 
-		// Auto-sync rules (except for init, version, and help commands)
-		if cmd.Name() != "init" && cmd.Name() != "version" && cmd.Name() != "help" {
-			if _, err := rules.SyncRules(); err != nil {
-				// Silent fail - don't block user on sync errors
-				fmt.Fprintf(os.Stderr, "Warning: auto-sync failed: %v\n", err)
+		// Structured output keeps stdout for data, messages go to stderr
+		format, _ := cmd.Flags().GetString("output")
+		if err := output.SetFormat(format); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if output.IsStructured() {
+			color.Output = os.Stderr
+		}
+
+		// The passphrase of an encrypted ledger is remembered for the configured time
+		vault.Timeout = config.GetKeyTimeout()
+
+		// --ledger/-L runs the command on a named ledger or a ledger in another directory
+		if name, _ := cmd.Flags().GetString("ledger"); name != "" {
+			if err := useLedger(config.ResolveLedger(name)); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		} else if named, ok := config.GetDefaultLedger(); ok && usesDefaultLedger(cmd) {
+			if _, err := os.Stat(ledger.StateDir); os.IsNotExist(err) {
+				if err := useLedger(named.Path); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: default ledger '%s': %v\n", named.Name, err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		// A ledger written by a newer spendgrid must not be touched, an older one still works
+		if _, err := storage.Stat(ledger.StateDir); err == nil && usesDefaultLedger(cmd) {
+			if err := migrate.CheckCompatible(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if version, _, err := migrate.CurrentVersion(); err == nil && version < ledger.SchemaVersion && cmd.Name() != "migrate" {
+				color.New(color.FgYellow).Fprintf(os.Stderr, "Ledger uses schema %d, run 'spendgrid migrate' to upgrade to %d\n", version, ledger.SchemaVersion)
+			}
+
+			// An encrypted ledger is unlocked once, before any of its files is read
+			if root, err := storage.Root(); err == nil && vault.Enabled(root) && !staysLocked(cmd) {
+				if _, err := vault.Unlock(root); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+		}
+
+		// Auto-sync rules (except for init, version, help, journal, migrate, restore and merge
+		// commands, and commands that leave an encrypted ledger locked)
+		if cmd.Name() != "init" && cmd.Name() != "version" && cmd.Name() != "help" &&
+			cmd.Name() != "undo" && cmd.Name() != "redo" && cmd.Name() != "history" && cmd.Name() != "migrate" &&
+			cmd.Name() != "restore" && cmd.Name() != "merge" && !staysLocked(cmd) {
+			// Older ledgers get a state directory first so the lock can be taken
+			if _, err := storage.Stat(ledger.StateDir); err == nil {
+				if err := ledger.EnsureStateDir(); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				}
+			}
+
+			// The lock keeps a second terminal from syncing the same months at the same time
+			if err := storage.Lock(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: auto-sync skipped: %v\n", err)
+			} else {
+				autoSync()
+				storage.Unlock()
+			}
+		}
+
+		// Snapshot the ledger so the changes of the command can be undone
+		if commands.Journaled(cmd) {
+			var err error
+			if commandRecorder, err = journal.Begin(strings.TrimPrefix(cmd.CommandPath(), "spendgrid ")); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: journal failed: %v\n", err)
 			}
 		}
 
@@ -48,17 +125,136 @@ Track your income, expenses, and budgets with simple markdown files.
 			}
 		}
 	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		if commandRecorder == nil {
+			return
+		}
+		summary := strings.TrimSpace(strings.TrimPrefix(cmd.CommandPath(), "spendgrid ") + " " + strings.Join(args, " "))
+		if _, err := commandRecorder.Finish(summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: journal failed: %v\n", err)
+		}
+	},
+}
+
+// useLedger makes the ledger directory at path the one commands work on
+func useLedger(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("cannot open ledger: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("cannot open ledger: %s is not a directory", path)
+	}
+
+	// Ledger, config, journal and lock paths are relative to the ledger root
+	if err := storage.SetRoot(path); err != nil {
+		return fmt.Errorf("cannot open ledger: %v", err)
+	}
+	return nil
+}
+
+// usesDefaultLedger returns false for commands that do not work on a ledger
+// or, like init, work on the current directory on purpose
+func usesDefaultLedger(cmd *cobra.Command) bool {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	switch cmd.Name() {
+	case "init", "ledger", "last", "config", "version", "help", "completion", "merge":
+		return false
+	}
+	return true
+}
+
+// staysLocked returns true if the command must not unlock an encrypted ledger in the root directory
+func staysLocked(cmd *cobra.Command) bool {
+	root, err := storage.Root()
+	if err != nil || !vault.Enabled(root) {
+		return false
+	}
+	return cmd.Name() == "lock" || !usesDefaultLedger(cmd)
+}
+
+// autoSync adds rule lines to the month files and journals them on their own
+func autoSync() {
+	recorder, err := journal.Begin("sync")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: journal failed: %v\n", err)
+	}
+
+	if _, err := rules.SyncRules(ledger.Current()); err != nil {
+		// Silent fail - don't block user on sync errors
+		fmt.Fprintf(os.Stderr, "Warning: auto-sync failed: %v\n", err)
+	}
+
+	if recorder != nil {
+		if _, err := recorder.Finish("auto-sync"); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: journal failed: %v\n", err)
+		}
+	}
 }
 
+// commandRecorder records the changes of a journaled command
+var commandRecorder *journal.Recorder
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 func Execute() {
+	// Quick input: spendgrid "-100TL market #mutfak"
+	if args := os.Args[1:]; len(args) > 0 {
+		if cmd, _, err := rootCmd.Find(args); (err != nil || cmd == rootCmd) && transaction.LooksLikeQuickInput(args[0]) {
+			rootCmd.SetArgs(append([]string{"quick"}, args...))
+		} else if rest, ok := leadingFlags(args); ok && len(rest) > 0 {
+			// quick does not parse flags, so spendgrid -L work "-100TL market" sets them here
+			if rest[0] == "quick" {
+				rootCmd.SetArgs(rest)
+			} else if transaction.LooksLikeQuickInput(rest[0]) {
+				rootCmd.SetArgs(append([]string{"quick"}, rest...))
+			}
+		}
+	}
+
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 }
 
+// leadingFlags sets the --ledger and --output flags given before the command
+// and returns the remaining arguments, ok is false if there were none
+func leadingFlags(args []string) ([]string, bool) {
+	found := false
+	for len(args) > 0 {
+		name, value, hasValue := strings.Cut(args[0], "=")
+		switch name {
+		case "-L", "--ledger":
+			name = "ledger"
+		case "-o", "--output":
+			name = "output"
+		default:
+			return args, found
+		}
+
+		if !hasValue {
+			if len(args) < 2 {
+				return args, found
+			}
+			value = args[1]
+			args = args[1:]
+		}
+		args = args[1:]
+
+		if err := rootCmd.PersistentFlags().Set(name, value); err != nil {
+			return args, found
+		}
+		found = true
+	}
+	return args, found
+}
+
 func init() {
+	rootCmd.PersistentFlags().StringP("output", "o", output.Text, "Output format: text, json, csv or yaml")
+	rootCmd.PersistentFlags().StringP("ledger", "L", "", "Ledger name or directory to use instead of the current directory")
+
 	// Add all commands to root
 	rootCmd.AddCommand(commands.InitCmd)
 	rootCmd.AddCommand(commands.StatusCmd)
@@ -68,6 +264,7 @@ func init() {
 	rootCmd.AddCommand(commands.RemoveCmd)
 	rootCmd.AddCommand(commands.SyncCmd)
 	rootCmd.AddCommand(commands.LastCmd)
+	rootCmd.AddCommand(commands.LedgerCmd)
 	rootCmd.AddCommand(commands.RulesCmd)
 	rootCmd.AddCommand(commands.ValidateCmd)
 	rootCmd.AddCommand(commands.InvestmentsCmd)
@@ -79,6 +276,37 @@ func init() {
 	rootCmd.AddCommand(commands.CompleteCmd)
 	rootCmd.AddCommand(commands.UncompleteCmd)
 	rootCmd.AddCommand(commands.CompleteMonthCmd)
+	rootCmd.AddCommand(commands.ReconcileCmd)
+	rootCmd.AddCommand(commands.ForecastCmd)
+	rootCmd.AddCommand(commands.BudgetCmd)
+	rootCmd.AddCommand(commands.EnvelopeCmd)
+	rootCmd.AddCommand(commands.QuickCmd)
+	rootCmd.AddCommand(commands.TagsCmd)
+	rootCmd.AddCommand(commands.QueryCmd)
+	rootCmd.AddCommand(commands.SearchCmd)
+	rootCmd.AddCommand(commands.BulkCmd)
+	rootCmd.AddCommand(commands.UndoCmd)
+	rootCmd.AddCommand(commands.RedoCmd)
+	rootCmd.AddCommand(commands.HistoryCmd)
+	rootCmd.AddCommand(commands.MigrateCmd)
+	rootCmd.AddCommand(commands.EncryptCmd)
+	rootCmd.AddCommand(commands.DecryptCmd)
+	rootCmd.AddCommand(commands.LockCmd)
+	rootCmd.AddCommand(commands.BackupCmd)
+	rootCmd.AddCommand(commands.RestoreCmd)
+	rootCmd.AddCommand(commands.GitCmd)
+	rootCmd.AddCommand(commands.LogCmd)
+	rootCmd.AddCommand(commands.DiffCmd)
+	rootCmd.AddCommand(commands.MergeCmd)
+	rootCmd.AddCommand(commands.ServeCmd)
+	rootCmd.AddCommand(commands.TuiCmd)
+
+	// Changes of the ledger are committed if git versioning is enabled
+	journal.OnChange = func(subject string, changes []journal.FileChange) {
+		if err := vcs.Commit(subject, changes); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: git commit failed: %v\n", err)
+		}
+	}
 }
 
 func main() {
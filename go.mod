@@ -3,14 +3,19 @@ module spendgrid
 go 1.25.6
 
 require (
-	github.com/adrg/xdg v0.5.3 // indirect
-	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203 // indirect
-	github.com/fatih/color v1.18.0 // indirect
+	github.com/adrg/xdg v0.5.3
+	github.com/eiannone/keyboard v0.0.0-20220611211555-0d226195f203
+	github.com/fatih/color v1.18.0
+	github.com/spf13/cobra v1.10.2
+	golang.org/x/crypto v0.47.0
+	golang.org/x/term v0.39.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.14 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/spf13/cobra v1.10.2 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
 	golang.org/x/sys v0.40.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"spendgrid/internal/currency"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/pool"
+	"spendgrid/internal/transaction"
+)
+
+// poolInput is the body of a request that adds a backlog item
+type poolInput struct {
+	Description string   `json:"description"`
+	Amount      float64  `json:"amount"`
+	Currency    string   `json:"currency"`
+	Month       string   `json:"month"` // expected month, YYYY-MM
+	Tags        []string `json:"tags"`
+}
+
+// moveInput is the body of a request that moves a backlog item to a month
+type moveInput struct {
+	Month string `json:"month"` // YYYY-MM
+	Day   int    `json:"day"`   // optional, the day of the item or 1 otherwise
+}
+
+// itemNumber returns the number of the backlog item in the path, 1 is the first item
+func itemNumber(r *http.Request) (int, error) {
+	n, err := strconv.Atoi(r.PathValue("n"))
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid item number: %s", r.PathValue("n"))
+	}
+	return n, nil
+}
+
+// listPool returns the backlog items, their position in the list is their number
+func (s *Server) listPool(w http.ResponseWriter, r *http.Request) {
+	items, err := pool.LoadItems(s.l)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "pool", items)
+}
+
+// addPoolItem adds an item to the end of the backlog
+func (s *Server) addPoolItem(w http.ResponseWriter, r *http.Request) {
+	var in poolInput
+	if err := decode(r, &in); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	item := &pool.Item{Description: in.Description, Amount: in.Amount, Tags: trimAll(in.Tags, "#")}
+	if in.Currency != "" {
+		item.Currency = currency.Normalize(in.Currency)
+	}
+	if in.Month != "" {
+		t, err := time.Parse("2006-01", in.Month)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid month: %s (use YYYY-MM)", in.Month))
+			return
+		}
+		item.Year, item.Month = t.Year(), int(t.Month())
+	}
+
+	err := s.change("pool add "+item.Description, func() error {
+		return pool.AddItem(s.l, item)
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	items, err := pool.LoadItems(s.l)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusCreated, "pool.item", items[len(items)-1])
+}
+
+// removePoolItem removes an item from the backlog and returns it
+func (s *Server) removePoolItem(w http.ResponseWriter, r *http.Request) {
+	n, err := itemNumber(r)
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var item *pool.Item
+	err = s.change(fmt.Sprintf("pool remove %d", n), func() error {
+		item, err = pool.RemoveItem(s.l, n)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusOK, "pool.item", item)
+}
+
+// movePoolItem moves an item from the backlog to a month and returns the new row
+func (s *Server) movePoolItem(w http.ResponseWriter, r *http.Request) {
+	n, err := itemNumber(r)
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var in moveInput
+	if err := decode(r, &in); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	t, err := time.Parse("2006-01", in.Month)
+	if err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("invalid month: %s (use YYYY-MM)", in.Month))
+		return
+	}
+	year, month := t.Year(), int(t.Month())
+
+	days := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if in.Day < 0 || in.Day > days {
+		fail(w, http.StatusBadRequest, fmt.Errorf("invalid day: %d", in.Day))
+		return
+	}
+
+	var row *transaction.Row
+	err = s.change(fmt.Sprintf("pool move %d %s", n, in.Month), func() error {
+		tx, err := pool.MoveItem(s.l, n, year, month, in.Day)
+		if err != nil {
+			return err
+		}
+		rows, err := transaction.MonthRows(s.l, year, month)
+		if err != nil {
+			return err
+		}
+		// The moved row is the last one with its content
+		for _, candidate := range rows {
+			if parser.FormatTransaction(candidate.Transaction) == parser.FormatTransaction(tx) {
+				row = candidate
+			}
+		}
+		if row == nil {
+			return fmt.Errorf("moved row not found in %s", in.Month)
+		}
+		return nil
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusOK, "transaction", row)
+}
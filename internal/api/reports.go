@@ -0,0 +1,219 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/config"
+	"spendgrid/internal/currency"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/forecast"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/reports"
+	"spendgrid/internal/validator"
+)
+
+// Conversion is the result of converting an amount to another currency
+type Conversion struct {
+	Amount float64 `json:"amount" yaml:"amount"`
+	From   string  `json:"from" yaml:"from"`
+	To     string  `json:"to" yaml:"to"`
+	Date   string  `json:"date" yaml:"date"`
+	Result float64 `json:"result" yaml:"result"`
+}
+
+// rateInput is the body of a request that sets a manual exchange rate
+type rateInput struct {
+	Rate float64 `json:"rate"` // value of one unit in TRY
+}
+
+// monthlyReport returns the report of a month, ?period=YYYY-MM, the current month by default
+func (s *Server) monthlyReport(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	year, month := now.Year(), int(now.Month())
+	if period := r.URL.Query().Get("period"); period != "" {
+		t, err := time.Parse("2006-01", period)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid period: %s (use YYYY-MM)", period))
+			return
+		}
+		year, month = t.Year(), int(t.Month())
+	}
+
+	if !s.l.Exists(ledger.MonthPath(year, month)) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no month file for %04d-%02d", year, month))
+		return
+	}
+
+	report, _, err := reports.BuildMonthlyReport(s.l, year, month)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "report.monthly", report)
+}
+
+// yearlyReport returns the report of a year, ?year=YYYY, the current year by default
+func (s *Server) yearlyReport(w http.ResponseWriter, r *http.Request) {
+	year := time.Now().Year()
+	if value := r.URL.Query().Get("year"); value != "" {
+		y, err := strconv.Atoi(value)
+		if err != nil || len(value) != 4 {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid year: %s", value))
+			return
+		}
+		year = y
+	}
+
+	writeData(w, http.StatusOK, "report.yearly", reports.BuildYearlyReport(s.l, year))
+}
+
+// forecastReport returns the cash flow projection, ?months= (12 by default) and ?opening= like the forecast command
+func (s *Server) forecastReport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	months := 12
+	if value := params.Get("months"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 120 {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid months: %s", value))
+			return
+		}
+		months = n
+	}
+	var opening *float64
+	if value := params.Get("opening"); value != "" {
+		balance, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid opening balance: %s", value))
+			return
+		}
+		opening = &balance
+	}
+
+	fc, err := forecast.Build(months, opening)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "forecast", fc)
+}
+
+// listRates returns the cached exchange rates of a day, ?date=YYYY-MM-DD, today by default
+func (s *Server) listRates(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", date); err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", date))
+		return
+	}
+
+	table, err := exchange.DayRates(date)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "exchange.rates", table)
+}
+
+// convert converts ?amount= from ?from= to ?to= (the base currency by default) at ?date=
+// Rates that are not cached are fetched
+func (s *Server) convert(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	amount, err := strconv.ParseFloat(params.Get("amount"), 64)
+	if err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("invalid amount: %s", params.Get("amount")))
+		return
+	}
+	from := currency.Normalize(params.Get("from"))
+	if from == "" {
+		fail(w, http.StatusBadRequest, fmt.Errorf("from is required"))
+		return
+	}
+	to := config.GetBaseCurrency()
+	if params.Get("to") != "" {
+		to = currency.Normalize(params.Get("to"))
+	}
+	date := time.Now()
+	if value := params.Get("date"); value != "" {
+		if date, err = time.Parse("2006-01-02", value); err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", value))
+			return
+		}
+	}
+
+	result, err := exchange.ConvertAmount(amount, from, to, date)
+	if err != nil {
+		fail(w, http.StatusBadGateway, err)
+		return
+	}
+	writeData(w, http.StatusOK, "exchange.conversion", &Conversion{
+		Amount: amount,
+		From:   from,
+		To:     to,
+		Date:   date.Format("2006-01-02"),
+		Result: result,
+	})
+}
+
+// refreshRates fetches the rates of today and returns them
+func (s *Server) refreshRates(w http.ResponseWriter, r *http.Request) {
+	if err := exchange.RefreshRates(); err != nil {
+		fail(w, http.StatusBadGateway, err)
+		return
+	}
+
+	table, err := exchange.TodayRates()
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "exchange.rates", table)
+}
+
+// setRate sets a manual exchange rate of a currency for a day
+func (s *Server) setRate(w http.ResponseWriter, r *http.Request) {
+	date := r.PathValue("date")
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", date))
+		return
+	}
+	code := strings.ToUpper(currency.Normalize(r.PathValue("currency")))
+
+	var in rateInput
+	if err := decode(r, &in); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if in.Rate <= 0 {
+		fail(w, http.StatusBadRequest, fmt.Errorf("rate must be positive"))
+		return
+	}
+
+	if err := exchange.SetManualRate(date, code, in.Rate); err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	table, err := exchange.DayRates(date)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "exchange.rates", table)
+}
+
+// validate checks the month files of the current year and the config files
+func (s *Server) validate(w http.ResponseWriter, r *http.Request) {
+	result, err := validator.Validate(s.l)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "validate", result)
+}
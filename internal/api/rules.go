@@ -0,0 +1,157 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"spendgrid/internal/currency"
+	"spendgrid/internal/rules"
+)
+
+// checkRule validates a rule sent by a client and normalizes its currency
+func checkRule(rule *rules.Rule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if strings.ContainsAny(rule.Name, "|[]\n") {
+		return fmt.Errorf("name cannot contain '|', '[', ']' or line breaks")
+	}
+	rule.Type = strings.ToLower(rule.Type)
+	if rule.Type != "income" && rule.Type != "expense" {
+		return fmt.Errorf("type must be 'income' or 'expense'")
+	}
+	if rule.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	rule.Currency = currency.Normalize(rule.Currency)
+	if rule.Schedule.Frequency != "" && rule.Schedule.Frequency != "monthly" {
+		return fmt.Errorf("only monthly rules are supported")
+	}
+	if rule.Schedule.Day < 1 || rule.Schedule.Day > 31 {
+		return fmt.Errorf("invalid day: %d", rule.Schedule.Day)
+	}
+	return nil
+}
+
+// listRules returns all rules
+func (s *Server) listRules(w http.ResponseWriter, r *http.Request) {
+	ruleSet, err := rules.LoadRules(s.l)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "rules", ruleSet.Rules)
+}
+
+// getRule returns a single rule
+func (s *Server) getRule(w http.ResponseWriter, r *http.Request) {
+	rule, err := rules.GetRule(s.l, r.PathValue("id"))
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "rule", rule)
+}
+
+// createRule adds a rule and syncs it into the month files
+// Left out fields default to an active monthly rule on day 1 with a generated ID
+func (s *Server) createRule(w http.ResponseWriter, r *http.Request) {
+	rule := rules.Rule{Active: true, Schedule: rules.Schedule{Frequency: "monthly", Day: 1}}
+	if err := decode(r, &rule); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := checkRule(&rule); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if rule.ID == "" {
+		rule.ID = rules.GenerateRuleID(rule.Name)
+	}
+
+	err := s.change("rules add "+rule.Name, func() error {
+		if err := rules.AddRule(s.l, rule); err != nil {
+			return err
+		}
+		_, err := rules.SyncRules(s.l)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusCreated, "rule", rule)
+}
+
+// updateRule changes the given fields of a rule and syncs the month files
+func (s *Server) updateRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	existing, err := rules.GetRule(s.l, id)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	rule := *existing
+	if err := decode(r, &rule); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if rule.ID != id {
+		fail(w, http.StatusBadRequest, fmt.Errorf("the ID of a rule cannot be changed"))
+		return
+	}
+	if err := checkRule(&rule); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	err = s.change("rules edit "+rule.Name, func() error {
+		if err := rules.UpdateRule(s.l, id, rule); err != nil {
+			return err
+		}
+		_, err := rules.SyncRules(s.l)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusOK, "rule", rule)
+}
+
+// deleteRule removes a rule, its lines in the month files are kept
+func (s *Server) deleteRule(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	rule, err := rules.GetRule(s.l, id)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = s.change("rules remove "+rule.Name, func() error {
+		return rules.DeleteRule(s.l, id)
+	})
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "rule", rule)
+}
+
+// syncRules adds the lines of the active rules to the current and future month files
+func (s *Server) syncRules(w http.ResponseWriter, r *http.Request) {
+	var result *rules.SyncResult
+	err := s.change("sync", func() error {
+		var err error
+		result, err = rules.SyncRules(s.l)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "rules.sync", result)
+}
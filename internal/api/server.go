@@ -0,0 +1,210 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/transaction"
+)
+
+// Prefix is the path every endpoint of the API starts with
+const Prefix = "/api/v1"
+
+// maxBody is the largest request body accepted, in bytes
+const maxBody = 1 << 20
+
+// Server serves the JSON API of a ledger
+type Server struct {
+	l     *ledger.Ledger
+	token string
+	mux   *http.ServeMux
+
+	// storage.Lock nests within a process, so requests take turns here
+	// before the ledger is locked against other spendgrid processes
+	mu sync.Mutex
+}
+
+// New returns the API server of a ledger, requests must carry the token
+func New(l *ledger.Ledger, token string) *Server {
+	s := &Server{l: l, token: token, mux: http.NewServeMux()}
+
+	s.route("GET /transactions", s.listTransactions)
+	s.route("POST /transactions", s.createTransaction)
+	s.route("GET /transactions/{id}", s.getTransaction)
+	s.route("PUT /transactions/{id}", s.updateTransaction)
+	s.route("PATCH /transactions/{id}", s.updateTransaction)
+	s.route("DELETE /transactions/{id}", s.deleteTransaction)
+
+	s.route("GET /rules", s.listRules)
+	s.route("POST /rules", s.createRule)
+	s.route("POST /rules/sync", s.syncRules)
+	s.route("GET /rules/{id}", s.getRule)
+	s.route("PUT /rules/{id}", s.updateRule)
+	s.route("PATCH /rules/{id}", s.updateRule)
+	s.route("DELETE /rules/{id}", s.deleteRule)
+
+	s.route("GET /pool", s.listPool)
+	s.route("POST /pool", s.addPoolItem)
+	s.route("DELETE /pool/{n}", s.removePoolItem)
+	s.route("POST /pool/{n}/move", s.movePoolItem)
+
+	s.route("GET /reports/monthly", s.monthlyReport)
+	s.route("GET /reports/yearly", s.yearlyReport)
+	s.route("GET /forecast", s.forecastReport)
+
+	s.route("GET /rates", s.listRates)
+	s.route("GET /rates/convert", s.convert)
+	s.route("POST /rates/refresh", s.refreshRates)
+	s.route("PUT /rates/{date}/{currency}", s.setRate)
+
+	s.route("GET /validate", s.validate)
+
+	return s
+}
+
+// route registers a handler for a method and a path below Prefix
+func (s *Server) route(pattern string, handler http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.mux.HandleFunc(method+" "+Prefix+path, handler)
+}
+
+// GenerateToken returns a random token for a server started without one
+func GenerateToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ServeHTTP checks the token and serves one request at a time
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	defer func() {
+		log.Printf("%s %s %d", r.Method, r.URL.RequestURI(), rec.status)
+	}()
+
+	if !s.authorized(r) {
+		rec.Header().Set("WWW-Authenticate", `Bearer realm="spendgrid"`)
+		writeError(rec, http.StatusUnauthorized, fmt.Errorf("missing or wrong token"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mux.ServeHTTP(rec, r)
+}
+
+// authorized reports whether a request carries the token as "Authorization: Bearer <token>"
+func (s *Server) authorized(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) == 1
+}
+
+// change runs fn with the ledger locked and journals what it changed as one entry,
+// so an API change can be undone and is committed like the change of a command
+func (s *Server) change(summary string, fn func() error) error {
+	if err := s.l.EnsureInitialized(); err != nil {
+		return err
+	}
+	if err := storage.Lock(); err != nil {
+		return err
+	}
+	defer storage.Unlock()
+
+	recorder, err := journal.Begin("api")
+	if err != nil {
+		log.Printf("Warning: journal failed: %v", err)
+	}
+
+	// A failed change may have written some files, they are journaled as well
+	changeErr := fn()
+
+	if recorder != nil {
+		if _, err := recorder.Finish("api: " + summary); err != nil {
+			log.Printf("Warning: journal failed: %v", err)
+		}
+	}
+	return changeErr
+}
+
+// statusRecorder remembers the status code of a response for the request log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// errorBody is the response of a failed request
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// writeData writes data wrapped in the document of the structured output
+func writeData(w http.ResponseWriter, status int, kind string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(output.NewDocument(kind, data)); err != nil {
+		log.Printf("Warning: failed to encode response: %v", err)
+	}
+}
+
+// writeError writes an error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(errorBody{Error: err.Error()}); err != nil {
+		log.Printf("Warning: failed to encode response: %v", err)
+	}
+}
+
+// fail writes an error with the status that fits it, or status if none fits better
+func fail(w http.ResponseWriter, status int, err error) {
+	switch {
+	case errors.Is(err, transaction.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, storage.ErrChanged):
+		status = http.StatusConflict
+	case strings.Contains(err.Error(), "not found"):
+		status = http.StatusNotFound
+	}
+	writeError(w, status, err)
+}
+
+// decode reads the JSON body of a request into v, unknown fields are an error
+func decode(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("request body is empty")
+		}
+		return fmt.Errorf("invalid request body: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/config"
+	"spendgrid/internal/currency"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/query"
+	"spendgrid/internal/transaction"
+)
+
+// transactionInput is the body of a create or update request
+// Fields left out keep their value on update
+type transactionInput struct {
+	Input       *string            `json:"input"` // quick input, e.g. "-100TL market #mutfak", only on create
+	Date        *string            `json:"date"`  // YYYY-MM-DD
+	Description *string            `json:"description"`
+	Amount      *float64           `json:"amount"`
+	Currency    *string            `json:"currency"`
+	Rate        *float64           `json:"rate"`
+	Tags        *[]string          `json:"tags"`
+	Projects    *[]string          `json:"projects"`
+	Account     *string            `json:"account"`
+	Meta        *map[string]string `json:"meta"`
+	Completed   *bool              `json:"completed"` // only for rule rows
+}
+
+// apply sets the given fields on tx, which is dated in year and month
+// Returns the year and month of the row after the change
+func (in *transactionInput) apply(tx *parser.Transaction, year, month int) (int, int, error) {
+	if in.Date != nil {
+		date, err := time.Parse("2006-01-02", *in.Date)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid date: %s (use YYYY-MM-DD)", *in.Date)
+		}
+		year, month, tx.Day = date.Year(), int(date.Month()), date.Day()
+	}
+	if in.Description != nil {
+		tx.Description = strings.TrimSpace(*in.Description)
+	}
+	if in.Amount != nil {
+		tx.Amount = *in.Amount
+	}
+	if in.Currency != nil {
+		tx.Currency = currency.Normalize(*in.Currency)
+	}
+	if in.Rate != nil {
+		tx.Rate = *in.Rate
+	}
+	if in.Tags != nil {
+		tx.Tags = trimAll(*in.Tags, "#")
+	}
+	if in.Projects != nil {
+		tx.Projects = trimAll(*in.Projects, "@")
+	}
+	if in.Account != nil {
+		tx.Account = strings.TrimPrefix(strings.TrimSpace(*in.Account), "&")
+	}
+	if in.Meta != nil {
+		tx.Meta = make(map[string]string, len(*in.Meta))
+		for key, value := range *in.Meta {
+			tx.Meta[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	if in.Completed != nil {
+		if !tx.IsRule {
+			return 0, 0, fmt.Errorf("only rule rows can be completed")
+		}
+		tx.Completed = *in.Completed
+	}
+
+	// A separator in a field would break the line of the row
+	// ':' separates tag paths and is only a separator in meta keys
+	fields := append(append([]string{tx.Currency, tx.Account}, tx.Tags...), tx.Projects...)
+	for key, value := range tx.Meta {
+		if strings.Contains(key, ":") {
+			return 0, 0, fmt.Errorf("invalid meta key: %q", key)
+		}
+		fields = append(fields, key, value)
+	}
+	for _, field := range fields {
+		if strings.ContainsAny(field, "|[],\n") {
+			return 0, 0, fmt.Errorf("invalid value: %q", field)
+		}
+	}
+	for _, field := range append(append([]string{tx.Account}, tx.Tags...), tx.Projects...) {
+		if strings.ContainsAny(field, " \t") {
+			return 0, 0, fmt.Errorf("tags, projects and accounts cannot contain spaces: %q", field)
+		}
+	}
+
+	return year, month, nil
+}
+
+// trimAll trims spaces and a prefix from every item and drops empty ones
+func trimAll(items []string, prefix string) []string {
+	trimmed := []string{}
+	for _, item := range items {
+		if item = strings.TrimPrefix(strings.TrimSpace(item), prefix); item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return trimmed
+}
+
+// parseMonth parses YYYY-MM into an index that orders months, YYYY gives the first or last month of the year
+func parseMonth(value string, last bool) (int, error) {
+	if t, err := time.Parse("2006-01", value); err == nil {
+		return t.Year()*12 + int(t.Month()) - 1, nil
+	}
+	year, err := strconv.Atoi(value)
+	if err != nil || len(value) != 4 {
+		return 0, fmt.Errorf("invalid period: %s (use YYYY-MM or YYYY)", value)
+	}
+	if last {
+		return year*12 + 11, nil
+	}
+	return year * 12, nil
+}
+
+// monthRange returns the first and last month a list request asks for
+// period is a month or a year; from and to give a range, to defaults to the current month
+func monthRange(r *http.Request) (int, int, error) {
+	now := time.Now()
+	current := now.Year()*12 + int(now.Month()) - 1
+
+	params := r.URL.Query()
+	period, from, to := params.Get("period"), params.Get("from"), params.Get("to")
+	if period != "" && (from != "" || to != "") {
+		return 0, 0, fmt.Errorf("use either period or from and to")
+	}
+	if period != "" {
+		from, to = period, period
+	}
+
+	first, last := current, current
+	var err error
+	if to != "" {
+		if last, err = parseMonth(to, true); err != nil {
+			return 0, 0, err
+		}
+		first = last
+	}
+	if from != "" {
+		if first, err = parseMonth(from, false); err != nil {
+			return 0, 0, err
+		}
+	}
+	if first > last {
+		return 0, 0, fmt.Errorf("from is after to")
+	}
+	return first, last, nil
+}
+
+// listTransactions returns the rows of a period, optionally filtered by a query
+func (s *Server) listTransactions(w http.ResponseWriter, r *http.Request) {
+	if err := s.l.EnsureInitialized(); err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	first, last, err := monthRange(r)
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var matched map[string]bool
+	if expr := r.URL.Query().Get("q"); expr != "" {
+		q, err := query.Parse(expr)
+		if err != nil {
+			fail(w, http.StatusBadRequest, err)
+			return
+		}
+		entries, err := query.Entries(q)
+		if err != nil {
+			fail(w, http.StatusBadRequest, err)
+			return
+		}
+		matched = make(map[string]bool, len(entries))
+		for _, e := range entries {
+			matched[fmt.Sprintf("%s:%d", filepath.ToSlash(e.Path), e.Tx.LineNumber)] = true
+		}
+	}
+
+	rows := []*transaction.Row{}
+	for m := first; m <= last; m++ {
+		monthRows, err := transaction.MonthRows(s.l, m/12, m%12+1)
+		if err != nil {
+			fail(w, http.StatusInternalServerError, err)
+			return
+		}
+		for _, row := range monthRows {
+			if matched == nil || matched[fmt.Sprintf("%s:%d", row.File, row.LineNumber)] {
+				rows = append(rows, row)
+			}
+		}
+	}
+
+	writeData(w, http.StatusOK, "transactions", rows)
+}
+
+// getTransaction returns a single row
+func (s *Server) getTransaction(w http.ResponseWriter, r *http.Request) {
+	row, err := transaction.FindRow(s.l, r.PathValue("id"))
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "transaction", row)
+}
+
+// createTransaction adds a row, dated today unless a date is given
+func (s *Server) createTransaction(w http.ResponseWriter, r *http.Request) {
+	var in transactionInput
+	if err := decode(r, &in); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	now := time.Now()
+	tx := &parser.Transaction{Day: now.Day(), Currency: config.GetBaseCurrency(), Meta: make(map[string]string)}
+	if in.Input != nil {
+		quick, err := parser.QuickInputParser(*in.Input)
+		if err != nil {
+			fail(w, http.StatusBadRequest, fmt.Errorf("error parsing input: %v", err))
+			return
+		}
+		tx = quick
+	} else if in.Amount == nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("amount is required"))
+		return
+	}
+
+	year, month, err := in.apply(tx, now.Year(), int(now.Month()))
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var row *transaction.Row
+	err = s.change("add "+tx.Description, func() error {
+		row, err = transaction.AddRow(s.l, year, month, tx)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusCreated, "transaction", row)
+}
+
+// updateTransaction changes the given fields of a row, the response holds its new ID
+func (s *Server) updateTransaction(w http.ResponseWriter, r *http.Request) {
+	var in transactionInput
+	if err := decode(r, &in); err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	if in.Input != nil {
+		fail(w, http.StatusBadRequest, fmt.Errorf("input is only accepted when adding a transaction"))
+		return
+	}
+
+	id := r.PathValue("id")
+	row, err := transaction.FindRow(s.l, id)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	tx := *row.Transaction
+	year, month, err := in.apply(&tx, row.Year, row.Month)
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+
+	summary := "edit " + tx.Description
+	if in.Completed != nil && *in.Completed != row.Completed {
+		summary = "complete " + tx.Description
+		if !tx.Completed {
+			summary = "uncomplete " + tx.Description
+		}
+	}
+
+	var updated *transaction.Row
+	err = s.change(summary, func() error {
+		updated, err = transaction.UpdateRow(s.l, id, year, month, &tx)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusBadRequest, err)
+		return
+	}
+	writeData(w, http.StatusOK, "transaction", updated)
+}
+
+// deleteTransaction removes a row and returns it
+func (s *Server) deleteTransaction(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	row, err := transaction.FindRow(s.l, id)
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	err = s.change("remove "+row.Description, func() error {
+		row, err = transaction.DeleteRow(s.l, id)
+		return err
+	})
+	if err != nil {
+		fail(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeData(w, http.StatusOK, "transaction", row)
+}
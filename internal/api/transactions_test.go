@@ -0,0 +1,95 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+)
+
+// testServer returns a server over a new empty ledger in a temporary directory
+func testServer(t *testing.T) *Server {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, ".config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(dir, ".local", "share"))
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	root := filepath.Join(dir, "ledger")
+	if err := os.MkdirAll(filepath.Join(root, ledger.StateDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetRoot(root); err != nil {
+		t.Fatal(err)
+	}
+	return New(ledger.Open(root), "token")
+}
+
+// request sends a JSON request and decodes the transaction in the response
+func request(t *testing.T, s *Server, method, path, body string, want int) map[string]interface{} {
+	req := httptest.NewRequest(method, Prefix+path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != want {
+		t.Fatalf("%s %s: got status %d, want %d: %s", method, path, rec.Code, want, rec.Body.String())
+	}
+	var doc struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if want < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return doc.Data
+}
+
+func TestTransactionTagPaths(t *testing.T) {
+	s := testServer(t)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"tag path", `{"date":"2026-01-05","description":"Market","amount":-100,"currency":"TRY","tags":["yemek:market"]}`, http.StatusCreated},
+		{"quick input with a tag path", `{"date":"2026-01-06","input":"-45TL market #yemek:market"}`, http.StatusCreated},
+		{"tag with a separator", `{"date":"2026-01-07","description":"Market","amount":-100,"currency":"TRY","tags":["yemek|market"]}`, http.StatusBadRequest},
+		{"tag with a space", `{"date":"2026-01-07","description":"Market","amount":-100,"currency":"TRY","tags":["yemek market"]}`, http.StatusBadRequest},
+		{"meta key with a colon", `{"date":"2026-01-07","description":"Market","amount":-100,"currency":"TRY","meta":{"A:B":"c"}}`, http.StatusBadRequest},
+		{"meta value with a colon", `{"date":"2026-01-07","description":"Market","amount":-100,"currency":"TRY","meta":{"TIME":"10:30"}}`, http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request(t, s, "POST", "/transactions", tt.body, tt.want)
+		})
+	}
+
+	created := request(t, s, "POST", "/transactions",
+		`{"date":"2026-01-08","description":"Market","amount":-100,"currency":"TRY","tags":["yemek:market"]}`, http.StatusCreated)
+	id, _ := created["id"].(string)
+	if id == "" {
+		t.Fatalf("created row has no ID: %v", created)
+	}
+
+	// Fields left out keep their value, the unchanged tag path must pass the checks again
+	updated := request(t, s, "PATCH", "/transactions/"+id, `{"description":"Groceries"}`, http.StatusOK)
+	if updated["description"] != "Groceries" {
+		t.Errorf("description: got %v, want Groceries", updated["description"])
+	}
+	tags, _ := updated["tags"].([]interface{})
+	if len(tags) != 1 || tags[0] != "yemek:market" {
+		t.Errorf("tags: got %v, want [yemek:market]", updated["tags"])
+	}
+}
@@ -0,0 +1,354 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// DefaultDir is where backups are written unless another directory is given
+var DefaultDir = filepath.Join(ledger.StateDir, "backups")
+
+// ManifestFile lists the SHA-256 of every file in an archive, in sha256sum format
+const ManifestFile = "MANIFEST.sha256"
+
+// ratesFile is where the exchange rate cache is kept inside an archive
+const ratesFile = ".rates/exchange_rates.json"
+
+// Extensions of plain and passphrase-encrypted archives
+const (
+	Ext          = ".tar.gz"
+	EncryptedExt = ".tar.gz.enc"
+)
+
+// AutoKeep is the number of automatic backups kept, older ones are removed
+const AutoKeep = 10
+
+// archiveName matches spendgrid-<date>-<time>[-<reason>].tar.gz[.enc]
+var archiveName = regexp.MustCompile(`^spendgrid-(\d{8}-\d{6})(?:-([a-z0-9-]+))?\.tar\.gz(\.enc)?$`)
+
+// Options controls what Create writes
+type Options struct {
+	Dir        string // directory of the archive, DefaultDir if empty
+	Reason     string // set for automatic backups, e.g. migrate
+	Rates      bool   // include the exchange rate cache
+	Passphrase string // encrypt the archive if set
+}
+
+// Archive is a backup archive on disk
+type Archive struct {
+	Path      string    `json:"path" yaml:"path"`
+	Time      time.Time `json:"time" yaml:"time"`
+	Reason    string    `json:"reason" yaml:"reason"` // empty for backups made with 'spendgrid backup'
+	Encrypted bool      `json:"encrypted" yaml:"encrypted"`
+	Size      int64     `json:"size" yaml:"size"`
+	Files     int       `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// Create writes an archive of the ledger in the root directory
+// Files are archived as stored, so the files of an encrypted ledger stay encrypted
+func Create(opts Options) (*Archive, error) {
+	if err := storage.Lock(); err != nil {
+		return nil, err
+	}
+	defer storage.Unlock()
+
+	files, err := ledgerFiles()
+	if err != nil {
+		return nil, err
+	}
+	contents := make(map[string][]byte, len(files)+1)
+	for _, name := range files {
+		data, err := os.ReadFile(storage.Path(filepath.FromSlash(name)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		contents[name] = data
+	}
+	if opts.Rates {
+		if data, err := os.ReadFile(exchange.GetCachePath()); err == nil {
+			contents[ratesFile] = data
+		}
+	}
+
+	data, err := pack(contents)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Passphrase != "" {
+		if data, err = vault.SealPassphrase(opts.Passphrase, "backup", data); err != nil {
+			return nil, err
+		}
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = storage.Path(DefaultDir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	now := time.Now()
+	path := archivePath(dir, now, opts.Reason, opts.Passphrase != "")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %v", err)
+	}
+
+	return &Archive{
+		Path:      path,
+		Time:      now,
+		Reason:    opts.Reason,
+		Encrypted: opts.Passphrase != "",
+		Size:      int64(len(data)),
+		Files:     len(contents),
+	}, nil
+}
+
+// Auto takes an automatic backup before a risky change and keeps the newest AutoKeep of them
+// Returns the archive path
+func Auto(reason string) (string, error) {
+	archive, err := Create(Options{Reason: reason})
+	if err != nil {
+		return "", fmt.Errorf("backup failed: %v", err)
+	}
+	if _, err := Prune(storage.Path(DefaultDir), Retention{Keep: AutoKeep, AutoOnly: true}); err != nil {
+		return archive.Path, err
+	}
+	return archive.Path, nil
+}
+
+// archivePath returns a file name for a new archive that does not exist yet
+func archivePath(dir string, t time.Time, reason string, encrypted bool) string {
+	ext := Ext
+	if encrypted {
+		ext = EncryptedExt
+	}
+	base := "spendgrid-" + t.Format("20060102-150405")
+	if reason != "" {
+		base += "-" + reason
+	}
+
+	path := filepath.Join(dir, base+ext)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = filepath.Join(dir, base+"-"+strconv.Itoa(i)+ext)
+	}
+}
+
+// ledgerFiles returns the files of the ledger in the root directory:
+// _config, _pool, the year directories and .spendgrid without its backups and lock
+func ledgerFiles() ([]string, error) {
+	root := os.DirFS(storage.Path("."))
+	entries, err := fs.ReadDir(root, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger directory: %v", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() || !inBackup(e.Name()) {
+			continue
+		}
+		err := fs.WalkDir(root, e.Name(), func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if name == filepath.ToSlash(DefaultDir) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			// Temporary files of a write in progress
+			if strings.HasPrefix(d.Name(), ".") || name == ledger.StateDir+"/lock" {
+				return nil
+			}
+			names = append(names, name)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", e.Name(), err)
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// inBackup returns true for the top-level directories a backup holds
+func inBackup(dir string) bool {
+	switch dir {
+	case "_config", "_pool", ledger.StateDir:
+		return true
+	}
+	_, err := strconv.Atoi(dir)
+	return len(dir) == 4 && err == nil
+}
+
+// pack writes the manifest and the files to a tar.gz
+func pack(contents map[string][]byte) ([]byte, error) {
+	names := make([]string, 0, len(contents))
+	for name := range contents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var manifest strings.Builder
+	for _, name := range names {
+		sum := sha256.Sum256(contents[name])
+		fmt.Fprintf(&manifest, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	write := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(data)), ModTime: now, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+
+	if err := write(ManifestFile, []byte(manifest.String())); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %v", err)
+	}
+	for _, name := range names {
+		if err := write(name, contents[name]); err != nil {
+			return nil, fmt.Errorf("failed to write archive: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write archive: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// IsEncrypted returns true if an archive needs a passphrase
+func IsEncrypted(path string) bool {
+	return strings.HasSuffix(path, ".enc")
+}
+
+// Open reads an archive and verifies every file against the manifest
+// Returns the files by name, without the manifest
+func Open(path, passphrase string) (map[string][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup: %v", err)
+	}
+	if IsEncrypted(path) {
+		if data, err = vault.OpenPassphrase(passphrase, "backup", data); err != nil {
+			return nil, err
+		}
+	}
+
+	files, err := unpack(data)
+	if err != nil {
+		return nil, err
+	}
+	manifest, ok := files[ManifestFile]
+	if !ok {
+		return nil, fmt.Errorf("%s has no %s, it is not a spendgrid backup", path, ManifestFile)
+	}
+	delete(files, ManifestFile)
+
+	var problems []string
+	listed := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(manifest)), "\n") {
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("invalid manifest line '%s'", line)
+		}
+		listed[name] = true
+
+		content, ok := files[name]
+		if !ok {
+			problems = append(problems, name+" is missing")
+			continue
+		}
+		actual := sha256.Sum256(content)
+		if hex.EncodeToString(actual[:]) != sum {
+			problems = append(problems, name+" does not match its checksum")
+		}
+	}
+	for name := range files {
+		if !listed[name] {
+			problems = append(problems, name+" is not in the manifest")
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("backup is damaged: %s", strings.Join(problems, ", "))
+	}
+	return files, nil
+}
+
+// Ledger returns the ledger held in the files of an archive, read-only
+// The files of an encrypted ledger are archived sealed and cannot be read this way
+func Ledger(files map[string][]byte) (*ledger.Ledger, error) {
+	if _, ok := files[ledger.StateDir+"/"+vault.SettingsFile]; ok {
+		return nil, fmt.Errorf("the ledger in the backup is encrypted")
+	}
+	return ledger.New(ledger.ReadOnly(ledger.MemFS(files))), nil
+}
+
+// unpack reads the regular files of a tar.gz
+func unpack(data []byte) (map[string][]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open backup: %v", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %v", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := filepath.ToSlash(filepath.Clean(hdr.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("backup contains an unsafe path %s", hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup: %v", err)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
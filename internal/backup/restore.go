@@ -0,0 +1,258 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// Retention decides which archives Prune keeps
+type Retention struct {
+	Keep     int  // keep the newest Keep archives, 0 for no limit
+	Days     int  // remove archives older than Days days, 0 for no limit
+	AutoOnly bool // only consider automatic backups
+}
+
+// List returns the archives in a directory, newest first
+func List(dir string) ([]*Archive, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []*Archive{}, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+	}
+
+	archives := []*Archive{}
+	written := make(map[*Archive]time.Time)
+	for _, e := range entries {
+		m := archiveName.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		t, err := time.ParseInLocation("20060102-150405", m[1], time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		a := &Archive{
+			Path:      filepath.Join(dir, e.Name()),
+			Time:      t,
+			Reason:    m[2],
+			Encrypted: m[3] != "",
+			Size:      info.Size(),
+		}
+		archives = append(archives, a)
+		written[a] = info.ModTime()
+	}
+
+	// Names only have seconds, archives of the same second are ordered by when they were written
+	sort.SliceStable(archives, func(i, j int) bool {
+		if !archives[i].Time.Equal(archives[j].Time) {
+			return archives[i].Time.After(archives[j].Time)
+		}
+		return written[archives[i]].After(written[archives[j]])
+	})
+	return archives, nil
+}
+
+// SealPlain encrypts the plain archives in dir with a passphrase and removes the plain copies,
+// so a ledger encrypted after it was backed up keeps no readable copy in its backups
+// Returns the archives it encrypted
+func SealPlain(dir, passphrase string) ([]*Archive, error) {
+	archives, err := PlainArchives(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed := []*Archive{}
+	for _, a := range archives {
+		data, err := os.ReadFile(a.Path)
+		if err != nil {
+			return sealed, fmt.Errorf("failed to read %s: %v", a.Path, err)
+		}
+		if data, err = vault.SealPassphrase(passphrase, "backup", data); err != nil {
+			return sealed, err
+		}
+
+		path := strings.TrimSuffix(a.Path, Ext) + EncryptedExt
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return sealed, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		if err := os.Remove(a.Path); err != nil {
+			return sealed, fmt.Errorf("failed to remove %s: %v", a.Path, err)
+		}
+
+		a.Path, a.Encrypted, a.Size = path, true, int64(len(data))
+		sealed = append(sealed, a)
+	}
+	return sealed, nil
+}
+
+// PlainArchives returns the archives in dir anyone can read: archives without a
+// passphrase that hold the files of a plain ledger, newest first
+func PlainArchives(dir string) ([]*Archive, error) {
+	archives, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	plain := []*Archive{}
+	for _, a := range archives {
+		if a.Encrypted {
+			continue
+		}
+		data, err := os.ReadFile(a.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", a.Path, err)
+		}
+		// An unreadable archive is left to verify; it may still hold plain files
+		if files, err := unpack(data); err == nil {
+			if _, sealed := files[ledger.StateDir+"/"+vault.SettingsFile]; sealed {
+				continue
+			}
+		}
+		plain = append(plain, a)
+	}
+	return plain, nil
+}
+
+// Prune removes the archives in dir that fall outside the retention
+// The newest archive is always kept; returns the removed archives
+func Prune(dir string, r Retention) ([]*Archive, error) {
+	archives, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -r.Days)
+	removed := []*Archive{}
+	kept := 0
+	for _, a := range archives {
+		if r.AutoOnly && a.Reason == "" {
+			continue
+		}
+		kept++
+		if kept == 1 {
+			continue
+		}
+		if (r.Keep > 0 && kept > r.Keep) || (r.Days > 0 && a.Time.Before(cutoff)) {
+			if err := os.Remove(a.Path); err != nil {
+				return removed, fmt.Errorf("failed to remove %s: %v", a.Path, err)
+			}
+			removed = append(removed, a)
+			kept--
+		}
+	}
+	return removed, nil
+}
+
+// Restore verifies an archive and puts its files back
+// With an empty target the ledger in the root directory is replaced: a backup
+// of it is taken first, then files missing from the archive are removed
+// Otherwise the files are extracted to target, which must be empty or missing
+// The exchange rate cache is only restored if rates is set
+// Returns the number of files restored
+func Restore(path, passphrase, target string, rates bool) (int, error) {
+	files, err := Open(path, passphrase)
+	if err != nil {
+		return 0, err
+	}
+
+	// A ledger written by a newer spendgrid could not be used after restoring it
+	if version, ok := files[ledger.StateDir+"/"+ledger.VersionFile]; ok {
+		if fields := strings.Fields(string(version)); len(fields) > 0 {
+			if schema, err := strconv.Atoi(fields[0]); err == nil && schema > ledger.SchemaVersion {
+				return 0, fmt.Errorf("backup uses schema %d but this spendgrid only knows schema %d, please upgrade spendgrid", schema, ledger.SchemaVersion)
+			}
+		}
+	}
+
+	ratesData, hasRates := files[ratesFile]
+	delete(files, ratesFile)
+
+	if target != "" {
+		err = extract(files, target)
+	} else {
+		err = replaceLedger(files)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if rates && hasRates {
+		cachePath := exchange.GetCachePath()
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+			return len(files), fmt.Errorf("failed to restore exchange rates: %v", err)
+		}
+		if err := os.WriteFile(cachePath, ratesData, 0644); err != nil {
+			return len(files), fmt.Errorf("failed to restore exchange rates: %v", err)
+		}
+	}
+	return len(files), nil
+}
+
+// extract writes files to a new directory
+func extract(files map[string][]byte, target string) error {
+	if entries, err := os.ReadDir(target); err == nil && len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", target)
+	}
+
+	for name, data := range files {
+		path := filepath.Join(target, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", path, err)
+		}
+	}
+	return nil
+}
+
+// replaceLedger makes the ledger in the root directory hold exactly files
+func replaceLedger(files map[string][]byte) error {
+	if err := storage.Lock(); err != nil {
+		return err
+	}
+	defer storage.Unlock()
+
+	safety, err := Auto("restore")
+	if err != nil {
+		return err
+	}
+	color.New(color.FgYellow).Fprintf(os.Stderr, "Current ledger saved to %s\n", safety)
+
+	current, err := ledgerFiles()
+	if err != nil {
+		return err
+	}
+
+	for name, data := range files {
+		if err := storage.WriteStored(filepath.FromSlash(name), data); err != nil {
+			return err
+		}
+	}
+	for _, name := range current {
+		if _, ok := files[name]; ok {
+			continue
+		}
+		if err := os.Remove(storage.Path(filepath.FromSlash(name))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", name, err)
+		}
+	}
+	return nil
+}
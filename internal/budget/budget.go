@@ -0,0 +1,387 @@
+package budget
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+
+	"spendgrid/internal/category"
+	"spendgrid/internal/config"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/reports"
+	"spendgrid/internal/storage"
+)
+
+// Budget is a monthly spending limit for a category and its sub-categories
+type Budget struct {
+	Tag      string  `yaml:"tag"`
+	Limit    float64 `yaml:"limit"`
+	Currency string  `yaml:"currency,omitempty"` // Defaults to the base currency
+	Rollover bool    `yaml:"rollover,omitempty"` // Carry unspent amounts to the next month
+	Start    string  `yaml:"start,omitempty"`    // Format: YYYY-MM, first month of rollover
+}
+
+// BudgetSet holds all budgets
+type BudgetSet struct {
+	Budgets []Budget `yaml:"budgets"`
+}
+
+// Status is the state of a budget in a single month, in base currency
+type Status struct {
+	Budget    *Budget
+	Limit     float64
+	Carry     float64 // Unspent amount carried from previous months
+	Available float64 // Limit + carry
+	Spent     float64
+	Remaining float64
+	Percent   float64
+}
+
+// IsOver returns true if spending exceeded the available amount
+func (s *Status) IsOver() bool {
+	return s.Spent > s.Available+0.005
+}
+
+// GetBudgetsFilePath returns the path to budgets.yml
+func GetBudgetsFilePath() string {
+	return filepath.Join("_config", "budgets.yml")
+}
+
+// LoadBudgets loads all budgets
+func LoadBudgets() (*BudgetSet, error) {
+	data, err := storage.ReadFile(GetBudgetsFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &BudgetSet{Budgets: []Budget{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read budgets: %v", err)
+	}
+
+	var set BudgetSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse budgets: %v", err)
+	}
+
+	for i := range set.Budgets {
+		set.Budgets[i].Tag = strings.TrimPrefix(strings.TrimSpace(set.Budgets[i].Tag), "#")
+		set.Budgets[i].Currency = strings.ToUpper(strings.TrimSpace(set.Budgets[i].Currency))
+	}
+
+	return &set, nil
+}
+
+// tracker computes budget statuses, caching monthly reports
+type tracker struct {
+	base    string
+	tree    *category.Tree
+	reports map[string]*reports.MonthlyReport
+}
+
+func newTracker() *tracker {
+	tree, err := category.Load()
+	if err != nil {
+		tree = &category.Tree{}
+	}
+
+	return &tracker{
+		base:    config.GetBaseCurrency(),
+		tree:    tree,
+		reports: make(map[string]*reports.MonthlyReport),
+	}
+}
+
+// matches returns true if the tag is the budget category or one of its sub-categories
+func (t *tracker) matches(b *Budget, tag string) bool {
+	path := t.tree.Resolve(b.Tag)
+	for _, ancestor := range category.Ancestors(t.tree.Resolve(tag)) {
+		if ancestor == path {
+			return true
+		}
+	}
+	return false
+}
+
+// report returns the monthly report, or nil if the month file does not exist
+func (t *tracker) report(year, month int) (*reports.MonthlyReport, error) {
+	key := fmt.Sprintf("%04d-%02d", year, month)
+	if report, ok := t.reports[key]; ok {
+		return report, nil
+	}
+
+	var report *reports.MonthlyReport
+	if _, err := storage.Stat(ledger.MonthPath(year, month)); err == nil {
+		report, _, err = reports.BuildMonthlyReport(ledger.Current(), year, month)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.reports[key] = report
+	return report, nil
+}
+
+// spent returns the amount spent on a budget in a month, using the report's category totals
+func (t *tracker) spent(b *Budget, year, month int) (float64, error) {
+	report, err := t.report(year, month)
+	if err != nil || report == nil {
+		return 0, err
+	}
+
+	// Category totals are rolled up, so the budget path holds its whole subtree
+	return 0 - report.BaseByCategory[t.tree.Resolve(b.Tag)], nil
+}
+
+// limit returns the budget limit in base currency
+func (t *tracker) limit(b *Budget, year, month int) float64 {
+	if b.Currency == "" || b.Currency == t.base {
+		return b.Limit
+	}
+	date := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	converted, err := exchange.ConvertAmount(b.Limit, b.Currency, t.base, date)
+	if err != nil {
+		return b.Limit
+	}
+	return converted
+}
+
+// rolloverStart returns the first month whose unspent amount is carried
+// Without a start month it is the first month of the ledger that has rows,
+// so the empty month files created by init do not carry their full limit
+func (t *tracker) rolloverStart(b *Budget, year, month int) (int, int, error) {
+	if b.Start != "" {
+		if y, m, err := parseYearMonth(b.Start); err == nil {
+			return y, m, nil
+		}
+	}
+
+	years, err := ledger.Years()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(years) == 0 || years[0] > year {
+		return year, month, nil
+	}
+
+	for y, m := years[0], 1; y < year || (y == year && m < month); {
+		report, err := t.report(y, m)
+		if err != nil {
+			return 0, 0, err
+		}
+		if report != nil && len(report.Transactions) > 0 {
+			return y, m, nil
+		}
+
+		m++
+		if m > 12 {
+			m = 1
+			y++
+		}
+	}
+	return year, month, nil
+}
+
+// status computes the status of a budget in a month, including rollover
+func (t *tracker) status(b *Budget, year, month int) (*Status, error) {
+	carry := 0.0
+	if b.Rollover {
+		startYear, startMonth, err := t.rolloverStart(b, year, month)
+		if err != nil {
+			return nil, err
+		}
+
+		for y, m := startYear, startMonth; y < year || (y == year && m < month); {
+			spent, err := t.spent(b, y, m)
+			if err != nil {
+				return nil, err
+			}
+			carry += t.limit(b, y, m) - spent
+			if carry < 0 {
+				carry = 0
+			}
+
+			m++
+			if m > 12 {
+				m = 1
+				y++
+			}
+		}
+	}
+
+	spent, err := t.spent(b, year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{
+		Budget: b,
+		Limit:  t.limit(b, year, month),
+		Carry:  carry,
+		Spent:  spent,
+	}
+	st.Available = st.Limit + st.Carry
+	st.Remaining = st.Available - st.Spent
+	if st.Available > 0 {
+		st.Percent = st.Spent / st.Available * 100
+	}
+
+	return st, nil
+}
+
+// GetStatuses returns the status of every budget in a month
+func GetStatuses(year, month int) ([]*Status, error) {
+	set, err := LoadBudgets()
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracker()
+	var statuses []*Status
+	for i := range set.Budgets {
+		st, err := t.status(&set.Budgets[i], year, month)
+		if err != nil {
+			return nil, err
+		}
+		statuses = append(statuses, st)
+	}
+
+	return statuses, nil
+}
+
+// ShowBudgets prints spent/remaining/percentage per budget
+// period: empty for the current month, MM or YYYY-MM
+func ShowBudgets(period string) error {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return err
+	}
+
+	statuses, err := GetStatuses(year, month)
+	if err != nil {
+		return err
+	}
+
+	base := config.GetBaseCurrency()
+
+	fmt.Println()
+	color.Cyan("🎯 %s - %04d-%02d (%s)", i18n.T("budget.header"), year, month, base)
+	fmt.Println(strings.Repeat("=", 90))
+
+	if len(statuses) == 0 {
+		fmt.Println(i18n.T("budget.empty"))
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Printf("%-20s %12s %10s %12s %12s %7s  %s\n",
+		"Budget", "Limit", "Carry", "Spent", "Remaining", "%", "")
+	fmt.Println(strings.Repeat("-", 90))
+
+	for _, st := range statuses {
+		line := fmt.Sprintf("%-20s %12.2f %10.2f %12.2f %12.2f %6.0f%%  %s",
+			"#"+output.Truncate(st.Budget.Tag, 19), st.Limit, st.Carry, st.Spent, st.Remaining, st.Percent, progressBar(st.Percent))
+
+		switch {
+		case st.IsOver():
+			color.Red("%s", line)
+		case st.Percent >= 80:
+			color.Yellow("%s", line)
+		default:
+			color.Green("%s", line)
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 90))
+	fmt.Println()
+	return nil
+}
+
+// WarnIfOver prints a warning for each budget the transaction counts towards
+// that is over its limit after the transaction was added
+func WarnIfOver(tx *parser.Transaction, year, month int) {
+	if tx == nil || !tx.IsExpense() || len(tx.Tags) == 0 {
+		return
+	}
+
+	set, err := LoadBudgets()
+	if err != nil || len(set.Budgets) == 0 {
+		return
+	}
+
+	t := newTracker()
+	for i := range set.Budgets {
+		b := &set.Budgets[i]
+
+		matched := false
+		for _, tag := range tx.Tags {
+			if t.matches(b, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		st, err := t.status(b, year, month)
+		if err != nil || !st.IsOver() {
+			continue
+		}
+
+		color.Yellow(i18n.T("budget.over_limit"), b.Tag, st.Spent, st.Available, t.base, st.Percent)
+	}
+}
+
+// parsePeriod parses an empty string, MM or YYYY-MM into year and month
+func parsePeriod(period string) (int, int, error) {
+	now := time.Now()
+	period = strings.TrimSpace(period)
+
+	if period == "" {
+		return now.Year(), int(now.Month()), nil
+	}
+
+	if strings.Contains(period, "-") {
+		return parseYearMonth(period)
+	}
+
+	month, err := strconv.Atoi(period)
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("invalid period (use MM or YYYY-MM): %s", period)
+	}
+	return now.Year(), month, nil
+}
+
+// parseYearMonth parses YYYY-MM
+func parseYearMonth(value string) (int, int, error) {
+	t, err := time.Parse("2006-01", value)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid period (use MM or YYYY-MM): %s", value)
+	}
+	return t.Year(), int(t.Month()), nil
+}
+
+func progressBar(percent float64) string {
+	const width = 10
+	filled := int(percent / 100 * width)
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
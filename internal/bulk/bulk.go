@@ -0,0 +1,199 @@
+package bulk
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/backup"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/query"
+	"spendgrid/internal/storage"
+)
+
+// Operation changes a copy of a matched transaction
+// Returning false deletes the row
+type Operation func(tx *parser.Transaction, entry *ledger.Entry) (bool, error)
+
+// LineChange is a single changed row
+type LineChange struct {
+	Line    int    `json:"line" yaml:"line"`
+	Before  string `json:"before" yaml:"before"`
+	After   string `json:"after,omitempty" yaml:"after,omitempty"` // empty if the row is deleted
+	Deleted bool   `json:"deleted,omitempty" yaml:"deleted,omitempty"`
+}
+
+// FilePlan holds the changes to one month file
+type FilePlan struct {
+	Path   string       `json:"path" yaml:"path"`
+	Lines  []LineChange `json:"lines" yaml:"lines"`
+	before string
+	after  string
+}
+
+// Plan is a bulk change computed but not applied yet
+type Plan struct {
+	Filter string      `json:"filter" yaml:"filter"`
+	Files  []*FilePlan `json:"files" yaml:"files"`
+}
+
+// Rows returns the number of changed rows
+func (p *Plan) Rows() int {
+	n := 0
+	for _, f := range p.Files {
+		n += len(f.Lines)
+	}
+	return n
+}
+
+// NewPlan runs op on every row matching filter and collects the resulting line changes
+// Rows the operation leaves unchanged are skipped
+func NewPlan(filter string, op Operation) (*Plan, error) {
+	q, err := query.Parse(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := query.Entries(q)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Filter: filter, Files: []*FilePlan{}}
+	byPath := make(map[string]*FilePlan)
+
+	for _, entry := range entries {
+		tx := clone(entry.Tx)
+		keep, err := op(tx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", entry.Path, entry.Tx.LineNumber, err)
+		}
+
+		change := LineChange{Line: entry.Tx.LineNumber, Before: entry.Tx.Raw}
+		if keep {
+			change.After = ledger.FormatRow(tx)
+			if change.After == ledger.FormatRow(entry.Tx) {
+				continue
+			}
+		} else {
+			change.Deleted = true
+		}
+
+		fp := byPath[entry.Path]
+		if fp == nil {
+			fp = &FilePlan{Path: entry.Path}
+			byPath[entry.Path] = fp
+			plan.Files = append(plan.Files, fp)
+		}
+		fp.Lines = append(fp.Lines, change)
+	}
+
+	for _, fp := range plan.Files {
+		if err := fp.build(); err != nil {
+			return nil, err
+		}
+	}
+
+	return plan, nil
+}
+
+// build reads the file and computes its new content
+func (fp *FilePlan) build() error {
+	content, err := storage.ReadFile(fp.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", fp.Path, err)
+	}
+	fp.before = string(content)
+
+	lines := strings.Split(fp.before, "\n")
+	deleted := make(map[int]bool)
+	for _, change := range fp.Lines {
+		if change.Line < 1 || change.Line > len(lines) {
+			return fmt.Errorf("line %d out of range in %s", change.Line, fp.Path)
+		}
+		if change.Deleted {
+			deleted[change.Line] = true
+		} else {
+			lines[change.Line-1] = change.After
+		}
+	}
+
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		if !deleted[i+1] {
+			kept = append(kept, line)
+		}
+	}
+	fp.after = strings.Join(kept, "\n")
+
+	sort.Slice(fp.Lines, func(i, j int) bool { return fp.Lines[i].Line < fp.Lines[j].Line })
+	return nil
+}
+
+// Table returns one row per changed line for csv output
+func (p *Plan) Table() interface{} {
+	grid := &output.Grid{Header: []string{"file", "line", "before", "after", "deleted"}}
+	for _, fp := range p.Files {
+		for _, c := range fp.Lines {
+			grid.Rows = append(grid.Rows, []string{fp.Path, strconv.Itoa(c.Line), c.Before, c.After, strconv.FormatBool(c.Deleted)})
+		}
+	}
+	return grid
+}
+
+// Preview prints the changes as a diff
+func (p *Plan) Preview() {
+	for _, fp := range p.Files {
+		color.New(color.Bold).Printf("--- %s\n", fp.Path)
+		for _, change := range fp.Lines {
+			color.Cyan("@@ %d", change.Line)
+			color.Red("- %s", change.Before)
+			if !change.Deleted {
+				color.Green("+ %s", change.After)
+			}
+		}
+		fmt.Println()
+	}
+}
+
+// Apply writes all changed files together and records the change in the journal
+// Nothing is written if a file changed on disk since the plan was built
+func (p *Plan) Apply(command, summary string) (*journal.Entry, error) {
+	files := make([]storage.File, 0, len(p.Files))
+	changes := make([]journal.FileChange, 0, len(p.Files))
+	for _, fp := range p.Files {
+		files = append(files, storage.File{Path: fp.Path, Data: []byte(fp.after)})
+		changes = append(changes, journal.FileChange{Path: fp.Path, Before: fp.before, After: fp.after, Existed: true})
+	}
+
+	// A bulk change touches many rows, a copy of the ledger is kept first
+	if _, err := backup.Auto("bulk"); err != nil {
+		return nil, err
+	}
+	if err := storage.WriteFiles(files, 0644); err != nil {
+		return nil, err
+	}
+	return journal.Record(command, summary, changes)
+}
+
+func clone(tx *parser.Transaction) *parser.Transaction {
+	c := *tx
+	c.Tags = append([]string(nil), tx.Tags...)
+	c.Projects = append([]string(nil), tx.Projects...)
+	c.Meta = make(map[string]string, len(tx.Meta))
+	for k, v := range tx.Meta {
+		c.Meta[k] = v
+	}
+	return &c
+}
+
+// daysIn returns the number of days of a month
+func daysIn(year, month int) int {
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,122 @@
+package bulk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"spendgrid/internal/category"
+	"spendgrid/internal/currency"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+// Retag removes and adds tags
+// A removed tag also removes its sub-tags, e.g. removing yemek removes yemek:market
+func Retag(add, remove []string) Operation {
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		var tags []string
+		for _, tag := range tx.Tags {
+			if !matchesAny(tag, remove) {
+				tags = append(tags, tag)
+			}
+		}
+		for _, tag := range add {
+			tag = strings.TrimPrefix(tag, "#")
+			if tag != "" && !contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		tx.Tags = tags
+		return true, nil
+	}
+}
+
+// SetProject replaces the projects of a row, an empty project clears them
+func SetProject(project string) Operation {
+	project = strings.TrimPrefix(project, "@")
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		tx.Projects = nil
+		if project != "" {
+			tx.Projects = []string{project}
+		}
+		return true, nil
+	}
+}
+
+// SetMeta sets meta values, an empty value removes the key
+func SetMeta(values map[string]string) Operation {
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		for key, value := range values {
+			if value == "" {
+				delete(tx.Meta, key)
+			} else {
+				tx.Meta[key] = value
+			}
+		}
+		return true, nil
+	}
+}
+
+// ShiftDay moves rows by a number of days within their month
+func ShiftDay(days int) Operation {
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		day := tx.Day + days
+		if day < 1 || day > daysIn(entry.Year, entry.Month) {
+			return false, fmt.Errorf("day %d is outside %04d-%02d", day, entry.Year, entry.Month)
+		}
+		tx.Day = day
+		return true, nil
+	}
+}
+
+// ConvertCurrency rewrites amounts in another currency at the rate of the row date
+// The original amount is kept in the ORIG meta field
+func ConvertCurrency(to string) Operation {
+	to = currency.Normalize(to)
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		if strings.EqualFold(tx.Currency, to) {
+			return true, nil
+		}
+
+		amount, err := exchange.ConvertTransaction(tx, to, entry.Date())
+		if err != nil {
+			return false, fmt.Errorf("no exchange rate %s -> %s: %v", tx.Currency, to, err)
+		}
+
+		if _, ok := tx.Meta["ORIG"]; !ok {
+			tx.Meta["ORIG"] = fmt.Sprintf("%.2f %s", tx.Amount, tx.Currency)
+		}
+		tx.Amount = math.Round(amount*100) / 100
+		tx.Currency = to
+		tx.Rate = 0
+		return true, nil
+	}
+}
+
+// Delete removes rows
+func Delete() Operation {
+	return func(tx *parser.Transaction, entry *ledger.Entry) (bool, error) {
+		return false, nil
+	}
+}
+
+func matchesAny(tag string, list []string) bool {
+	for _, item := range list {
+		item = strings.TrimPrefix(item, "#")
+		if tag == item || strings.HasPrefix(tag, item+category.Separator) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
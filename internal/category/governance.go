@@ -0,0 +1,222 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+)
+
+// Unknown is a tag or project that is not in categories.yml or projects.yml
+type Unknown struct {
+	Kind       string // "tag" or "project"
+	Name       string
+	Suggestion string // Closest known name, empty if nothing is close
+}
+
+// String formats the unknown name with its prefix and suggestion
+func (u Unknown) String() string {
+	prefix := "#"
+	if u.Kind == "project" {
+		prefix = "@"
+	}
+
+	if u.Suggestion == "" {
+		return prefix + u.Name
+	}
+	return i18n.Tfmt("tags.did_you_mean", prefix+u.Name, prefix+u.Suggestion)
+}
+
+// GetProjectsFilePath returns the path to projects.yml
+func GetProjectsFilePath() string {
+	return filepath.Join("_config", "projects.yml")
+}
+
+// LoadProjects returns the projects listed in projects.yml of the current ledger
+func LoadProjects() ([]string, error) {
+	return LoadLedgerProjects(ledger.Current())
+}
+
+// LoadLedgerProjects returns the projects listed in projects.yml of a ledger
+// Both "projects: [...]" and a bare list are accepted
+func LoadLedgerProjects(l *ledger.Ledger) ([]string, error) {
+	data, err := l.ReadFile(GetProjectsFilePath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read projects: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse projects: %v", err)
+	}
+
+	var projects []string
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		if root.Kind == yaml.SequenceNode {
+			err = root.Decode(&projects)
+		} else {
+			var set struct {
+				Projects []string `yaml:"projects"`
+			}
+			err = root.Decode(&set)
+			projects = set.Projects
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse projects: %v", err)
+		}
+	}
+
+	for i := range projects {
+		projects[i] = strings.TrimPrefix(strings.TrimSpace(projects[i]), "@")
+	}
+
+	return projects, nil
+}
+
+// Names returns every name a tag can be written as: full paths, node names and aliases
+func (t *Tree) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for path := range t.paths {
+		add(path)
+	}
+	for name := range t.names {
+		add(name)
+	}
+	for alias := range t.aliases {
+		add(alias)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Checker checks tags and projects against categories.yml and projects.yml
+type Checker struct {
+	tree     *Tree
+	names    []string
+	projects []string
+}
+
+// NewChecker loads the configured tags and projects of the current ledger
+func NewChecker() (*Checker, error) {
+	return NewLedgerChecker(ledger.Current())
+}
+
+// NewLedgerChecker loads the configured tags and projects of a ledger
+func NewLedgerChecker(l *ledger.Ledger) (*Checker, error) {
+	tree, err := LoadLedger(l)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := LoadLedgerProjects(l)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Checker{tree: tree, names: tree.Names(), projects: projects}, nil
+}
+
+// Check returns the tags and projects that are not in the configured lists
+func (c *Checker) Check(tags, projects []string) []Unknown {
+	var unknown []Unknown
+
+	for _, tag := range tags {
+		if c.tree.Has(c.tree.Resolve(tag)) {
+			continue
+		}
+		unknown = append(unknown, Unknown{Kind: "tag", Name: tag, Suggestion: Suggest(tag, c.names)})
+	}
+
+	for _, project := range projects {
+		if contains(c.projects, project) {
+			continue
+		}
+		unknown = append(unknown, Unknown{Kind: "project", Name: project, Suggestion: Suggest(project, c.projects)})
+	}
+
+	return unknown
+}
+
+// Check returns the tags and projects that are not in the configured lists
+func Check(tags, projects []string) ([]Unknown, error) {
+	checker, err := NewChecker()
+	if err != nil {
+		return nil, err
+	}
+	return checker.Check(tags, projects), nil
+}
+
+// Suggest returns the candidate closest to the input, or an empty string
+// if no candidate is within a small edit distance
+func Suggest(input string, candidates []string) string {
+	maxDistance := 2
+	if n := len([]rune(input)); n <= 4 {
+		maxDistance = 1
+	}
+
+	best := ""
+	bestDistance := maxDistance + 1
+	lower := strings.ToLower(input)
+	for _, candidate := range candidates {
+		d := levenshtein(lower, strings.ToLower(candidate))
+		if d < bestDistance {
+			best = candidate
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the edit distance between two strings
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func contains(list []string, item string) bool {
+	for _, existing := range list {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package category
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/rules"
+	"spendgrid/internal/storage"
+)
+
+const backlogFile = "_pool/backlog.md"
+
+// MergeResult reports what a tag merge changed
+type MergeResult struct {
+	Files     int // Month files changed
+	Lines     int // Month file lines changed
+	Rules     int // Rules changed
+	PoolLines int // Pool lines changed
+}
+
+// MergeTags rewrites a tag and its sub-tags to a new name in every month file,
+// rules.yml, the pool and categories.yml
+// Tags are matched through the category tree, so #market, #yemek:market and an alias
+// of yemek:market are all merged. A new name without a path stays under the same parent.
+func MergeTags(oldTag, newTag string) (*MergeResult, error) {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
+	oldTag = strings.TrimPrefix(strings.TrimSpace(oldTag), "#")
+	newTag = strings.TrimPrefix(strings.TrimSpace(newTag), "#")
+	if oldTag == "" || newTag == "" {
+		return nil, fmt.Errorf("tag names cannot be empty")
+	}
+
+	tree, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	oldPath := tree.Resolve(oldTag)
+	newPath := tree.Resolve(newTag)
+	if !tree.Has(newPath) && !strings.Contains(newTag, Separator) {
+		if idx := strings.LastIndex(oldPath, Separator); idx >= 0 {
+			newPath = oldPath[:idx+1] + newTag
+		}
+	}
+	if oldPath == newPath {
+		return nil, fmt.Errorf("tags are the same")
+	}
+	if strings.HasPrefix(newPath, oldPath+Separator) {
+		return nil, fmt.Errorf("cannot merge #%s into its own sub-tag", oldPath)
+	}
+
+	rename := func(tag string) (string, bool) {
+		path := tree.Resolve(tag)
+		if path == oldPath {
+			return newPath, true
+		}
+		if strings.HasPrefix(path, oldPath+Separator) {
+			return newPath + strings.TrimPrefix(path, oldPath), true
+		}
+		return tag, false
+	}
+
+	result := &MergeResult{}
+
+	// Month files
+	years, err := ledger.Years()
+	if err != nil {
+		return nil, err
+	}
+	for _, year := range years {
+		for month := 1; month <= 12; month++ {
+			changed, err := rewriteFile(ledger.MonthPath(year, month), rename)
+			if err != nil {
+				return nil, err
+			}
+			if changed > 0 {
+				result.Files++
+				result.Lines += changed
+			}
+		}
+	}
+
+	// Pool
+	result.PoolLines, err = rewriteFile(backlogFile, rename)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rules
+	ruleSet, err := rules.LoadRules(ledger.Current())
+	if err != nil {
+		return nil, err
+	}
+	for i := range ruleSet.Rules {
+		rule := &ruleSet.Rules[i]
+		changed := false
+		for j, tag := range rule.Tags {
+			if renamed, ok := rename(tag); ok {
+				rule.Tags[j] = renamed
+				changed = true
+			}
+		}
+		if rule.Category != "" {
+			if renamed, ok := rename(rule.Category); ok {
+				rule.Category = renamed
+				changed = true
+			}
+		}
+		if changed {
+			result.Rules++
+		}
+	}
+	if result.Rules > 0 {
+		if err := rules.SaveRules(ledger.Current(), ruleSet); err != nil {
+			return nil, err
+		}
+	}
+
+	// Category tree
+	node := tree.remove(oldPath)
+	if node == nil {
+		node = &Node{}
+	}
+	tree.merge(&Node{Name: newPath, Aliases: node.Aliases, Children: node.Children})
+	tree.index()
+	if err := Save(tree); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// tagPattern matches #tag and #tag:sub tokens
+var tagPattern = regexp.MustCompile(`#[\p{L}\p{N}_]+(?::[\p{L}\p{N}_]+)*`)
+
+// rewriteFile renames tag tokens in a file
+// Returns the number of changed lines; a missing file changes nothing
+func rewriteFile(path string, rename func(string) (string, bool)) (int, error) {
+	content, err := storage.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	changed := 0
+	for i, line := range lines {
+		updated := rewriteLine(line, rename)
+		if updated != line {
+			lines[i] = updated
+			changed++
+		}
+	}
+
+	if changed == 0 {
+		return 0, nil
+	}
+
+	if err := storage.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return changed, nil
+}
+
+// rewriteLine renames the tag tokens of a line, leaving system tags (#tag#) alone
+func rewriteLine(line string, rename func(string) (string, bool)) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range tagPattern.FindAllStringIndex(line, -1) {
+		start, end := loc[0], loc[1]
+		if (start > 0 && line[start-1] == '#') || (end < len(line) && line[end] == '#') {
+			continue
+		}
+
+		renamed, ok := rename(line[start+1 : end])
+		if !ok {
+			continue
+		}
+
+		b.WriteString(line[last:start])
+		b.WriteString("#" + renamed)
+		last = end
+	}
+
+	if last == 0 {
+		return line
+	}
+	b.WriteString(line[last:])
+	return b.String()
+}
+
+// remove detaches the node at a path from the tree and returns it, or nil
+func (t *Tree) remove(path string) *Node {
+	segments := strings.Split(path, Separator)
+	siblings := &t.Categories
+
+	for i, segment := range segments {
+		idx := -1
+		for j, node := range *siblings {
+			if node.Name == segment {
+				idx = j
+				break
+			}
+		}
+		if idx < 0 {
+			return nil
+		}
+
+		node := (*siblings)[idx]
+		if i == len(segments)-1 {
+			*siblings = append((*siblings)[:idx], (*siblings)[idx+1:]...)
+			return node
+		}
+		siblings = &node.Children
+	}
+
+	return nil
+}
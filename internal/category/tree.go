@@ -0,0 +1,315 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"spendgrid/internal/ledger"
+)
+
+// Separator separates the levels of a tag path, e.g. #yemek:market
+const Separator = ":"
+
+// Node is a category in the tree
+// In categories.yml a node is either a plain name or a mapping with name, aliases and children
+type Node struct {
+	Name     string   `yaml:"name"`
+	Aliases  []string `yaml:"aliases,omitempty"`
+	Children []*Node  `yaml:"children,omitempty"`
+}
+
+// UnmarshalYAML accepts both a plain name and a full node mapping
+func (n *Node) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		n.Name = value.Value
+		return nil
+	}
+
+	type plain Node
+	return value.Decode((*plain)(n))
+}
+
+// MarshalYAML writes nodes without aliases and children as plain names
+func (n *Node) MarshalYAML() (interface{}, error) {
+	if len(n.Aliases) == 0 && len(n.Children) == 0 {
+		return n.Name, nil
+	}
+
+	type plain Node
+	return (*plain)(n), nil
+}
+
+// Tree is the category tree defined in categories.yml
+type Tree struct {
+	Categories []*Node `yaml:"categories"`
+
+	paths   map[string]bool     // full path -> exists
+	aliases map[string]string   // alias -> full path
+	names   map[string][]string // node name -> full paths
+}
+
+// GetCategoriesFilePath returns the path to categories.yml
+func GetCategoriesFilePath() string {
+	return filepath.Join("_config", "categories.yml")
+}
+
+// Load loads the category tree of the current SpendGrid directory
+// A missing file gives an empty tree
+func Load() (*Tree, error) {
+	return LoadLedger(ledger.Current())
+}
+
+// LoadLedger loads the category tree of a ledger
+func LoadLedger(l *ledger.Ledger) (*Tree, error) {
+	tree := &Tree{}
+
+	data, err := l.ReadFile(GetCategoriesFilePath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			tree.index()
+			return tree, nil
+		}
+		return nil, fmt.Errorf("failed to read categories: %v", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse categories: %v", err)
+	}
+
+	if len(doc.Content) > 0 {
+		root := doc.Content[0]
+		// Older files are a bare list of tags
+		if root.Kind == yaml.SequenceNode {
+			err = root.Decode(&tree.Categories)
+		} else {
+			err = root.Decode(tree)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse categories: %v", err)
+		}
+	}
+
+	// Plain names may be written as paths, e.g. "- yemek:market"
+	flat := tree.Categories
+	tree.Categories = nil
+	for _, node := range flat {
+		tree.merge(node)
+	}
+
+	tree.index()
+	return tree, nil
+}
+
+// Save writes the category tree to categories.yml of the current ledger
+func Save(tree *Tree) error {
+	return SaveLedger(ledger.Current(), tree)
+}
+
+// SaveLedger writes the category tree to categories.yml of a ledger
+func SaveLedger(l *ledger.Ledger, tree *Tree) error {
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("failed to marshal categories: %v", err)
+	}
+
+	header := "# SpendGrid Categories\n# # işareti ile başlayan etiketler, alt kategoriler için #yemek:market\n\n"
+	data = append([]byte(header), data...)
+
+	if err := l.WriteFile(GetCategoriesFilePath(), data); err != nil {
+		return fmt.Errorf("failed to write categories: %v", err)
+	}
+
+	return nil
+}
+
+// merge adds a top-level node, splitting path names and merging duplicates
+func (t *Tree) merge(node *Node) {
+	segments := strings.Split(strings.TrimPrefix(node.Name, "#"), Separator)
+	siblings := &t.Categories
+
+	var target *Node
+	for _, segment := range segments {
+		target = findChild(*siblings, segment)
+		if target == nil {
+			target = &Node{Name: segment}
+			*siblings = append(*siblings, target)
+		}
+		siblings = &target.Children
+	}
+
+	target.Aliases = appendUnique(target.Aliases, node.Aliases...)
+	for _, child := range node.Children {
+		t.mergeInto(target, child)
+	}
+}
+
+func (t *Tree) mergeInto(parent, node *Node) {
+	existing := findChild(parent.Children, node.Name)
+	if existing == nil {
+		parent.Children = append(parent.Children, node)
+		return
+	}
+
+	existing.Aliases = appendUnique(existing.Aliases, node.Aliases...)
+	for _, child := range node.Children {
+		t.mergeInto(existing, child)
+	}
+}
+
+// index builds the lookup tables
+func (t *Tree) index() {
+	t.paths = make(map[string]bool)
+	t.aliases = make(map[string]string)
+	t.names = make(map[string][]string)
+
+	var walk func(nodes []*Node, prefix string)
+	walk = func(nodes []*Node, prefix string) {
+		for _, node := range nodes {
+			path := node.Name
+			if prefix != "" {
+				path = prefix + Separator + node.Name
+			}
+
+			t.paths[path] = true
+			t.names[node.Name] = append(t.names[node.Name], path)
+			for _, alias := range node.Aliases {
+				t.aliases[strings.TrimPrefix(alias, "#")] = path
+			}
+
+			walk(node.Children, path)
+		}
+	}
+	walk(t.Categories, "")
+}
+
+// Resolve returns the full path of a tag
+// A tag resolves through an alias, an existing path, or a unique node name;
+// anything else is returned unchanged
+func (t *Tree) Resolve(tag string) string {
+	if t.paths[tag] {
+		return tag
+	}
+	if path, ok := t.aliases[tag]; ok {
+		return path
+	}
+	if paths := t.names[tag]; len(paths) == 1 {
+		return paths[0]
+	}
+	return tag
+}
+
+// Has returns true if the path exists in the tree
+func (t *Tree) Has(path string) bool {
+	return t.paths[path]
+}
+
+// Paths returns every path in the tree, sorted
+func (t *Tree) Paths() []string {
+	paths := make([]string, 0, len(t.paths))
+	for path := range t.paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Rollup returns the resolved paths of the given tags together with all their ancestors,
+// each path once, so a row counts once at every level
+func (t *Tree) Rollup(tags []string) []string {
+	seen := make(map[string]bool)
+	var paths []string
+	for _, tag := range tags {
+		for _, path := range Ancestors(t.Resolve(tag)) {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// AddTags adds unknown tags to the tree of the current ledger and saves it
+func AddTags(tags []string) error {
+	return AddLedgerTags(ledger.Current(), tags)
+}
+
+// AddLedgerTags adds unknown tags to the tree of a ledger and saves it
+func AddLedgerTags(l *ledger.Ledger, tags []string) error {
+	tree, err := LoadLedger(l)
+	if err != nil {
+		return err
+	}
+
+	added := false
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag == "" || tree.Has(tree.Resolve(tag)) {
+			continue
+		}
+		tree.merge(&Node{Name: tag})
+		added = true
+	}
+
+	if !added {
+		return nil
+	}
+
+	tree.index()
+	return SaveLedger(l, tree)
+}
+
+// Ancestors returns a path and all its parents, from the root down
+// e.g. "yemek:market" -> ["yemek", "yemek:market"]
+func Ancestors(path string) []string {
+	segments := strings.Split(path, Separator)
+	paths := make([]string, 0, len(segments))
+	for i := range segments {
+		paths = append(paths, strings.Join(segments[:i+1], Separator))
+	}
+	return paths
+}
+
+// Depth returns the level of a path, starting at 0 for top-level categories
+func Depth(path string) int {
+	return strings.Count(path, Separator)
+}
+
+// Leaf returns the last segment of a path
+func Leaf(path string) string {
+	if idx := strings.LastIndex(path, Separator); idx >= 0 {
+		return path[idx+1:]
+	}
+	return path
+}
+
+func findChild(nodes []*Node, name string) *Node {
+	for _, node := range nodes {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
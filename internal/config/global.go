@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/adrg/xdg"
 	"gopkg.in/yaml.v3"
@@ -14,9 +15,15 @@ const appName = "spendgrid"
 
 // GlobalConfig represents the global configuration
 type GlobalConfig struct {
-	Language string `yaml:"language" json:"language"`
+	Language      string            `yaml:"language" json:"language"`
+	Ledgers       map[string]string `yaml:"ledgers,omitempty" json:"ledgers,omitempty"`               // name -> ledger directory
+	DefaultLedger string            `yaml:"default_ledger,omitempty" json:"default_ledger,omitempty"` // used outside a ledger directory
+	KeyTimeout    string            `yaml:"key_timeout,omitempty" json:"key_timeout,omitempty"`       // how long the passphrase of an encrypted ledger is remembered
 }
 
+// DefaultKeyTimeout is used when key_timeout is not set
+const DefaultKeyTimeout = 15 * time.Minute
+
 var (
 	globalConfig     *GlobalConfig
 	globalConfigPath string
@@ -69,6 +76,31 @@ func SetLanguage(lang string) error {
 	return saveGlobalConfig()
 }
 
+// SetKeyTimeout sets how long the passphrase of an encrypted ledger is remembered, e.g. 30m
+// 0 asks for the passphrase on every command
+func SetKeyTimeout(value string) error {
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("invalid duration '%s', use e.g. 15m or 1h", value)
+	}
+	if globalConfig == nil {
+		globalConfig = &GlobalConfig{}
+	}
+	globalConfig.KeyTimeout = value
+	return saveGlobalConfig()
+}
+
+// GetKeyTimeout returns how long the passphrase of an encrypted ledger is remembered
+func GetKeyTimeout() time.Duration {
+	if globalConfig == nil || globalConfig.KeyTimeout == "" {
+		return DefaultKeyTimeout
+	}
+	timeout, err := time.ParseDuration(globalConfig.KeyTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
 // GetLanguage returns the configured language
 func GetLanguage() string {
 	if globalConfig == nil || globalConfig.Language == "" {
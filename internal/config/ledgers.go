@@ -0,0 +1,106 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// NamedLedger is a ledger directory registered under a name
+type NamedLedger struct {
+	Name    string `json:"name" yaml:"name"`
+	Path    string `json:"path" yaml:"path"`
+	Default bool   `json:"default" yaml:"default"`
+}
+
+// GetLedgers returns the registered ledgers sorted by name
+func GetLedgers() []NamedLedger {
+	cfg := GetGlobalConfig()
+
+	ledgers := make([]NamedLedger, 0, len(cfg.Ledgers))
+	for name, path := range cfg.Ledgers {
+		ledgers = append(ledgers, NamedLedger{Name: name, Path: path, Default: name == cfg.DefaultLedger})
+	}
+	sort.Slice(ledgers, func(i, j int) bool { return ledgers[i].Name < ledgers[j].Name })
+	return ledgers
+}
+
+// AddLedger registers a ledger directory under a name
+// The first registered ledger becomes the default
+func AddLedger(name, path string) error {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid ledger name '%s'", name)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("invalid ledger path: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(abs, ".spendgrid")); err != nil {
+		return fmt.Errorf("%s is not a spendgrid directory. Run 'spendgrid init' there first", abs)
+	}
+
+	cfg := GetGlobalConfig()
+	if _, ok := cfg.Ledgers[name]; ok {
+		return fmt.Errorf("ledger '%s' already exists", name)
+	}
+	if cfg.Ledgers == nil {
+		cfg.Ledgers = make(map[string]string)
+	}
+	cfg.Ledgers[name] = abs
+	if cfg.DefaultLedger == "" {
+		cfg.DefaultLedger = name
+	}
+
+	return saveGlobalConfig()
+}
+
+// RemoveLedger unregisters a ledger, its files are left untouched
+func RemoveLedger(name string) error {
+	cfg := GetGlobalConfig()
+	if _, ok := cfg.Ledgers[name]; !ok {
+		return fmt.Errorf("ledger '%s' not found", name)
+	}
+
+	delete(cfg.Ledgers, name)
+	if cfg.DefaultLedger == name {
+		cfg.DefaultLedger = ""
+	}
+
+	return saveGlobalConfig()
+}
+
+// SetDefaultLedger sets the ledger used outside a ledger directory, an empty name clears it
+func SetDefaultLedger(name string) error {
+	cfg := GetGlobalConfig()
+	if name != "" {
+		if _, ok := cfg.Ledgers[name]; !ok {
+			return fmt.Errorf("ledger '%s' not found", name)
+		}
+	}
+
+	cfg.DefaultLedger = name
+	return saveGlobalConfig()
+}
+
+// GetDefaultLedger returns the default ledger, ok is false if none is set
+func GetDefaultLedger() (NamedLedger, bool) {
+	cfg := GetGlobalConfig()
+	path, ok := cfg.Ledgers[cfg.DefaultLedger]
+	if !ok {
+		return NamedLedger{}, false
+	}
+	return NamedLedger{Name: cfg.DefaultLedger, Path: path, Default: true}, true
+}
+
+// ResolveLedger returns the directory of a registered ledger name,
+// anything else is treated as a directory path
+func ResolveLedger(nameOrPath string) string {
+	if path, ok := GetGlobalConfig().Ledgers[nameOrPath]; ok {
+		return path
+	}
+	return nameOrPath
+}
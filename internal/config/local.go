@@ -0,0 +1,85 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"spendgrid/internal/ledger"
+)
+
+const defaultBaseCurrency = "TRY"
+
+// LocalSettings represents _config/settings.yml of a SpendGrid directory
+type LocalSettings struct {
+	BaseCurrency string `yaml:"base_currency"`
+	DateFormat   string `yaml:"date_format"`
+	Strict       bool   `yaml:"strict"` // Only allow tags and projects listed in categories.yml and projects.yml
+}
+
+// GetLocalSettingsPath returns the path to the local settings file
+func GetLocalSettingsPath() string {
+	return filepath.Join("_config", "settings.yml")
+}
+
+// LoadLocalSettings loads the settings of the current SpendGrid directory
+func LoadLocalSettings() (*LocalSettings, error) {
+	return LoadLedgerSettings(ledger.Current())
+}
+
+// LoadLedgerSettings loads the settings of a ledger
+func LoadLedgerSettings(l *ledger.Ledger) (*LocalSettings, error) {
+	settings := &LocalSettings{BaseCurrency: defaultBaseCurrency}
+
+	data, err := l.ReadFile(GetLocalSettingsPath())
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return settings, nil
+		}
+		return nil, fmt.Errorf("failed to read local settings: %v", err)
+	}
+
+	if err := yaml.Unmarshal(data, settings); err != nil {
+		return nil, fmt.Errorf("failed to parse local settings: %v", err)
+	}
+
+	settings.BaseCurrency = strings.ToUpper(strings.TrimSpace(settings.BaseCurrency))
+	if settings.BaseCurrency == "" {
+		settings.BaseCurrency = defaultBaseCurrency
+	}
+
+	return settings, nil
+}
+
+// GetBaseCurrency returns the base currency of the current SpendGrid directory
+// Falls back to TRY if settings cannot be read
+func GetBaseCurrency() string {
+	return LedgerBaseCurrency(ledger.Current())
+}
+
+// LedgerBaseCurrency returns the base currency of a ledger, TRY if settings cannot be read
+func LedgerBaseCurrency(l *ledger.Ledger) string {
+	settings, err := LoadLedgerSettings(l)
+	if err != nil {
+		return defaultBaseCurrency
+	}
+	return settings.BaseCurrency
+}
+
+// IsStrict returns true if tags and projects are restricted to the configured lists
+func IsStrict() bool {
+	return LedgerStrict(ledger.Current())
+}
+
+// LedgerStrict returns true if tags and projects of a ledger are restricted to the configured lists
+func LedgerStrict(l *ledger.Ledger) bool {
+	settings, err := LoadLedgerSettings(l)
+	if err != nil {
+		return false
+	}
+	return settings.Strict
+}
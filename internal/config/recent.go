@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"spendgrid/internal/storage"
 )
 
 // RecentDir represents a recently used SpendGrid directory
@@ -114,9 +116,9 @@ func (s *RecentDirsStore) moveToFront(i int) {
 	s.Directories[0] = item
 }
 
-// SaveCurrentDirectory saves the current working directory to recent list
+// SaveCurrentDirectory saves the ledger directory commands work on to the recent list
 func SaveCurrentDirectory() error {
-	cwd, err := os.Getwd()
+	cwd, err := storage.Root()
 	if err != nil {
 		return fmt.Errorf("failed to get current directory: %v", err)
 	}
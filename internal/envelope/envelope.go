@@ -0,0 +1,459 @@
+package envelope
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"spendgrid/internal/category"
+	"spendgrid/internal/config"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/reports"
+	"spendgrid/internal/storage"
+)
+
+// Allocation is a single line of the ENVELOPES section
+// Assign: "- market | 3000.00 TRY"
+// Move:   "- eglence > market | 200.00 TRY"
+type Allocation struct {
+	From       string // Empty for assignments from income
+	To         string
+	Amount     float64
+	Currency   string
+	LineNumber int
+}
+
+// IsMove returns true if the allocation moves money between envelopes
+func (a *Allocation) IsMove() bool {
+	return a.From != ""
+}
+
+// Envelope is the state of a single envelope in a month, in base currency
+type Envelope struct {
+	Name      string
+	Carry     float64 // Available amount carried from the previous month
+	Assigned  float64 // Assigned and moved in during the month (moved out is negative)
+	Activity  float64 // Rows tagged with the envelope (expenses are negative)
+	Available float64 // Carry + assigned + activity
+}
+
+// Summary is the envelope view of a month
+type Summary struct {
+	Year         int
+	Month        int
+	BaseCurrency string
+	ToBeAssigned float64 // Income so far minus everything assigned so far
+	Envelopes    []*Envelope
+}
+
+// ParseAllocation parses an envelope line, returns nil if the line is not an allocation
+func ParseAllocation(line string, lineNum int) *Allocation {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "- ") {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(trimmed, "- "), "|")
+	if len(parts) < 2 {
+		return nil
+	}
+
+	amount, curr, err := parser.ParseAmount(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return nil
+	}
+
+	alloc := &Allocation{
+		Amount:     amount,
+		Currency:   curr,
+		LineNumber: lineNum,
+	}
+
+	names := strings.TrimSpace(parts[0])
+	if idx := strings.Index(names, ">"); idx >= 0 {
+		alloc.From = normalizeName(names[:idx])
+		alloc.To = normalizeName(names[idx+1:])
+	} else {
+		alloc.To = normalizeName(names)
+	}
+
+	if alloc.To == "" || (alloc.IsMove() && alloc.From == "") {
+		return nil
+	}
+
+	return alloc
+}
+
+// FormatAllocation formats an allocation as an envelope line
+func FormatAllocation(a *Allocation) string {
+	names := a.To
+	if a.IsMove() {
+		names = a.From + " > " + a.To
+	}
+	return fmt.Sprintf("- %s | %.2f %s", names, a.Amount, a.Currency)
+}
+
+// LoadAllocations reads the ENVELOPES section of a month file
+func LoadAllocations(year, month int) ([]*Allocation, error) {
+	content, err := storage.ReadFile(ledger.MonthPath(year, month))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read month file: %v", err)
+	}
+
+	var allocations []*Allocation
+	inSection := false
+	for i, line := range strings.Split(string(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "##") {
+			inSection = trimmed == parser.EnvelopesSection
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		if alloc := ParseAllocation(line, i+1); alloc != nil {
+			allocations = append(allocations, alloc)
+		}
+	}
+
+	return allocations, nil
+}
+
+// appendAllocation adds an allocation line to the ENVELOPES section, creating the section if needed
+func appendAllocation(year, month int, alloc *Allocation) error {
+	path := ledger.MonthPath(year, month)
+	content, err := storage.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("month file %s not found", path)
+		}
+		return fmt.Errorf("failed to read month file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+
+	sectionIdx := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == parser.EnvelopesSection {
+			sectionIdx = i
+			break
+		}
+	}
+
+	if sectionIdx == -1 {
+		lines = append(lines, "", parser.EnvelopesSection)
+		sectionIdx = len(lines) - 1
+	}
+
+	// Insert after the last allocation line of the section
+	insertIdx := sectionIdx + 1
+	for i := sectionIdx + 1; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(trimmed, "##") {
+			break
+		}
+		if trimmed != "" {
+			insertIdx = i + 1
+		}
+	}
+
+	lines = append(lines[:insertIdx], append([]string{FormatAllocation(alloc)}, lines[insertIdx:]...)...)
+
+	if err := storage.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write month file: %v", err)
+	}
+	return nil
+}
+
+// Assign assigns money from income to an envelope
+// amount may be negative to return money to be assigned
+func Assign(name, amount, period string) error {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return err
+	}
+
+	value, curr, err := parseAllocationAmount(amount)
+	if err != nil {
+		return err
+	}
+
+	alloc := &Allocation{To: normalizeName(name), Amount: value, Currency: curr}
+	if alloc.To == "" {
+		return fmt.Errorf("envelope name cannot be empty")
+	}
+
+	if err := appendAllocation(year, month, alloc); err != nil {
+		return err
+	}
+
+	color.Green(i18n.T("envelope.assigned"), alloc.Amount, alloc.Currency, alloc.To)
+	return warnIfOverAssigned(year, month)
+}
+
+// Move moves money from one envelope to another
+func Move(from, to, amount, period string) error {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return err
+	}
+
+	value, curr, err := parseAllocationAmount(amount)
+	if err != nil {
+		return err
+	}
+	if value <= 0 {
+		return fmt.Errorf("amount to move must be positive")
+	}
+
+	alloc := &Allocation{From: normalizeName(from), To: normalizeName(to), Amount: value, Currency: curr}
+	if alloc.From == "" || alloc.To == "" {
+		return fmt.Errorf("envelope names cannot be empty")
+	}
+	if alloc.From == alloc.To {
+		return fmt.Errorf("cannot move money to the same envelope")
+	}
+
+	if err := appendAllocation(year, month, alloc); err != nil {
+		return err
+	}
+
+	color.Green(i18n.T("envelope.moved"), alloc.Amount, alloc.Currency, alloc.From, alloc.To)
+
+	summary, err := BuildSummary(year, month)
+	if err != nil {
+		return err
+	}
+	for _, env := range summary.Envelopes {
+		if env.Name == alloc.From && env.Available < 0 {
+			color.Yellow(i18n.T("envelope.overspent"), env.Name, env.Available, summary.BaseCurrency)
+		}
+	}
+	return nil
+}
+
+func warnIfOverAssigned(year, month int) error {
+	summary, err := BuildSummary(year, month)
+	if err != nil {
+		return err
+	}
+	if summary.ToBeAssigned < -0.005 {
+		color.Yellow(i18n.T("envelope.over_assigned"), -summary.ToBeAssigned, summary.BaseCurrency)
+	}
+	return nil
+}
+
+// BuildSummary computes every envelope up to and including the given month,
+// carrying available amounts over from the first month of the ledger
+func BuildSummary(year, month int) (*Summary, error) {
+	summary := &Summary{
+		Year:         year,
+		Month:        month,
+		BaseCurrency: config.GetBaseCurrency(),
+	}
+
+	years, err := ledger.Years()
+	if err != nil {
+		return nil, err
+	}
+	if len(years) == 0 || years[0] > year {
+		return summary, nil
+	}
+
+	tree, err := category.Load()
+	if err != nil {
+		tree = &category.Tree{}
+	}
+
+	envelopes := make(map[string]*Envelope)
+	get := func(name string) *Envelope {
+		if envelopes[name] == nil {
+			envelopes[name] = &Envelope{Name: name}
+		}
+		return envelopes[name]
+	}
+
+	for y, m := years[0], 1; y < year || (y == year && m <= month); {
+		// Start of a new month: available becomes carry
+		for _, env := range envelopes {
+			env.Carry = env.Available
+			env.Assigned = 0
+			env.Activity = 0
+		}
+
+		allocations, err := LoadAllocations(y, m)
+		if err != nil {
+			return nil, err
+		}
+
+		date := time.Date(y, time.Month(m), 1, 0, 0, 0, 0, time.UTC)
+		for _, alloc := range allocations {
+			amount := alloc.Amount
+			if alloc.Currency != summary.BaseCurrency {
+				converted, err := exchange.ConvertAmount(alloc.Amount, alloc.Currency, summary.BaseCurrency, date)
+				if err != nil {
+					return nil, fmt.Errorf("no exchange rate for envelope line %d in %04d-%02d: %v", alloc.LineNumber, y, m, err)
+				}
+				amount = converted
+			}
+
+			get(alloc.To).Assigned += amount
+			if alloc.IsMove() {
+				get(alloc.From).Assigned -= amount
+			} else {
+				summary.ToBeAssigned -= amount
+			}
+		}
+
+		if _, err := storage.Stat(ledger.MonthPath(y, m)); err == nil {
+			report, _, err := reports.BuildMonthlyReport(ledger.Current(), y, m)
+			if err != nil {
+				return nil, err
+			}
+
+			summary.ToBeAssigned += report.BaseIncome
+			for name, env := range envelopes {
+				env.Activity += report.BaseByCategory[tree.Resolve(name)]
+			}
+		}
+
+		for _, env := range envelopes {
+			env.Available = env.Carry + env.Assigned + env.Activity
+		}
+
+		m++
+		if m > 12 {
+			m = 1
+			y++
+		}
+	}
+
+	for _, env := range envelopes {
+		summary.Envelopes = append(summary.Envelopes, env)
+	}
+	sort.Slice(summary.Envelopes, func(i, j int) bool {
+		return summary.Envelopes[i].Name < summary.Envelopes[j].Name
+	})
+
+	return summary, nil
+}
+
+// ShowEnvelopes prints available, assigned and activity per envelope
+// period: empty for the current month, MM or YYYY-MM
+func ShowEnvelopes(period string) error {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	year, month, err := parsePeriod(period)
+	if err != nil {
+		return err
+	}
+
+	summary, err := BuildSummary(year, month)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	color.Cyan("✉ %s - %04d-%02d (%s)", i18n.T("envelope.header"), year, month, summary.BaseCurrency)
+	fmt.Println(strings.Repeat("=", 80))
+
+	toBeAssigned := fmt.Sprintf("%s: %.2f %s", i18n.T("envelope.to_be_assigned"), summary.ToBeAssigned, summary.BaseCurrency)
+	switch {
+	case summary.ToBeAssigned < -0.005:
+		color.Red("%s", toBeAssigned)
+	case summary.ToBeAssigned > 0.005:
+		color.Yellow("%s", toBeAssigned)
+	default:
+		color.Green("%s", toBeAssigned)
+	}
+	fmt.Println(strings.Repeat("-", 80))
+
+	if len(summary.Envelopes) == 0 {
+		fmt.Println(i18n.T("envelope.empty"))
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Printf("%-24s %12s %12s %12s %14s\n", "Envelope", "Carry", "Assigned", "Activity", "Available")
+	fmt.Println(strings.Repeat("-", 80))
+
+	for _, env := range summary.Envelopes {
+		fmt.Printf("%-24s %12.2f %12.2f %12.2f ", output.Truncate(env.Name, 24), env.Carry, env.Assigned, env.Activity)
+		switch {
+		case env.Available < -0.005:
+			color.Red("%14.2f", env.Available)
+		case env.Available > 0.005:
+			color.Green("%14.2f", env.Available)
+		default:
+			fmt.Printf("%14.2f\n", env.Available)
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println()
+	return nil
+}
+
+// parseAllocationAmount parses an amount, defaulting to the base currency
+func parseAllocationAmount(input string) (float64, string, error) {
+	input = strings.TrimSpace(input)
+	value, curr, err := parser.ParseAmount(input)
+	if err != nil {
+		// Plain number without currency
+		value, curr, err = parser.ParseAmount(input + " " + config.GetBaseCurrency())
+		if err != nil {
+			return 0, "", fmt.Errorf("invalid amount: %s", input)
+		}
+	}
+	return value, curr, nil
+}
+
+// parsePeriod parses an empty string, MM or YYYY-MM into year and month
+func parsePeriod(period string) (int, int, error) {
+	now := time.Now()
+	period = strings.TrimSpace(period)
+
+	if period == "" {
+		return now.Year(), int(now.Month()), nil
+	}
+
+	if strings.Contains(period, "-") {
+		t, err := time.Parse("2006-01", period)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid period (use MM or YYYY-MM): %s", period)
+		}
+		return t.Year(), int(t.Month()), nil
+	}
+
+	month, err := strconv.Atoi(period)
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0, fmt.Errorf("invalid period (use MM or YYYY-MM): %s", period)
+	}
+	return now.Year(), month, nil
+}
+
+func normalizeName(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), "#")
+}
@@ -10,13 +10,31 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/adrg/xdg"
+
+	"spendgrid/internal/parser"
 )
 
 const appName = "spendgrid"
 
+// httpClient keeps reports from stalling when the rate APIs do not answer
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// offlineRetry is how long fetching is skipped after a failed fetch, so a report does not
+// wait on every missing date while a long-running server or TUI still recovers
+const offlineRetry = time.Minute
+
+// The cache file is read once per process and again only when it changes on disk
+var (
+	cacheMu      sync.Mutex
+	loaded       *ExchangeRateCache
+	loadedMtime  time.Time
+	offlineUntil time.Time // fetches are skipped until then after a failed fetch
+)
+
 // TCMBClient implements the TCMB (Central Bank of Turkey) API
 type TCMBClient struct {
 	BaseURL string
@@ -56,7 +74,7 @@ func (c *TCMBClient) FetchRates(date time.Time) (map[string]float64, error) {
 
 	url := fmt.Sprintf("%s/%s/%s.xml", c.BaseURL, monthStr, dateStr)
 
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch TCMB rates: %v", err)
 	}
@@ -119,7 +137,7 @@ func (c *FrankfurtClient) FetchRates(date time.Time) (map[string]float64, error)
 	dateStr := date.Format("2006-01-02")
 	url := fmt.Sprintf("%s/%s?from=EUR", c.BaseURL, dateStr)
 
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch Frankfurt rates: %v", err)
 	}
@@ -166,30 +184,41 @@ func GetCachePath() string {
 }
 
 // LoadCache loads the exchange rate cache from disk
+// The parsed cache is kept in memory until the file changes
 func LoadCache() (*ExchangeRateCache, error) {
-	cachePath := GetCachePath()
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
 
-	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
-		return &ExchangeRateCache{
-			Rates: make(map[string]map[string]float64),
-		}, nil
-	}
+	cachePath := GetCachePath()
 
-	data, err := os.ReadFile(cachePath)
-	if err != nil {
+	var mtime time.Time
+	info, err := os.Stat(cachePath)
+	if err == nil {
+		mtime = info.ModTime()
+	} else if !os.IsNotExist(err) {
 		return nil, fmt.Errorf("failed to read cache: %v", err)
 	}
+	if loaded != nil && loadedMtime.Equal(mtime) {
+		return loaded, nil
+	}
 
-	var cache ExchangeRateCache
-	if err := json.Unmarshal(data, &cache); err != nil {
-		return nil, fmt.Errorf("failed to parse cache: %v", err)
+	cache := &ExchangeRateCache{}
+	if !mtime.IsZero() {
+		data, err := os.ReadFile(cachePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %v", err)
+		}
+		if err := json.Unmarshal(data, cache); err != nil {
+			return nil, fmt.Errorf("failed to parse cache: %v", err)
+		}
 	}
 
 	if cache.Rates == nil {
 		cache.Rates = make(map[string]map[string]float64)
 	}
 
-	return &cache, nil
+	loaded, loadedMtime = cache, mtime
+	return cache, nil
 }
 
 // SaveCache saves the exchange rate cache to disk
@@ -207,7 +236,16 @@ func (c *ExchangeRateCache) SaveCache() error {
 		return fmt.Errorf("failed to marshal cache: %v", err)
 	}
 
-	return os.WriteFile(cachePath, data, 0644)
+	if err := os.WriteFile(cachePath, data, 0644); err != nil {
+		return err
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	if info, err := os.Stat(cachePath); err == nil {
+		loaded, loadedMtime = c, info.ModTime()
+	}
+	return nil
 }
 
 // GetRate gets a specific exchange rate for a date
@@ -220,6 +258,18 @@ func (c *ExchangeRateCache) GetRate(date string, currency string) (float64, bool
 	return 0, false
 }
 
+// LatestRate returns the most recent rate of a currency on or before a date
+func (c *ExchangeRateCache) LatestRate(date string, currency string) (float64, bool) {
+	latest := ""
+	rate := 0.0
+	for day, dateRates := range c.Rates {
+		if r, ok := dateRates[currency]; ok && day <= date && day > latest {
+			latest, rate = day, r
+		}
+	}
+	return rate, latest != ""
+}
+
 // SetRate sets an exchange rate for a date
 func (c *ExchangeRateCache) SetRate(date string, currency string, rate float64) {
 	if c.Rates[date] == nil {
@@ -242,6 +292,13 @@ func FetchAndCacheRates(date time.Time, preferTCMB bool) error {
 		return nil // Already cached
 	}
 
+	cacheMu.Lock()
+	skip := time.Now().Before(offlineUntil)
+	cacheMu.Unlock()
+	if skip {
+		return fmt.Errorf("failed to fetch rates: an earlier request failed")
+	}
+
 	var rates map[string]float64
 
 	if preferTCMB {
@@ -265,9 +322,16 @@ func FetchAndCacheRates(date time.Time, preferTCMB bool) error {
 	}
 
 	if err != nil {
+		cacheMu.Lock()
+		offlineUntil = time.Now().Add(offlineRetry)
+		cacheMu.Unlock()
 		return fmt.Errorf("failed to fetch rates: %v", err)
 	}
 
+	cacheMu.Lock()
+	offlineUntil = time.Time{}
+	cacheMu.Unlock()
+
 	// Cache the rates
 	for currency, rate := range rates {
 		cache.SetRate(dateStr, currency, rate)
@@ -278,10 +342,17 @@ func FetchAndCacheRates(date time.Time, preferTCMB bool) error {
 
 // GetExchangeRate gets the exchange rate for converting to base currency (TRY)
 func GetExchangeRate(date time.Time, fromCurrency string) (float64, error) {
+	return exchangeRate(date, fromCurrency, true)
+}
+
+// exchangeRate returns the TRY rate of a currency at a date
+// Rates are fetched only if fetch is set and the date is not in the future,
+// otherwise the latest cached rate on or before the date is used
+func exchangeRate(date time.Time, fromCurrency string, fetch bool) (float64, error) {
 	fromCurrency = strings.ToUpper(fromCurrency)
 
 	// If already TRY, return 1
-	if fromCurrency == "TRY" || fromCurrency == "TL" {
+	if isTRY(fromCurrency) {
 		return 1.0, nil
 	}
 
@@ -297,6 +368,14 @@ func GetExchangeRate(date time.Time, fromCurrency string) (float64, error) {
 		return rate, nil
 	}
 
+	// No rates are published for future dates, planned rows use the latest known rate
+	if !fetch || date.After(time.Now()) {
+		if rate, ok := cache.LatestRate(dateStr, fromCurrency); ok {
+			return rate, nil
+		}
+		return 0, fmt.Errorf("exchange rate not found for %s on %s", fromCurrency, dateStr)
+	}
+
 	// Fetch from API (use TCMB by default for Turkish users)
 	preferTCMB := true // This could be configurable
 	if err := FetchAndCacheRates(date, preferTCMB); err != nil {
@@ -304,6 +383,10 @@ func GetExchangeRate(date time.Time, fromCurrency string) (float64, error) {
 	}
 
 	// Try again from cache
+	cache, err = LoadCache()
+	if err != nil {
+		return 0, err
+	}
 	if rate, ok := cache.GetRate(dateStr, fromCurrency); ok {
 		return rate, nil
 	}
@@ -313,6 +396,10 @@ func GetExchangeRate(date time.Time, fromCurrency string) (float64, error) {
 
 // ConvertAmount converts an amount from one currency to another
 func ConvertAmount(amount float64, fromCurrency, toCurrency string, date time.Time) (float64, error) {
+	return convertAmount(amount, fromCurrency, toCurrency, date, true)
+}
+
+func convertAmount(amount float64, fromCurrency, toCurrency string, date time.Time, fetch bool) (float64, error) {
 	fromCurrency = strings.ToUpper(fromCurrency)
 	toCurrency = strings.ToUpper(toCurrency)
 
@@ -321,7 +408,7 @@ func ConvertAmount(amount float64, fromCurrency, toCurrency string, date time.Ti
 	}
 
 	// Convert to base currency (TRY) first
-	fromRate, err := GetExchangeRate(date, fromCurrency)
+	fromRate, err := exchangeRate(date, fromCurrency, fetch)
 	if err != nil {
 		return 0, err
 	}
@@ -329,12 +416,12 @@ func ConvertAmount(amount float64, fromCurrency, toCurrency string, date time.Ti
 	// amount in TRY
 	amountInBase := amount * fromRate
 
-	if toCurrency == "TRY" || toCurrency == "TL" {
+	if isTRY(toCurrency) {
 		return amountInBase, nil
 	}
 
 	// Convert from base to target
-	toRate, err := GetExchangeRate(date, toCurrency)
+	toRate, err := exchangeRate(date, toCurrency, fetch)
 	if err != nil {
 		return 0, err
 	}
@@ -342,8 +429,12 @@ func ConvertAmount(amount float64, fromCurrency, toCurrency string, date time.Ti
 	return amountInBase / toRate, nil
 }
 
-// RefreshRates forces a refresh of exchange rates
+// RefreshRates forces a refresh of exchange rates, even right after a failed fetch
 func RefreshRates() error {
+	cacheMu.Lock()
+	offlineUntil = time.Time{}
+	cacheMu.Unlock()
+
 	today := time.Now()
 	preferTCMB := true
 	return FetchAndCacheRates(today, preferTCMB)
@@ -360,6 +451,12 @@ func SetManualRate(dateStr, currency string, rate float64) error {
 	return cache.SaveCache()
 }
 
+// isTRY reports whether a currency code is the Turkish lira, the unit of cached rates
+func isTRY(currency string) bool {
+	currency = strings.ToUpper(currency)
+	return currency == "TRY" || currency == "TL"
+}
+
 func isToday(date time.Time) bool {
 	today := time.Now()
 	return date.Year() == today.Year() &&
@@ -367,17 +464,47 @@ func isToday(date time.Time) bool {
 		date.Day() == today.Day()
 }
 
+// RateTable holds the cached rates of a single day
+type RateTable struct {
+	Date  string             `json:"date" yaml:"date"`
+	Rates map[string]float64 `json:"rates" yaml:"rates"` // currency -> rate in TRY
+}
+
+// Table returns the rates for CSV output
+func (t *RateTable) Table() interface{} {
+	return t.Rates
+}
+
+// TodayRates returns the cached rates of today, empty if none are cached
+func TodayRates() (*RateTable, error) {
+	return DayRates(time.Now().Format("2006-01-02"))
+}
+
+// DayRates returns the cached rates of a day (YYYY-MM-DD), empty if none are cached
+func DayRates(date string) (*RateTable, error) {
+	cache, err := LoadCache()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %v", err)
+	}
+
+	rates := cache.Rates[date]
+	if rates == nil {
+		rates = make(map[string]float64)
+	}
+
+	return &RateTable{Date: date, Rates: rates}, nil
+}
+
 // ShowRates displays exchange rates in a table format
 func ShowRates() error {
-	cache, err := LoadCache()
+	table, err := TodayRates()
 	if err != nil {
-		return fmt.Errorf("failed to load exchange rates: %v", err)
+		return err
 	}
 
-	today := time.Now().Format("2006-01-02")
-	todayRates, exists := cache.Rates[today]
+	today, todayRates := table.Date, table.Rates
 
-	if !exists || len(todayRates) == 0 {
+	if len(todayRates) == 0 {
 		fmt.Println("No exchange rates found for today.")
 		fmt.Println("Run 'spendgrid exchange refresh' to fetch current rates.")
 		return nil
@@ -421,3 +548,24 @@ func ShowRates() error {
 	fmt.Println("\n💡 Tip: Use 'spendgrid exchange refresh' to update rates")
 	return nil
 }
+
+// ConvertTransaction converts a transaction amount to the target currency
+// The manual @rate of the transaction is TRY per unit, the TRY amount is then converted
+// to the target at the given date. Without @rate the cached/fetched rate is used,
+// planned rule lines only use cached rates
+func ConvertTransaction(tx *parser.Transaction, toCurrency string, date time.Time) (float64, error) {
+	if strings.EqualFold(tx.Currency, toCurrency) {
+		return tx.Amount, nil
+	}
+
+	fetch := !tx.IsRule || tx.Completed
+	if tx.Rate > 0 {
+		inTRY := tx.Amount * tx.Rate
+		if isTRY(toCurrency) {
+			return inTRY, nil
+		}
+		return convertAmount(inTRY, "TRY", toCurrency, date, fetch)
+	}
+
+	return convertAmount(tx.Amount, tx.Currency, toCurrency, date, fetch)
+}
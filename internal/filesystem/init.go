@@ -10,6 +10,8 @@ import (
 	"time"
 
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
 )
 
 // Init initializes SpendGrid in the current directory
@@ -63,15 +65,18 @@ func createDirectoryStructure() error {
 		return fmt.Errorf("failed to create year directory: %v", err)
 	}
 
-	// Create .spendgrid version file
+	// Create .spendgrid state directory with its version file
 	// Format: schema_version build_timestamp
-	schemaVersion := "1"
+	schemaVersion := strconv.Itoa(ledger.SchemaVersion)
 	buildTimestamp := strconv.FormatInt(time.Now().Unix(), 10)
 	versionInfo := fmt.Sprintf("%s %s\n", schemaVersion, buildTimestamp)
 
-	if err := os.WriteFile(".spendgrid", []byte(versionInfo), 0644); err != nil {
+	if err := os.MkdirAll(ledger.StateDir, 0755); err != nil {
 		return fmt.Errorf("failed to create .spendgrid: %v", err)
 	}
+	if err := os.WriteFile(filepath.Join(ledger.StateDir, ledger.VersionFile), []byte(versionInfo), 0644); err != nil {
+		return fmt.Errorf("failed to create .spendgrid/version: %v", err)
+	}
 
 	// Create config files
 	if err := createConfigFiles(); err != nil {
@@ -91,6 +96,8 @@ func createConfigFiles() error {
 	settings := `# SpendGrid Local Settings
 base_currency: TRY
 date_format: "DD.MM.YYYY"
+# strict: true ile sadece categories.yml ve projects.yml içindeki etiket/projelere izin verilir
+strict: false
 `
 	if err := os.WriteFile(filepath.Join("_config", "settings.yml"), []byte(settings), 0644); err != nil {
 		return fmt.Errorf("failed to create settings.yml: %v", err)
@@ -107,8 +114,13 @@ rules: []
 
 	// categories.yml
 	categories := `# SpendGrid Categories
-# # işareti ile başlayan etiketler
-#categories: []
+# # işareti ile başlayan etiketler, alt kategoriler için #yemek:market
+#categories:
+#  - name: yemek
+#    aliases: [food]
+#    children:
+#      - market
+#      - restoran
 `
 	if err := os.WriteFile(filepath.Join("_config", "categories.yml"), []byte(categories), 0644); err != nil {
 		return fmt.Errorf("failed to create categories.yml: %v", err)
@@ -123,6 +135,19 @@ rules: []
 		return fmt.Errorf("failed to create projects.yml: %v", err)
 	}
 
+	// budgets.yml
+	budgets := `# SpendGrid Budgets
+# Etiket başına aylık harcama limitleri
+# budgets:
+#   - tag: market
+#     limit: 3000
+#     rollover: true
+budgets: []
+`
+	if err := os.WriteFile(filepath.Join("_config", "budgets.yml"), []byte(budgets), 0644); err != nil {
+		return fmt.Errorf("failed to create budgets.yml: %v", err)
+	}
+
 	// backlog.md
 	backlog := `# Backlog
 # Tarihsiz işlemler, beklenen alacaklar, planlanan büyük harcamalar
@@ -164,7 +189,13 @@ func createYearFiles(year string) error {
 // CheckSchemaVersion checks the schema version of the current SpendGrid directory
 // Returns the schema version and build timestamp, or error if not initialized
 func CheckSchemaVersion() (schemaVersion string, buildTimestamp int64, err error) {
-	content, err := os.ReadFile(".spendgrid")
+	// Older ledgers keep the version in the .spendgrid file itself
+	path := storage.Path(ledger.StateDir)
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = storage.Path(filepath.Join(ledger.StateDir, ledger.VersionFile))
+	}
+
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", 0, fmt.Errorf("not a spendgrid directory")
 	}
@@ -0,0 +1,252 @@
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"spendgrid/internal/config"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/pool"
+	"spendgrid/internal/rules"
+)
+
+// MonthProjection holds the projected cash flow of a single month in base currency
+type MonthProjection struct {
+	Year         int     `json:"year" yaml:"year"`
+	Month        int     `json:"month" yaml:"month"`
+	Actual       float64 `json:"actual" yaml:"actual"`   // Rows and completed rule lines
+	Planned      float64 `json:"planned" yaml:"planned"` // Unchecked rule lines in the month file
+	Rules        float64 `json:"rules" yaml:"rules"`     // Rules not synced to the month file yet
+	Pool         float64 `json:"pool" yaml:"pool"`       // Dated pool items
+	Net          float64 `json:"net" yaml:"net"`
+	Balance      float64 `json:"balance" yaml:"balance"` // Running balance at the end of the month
+	MissingRates int     `json:"missing_rates" yaml:"missing_rates"`
+}
+
+// Forecast holds a multi-month cash flow projection
+type Forecast struct {
+	BaseCurrency  string             `json:"base_currency" yaml:"base_currency"`
+	Opening       float64            `json:"opening" yaml:"opening"`
+	Months        []*MonthProjection `json:"months" yaml:"months"`
+	FirstNegative *MonthProjection   `json:"first_negative" yaml:"first_negative"`
+}
+
+// Build projects the cash flow for the given number of months starting with the current month
+// If opening is nil, the opening balance is computed from all rows before the current month
+func Build(months int, opening *float64) (*Forecast, error) {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+	if months < 1 {
+		return nil, fmt.Errorf("months must be at least 1")
+	}
+
+	now := time.Now()
+	startYear, startMonth := now.Year(), int(now.Month())
+
+	fc := &Forecast{
+		BaseCurrency: config.GetBaseCurrency(),
+	}
+
+	if opening != nil {
+		fc.Opening = *opening
+	} else {
+		balance, err := openingBalance(startYear, startMonth, fc.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		fc.Opening = balance
+	}
+
+	activeRules, err := rules.GetActiveRules(ledger.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	poolItems, err := pool.LoadItems(ledger.Current())
+	if err != nil {
+		return nil, err
+	}
+
+	balance := fc.Opening
+	year, month := startYear, startMonth
+	for i := 0; i < months; i++ {
+		mp, err := projectMonth(year, month, fc.BaseCurrency, activeRules, poolItems)
+		if err != nil {
+			return nil, err
+		}
+
+		balance += mp.Net
+		mp.Balance = balance
+		fc.Months = append(fc.Months, mp)
+
+		if fc.FirstNegative == nil && balance < 0 {
+			fc.FirstNegative = mp
+		}
+
+		month++
+		if month > 12 {
+			month = 1
+			year++
+		}
+	}
+
+	return fc, nil
+}
+
+// projectMonth combines actual rows, rule lines, unsynced rules and dated pool items of a month
+func projectMonth(year, month int, base string, activeRules []rules.Rule, poolItems []*pool.Item) (*MonthProjection, error) {
+	mp := &MonthProjection{Year: year, Month: month}
+
+	entries, err := ledger.MonthEntries(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rule IDs already present in the month file (checked or not)
+	synced := make(map[string]bool)
+
+	for _, e := range entries {
+		if id := e.Tx.RuleID(); id != "" {
+			synced[id] = true
+		}
+
+		amount, err := exchange.ConvertTransaction(e.Tx, base, e.Date())
+		if err != nil {
+			mp.MissingRates++
+			continue
+		}
+
+		if e.IsPlanned() {
+			mp.Planned += amount
+		} else {
+			mp.Actual += amount
+		}
+	}
+
+	// Expand rules that have not been synced to this month yet
+	for i := range activeRules {
+		rule := &activeRules[i]
+		if synced[rule.ID] || !rule.ShouldApplyInMonth(year, month) {
+			continue
+		}
+
+		amount := math.Abs(rule.Amount)
+		if rule.Type == "expense" || rule.Amount < 0 {
+			amount = -amount
+		}
+
+		tx := &parser.Transaction{
+			Day:      rule.GetScheduledDay(year, month),
+			Amount:   amount,
+			Currency: strings.ToUpper(rule.Currency),
+		}
+		converted, err := exchange.ConvertTransaction(tx, base, dateOf(year, month, tx.Day))
+		if err != nil {
+			mp.MissingRates++
+			continue
+		}
+		mp.Rules += converted
+	}
+
+	// Dated pool items
+	for _, item := range poolItems {
+		if item.Year != year || item.Month != month || item.Amount == 0 {
+			continue
+		}
+
+		curr := item.Currency
+		if curr == "" {
+			curr = base
+		}
+		converted, err := exchange.ConvertAmount(item.Amount, curr, base, dateOf(year, month, 1))
+		if err != nil {
+			mp.MissingRates++
+			continue
+		}
+		mp.Pool += converted
+	}
+
+	mp.Net = mp.Actual + mp.Planned + mp.Rules + mp.Pool
+	return mp, nil
+}
+
+// openingBalance sums all rows before the given month, excluding unchecked rule lines
+func openingBalance(year, month int, base string) (float64, error) {
+	entries, err := ledger.AllEntries()
+	if err != nil {
+		return 0, err
+	}
+
+	balance := 0.0
+	for _, e := range entries {
+		if e.Year > year || (e.Year == year && e.Month >= month) || e.IsPlanned() {
+			continue
+		}
+		amount, err := exchange.ConvertTransaction(e.Tx, base, e.Date())
+		if err != nil {
+			continue
+		}
+		balance += amount
+	}
+
+	return balance, nil
+}
+
+func dateOf(year, month, day int) time.Time {
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// ShowForecast prints a month-by-month projection with running balance
+func ShowForecast(months int, opening *float64) error {
+	fc, err := Build(months, opening)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	color.Cyan("🔮 %s (%s)", i18n.T("forecast.header"), fc.BaseCurrency)
+	fmt.Println(strings.Repeat("=", 100))
+	fmt.Printf("%-10s %13s %13s %13s %13s %13s %15s\n",
+		"Month", "Actual", "Planned", "Rules", "Pool", "Net", "Balance")
+	fmt.Println(strings.Repeat("-", 100))
+	fmt.Printf("%-10s %13s %13s %13s %13s %13s %15.2f\n", "Opening", "", "", "", "", "", fc.Opening)
+
+	for _, mp := range fc.Months {
+		label := fmt.Sprintf("%04d-%02d", mp.Year, mp.Month)
+		if mp.MissingRates > 0 {
+			label += " ⚠"
+		}
+
+		line := fmt.Sprintf("%-10s %13.2f %13.2f %13.2f %13.2f %13.2f %15.2f",
+			label, mp.Actual, mp.Planned, mp.Rules, mp.Pool, mp.Net, mp.Balance)
+
+		switch {
+		case mp == fc.FirstNegative:
+			color.New(color.FgHiRed, color.Bold).Println(line + "  ◀")
+		case mp.Balance < 0:
+			color.HiRed(line)
+		default:
+			fmt.Println(line)
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 100))
+
+	if fc.FirstNegative != nil {
+		color.Red(i18n.T("forecast.negative"),
+			fc.FirstNegative.Year, fc.FirstNegative.Month, fc.FirstNegative.Balance, fc.BaseCurrency)
+	} else {
+		color.Green(i18n.T("forecast.positive"), len(fc.Months))
+	}
+	fmt.Println()
+
+	return nil
+}
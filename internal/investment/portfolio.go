@@ -2,34 +2,32 @@ package investment
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
 	"spendgrid/internal/i18n"
-	"spendgrid/internal/parser"
+	"spendgrid/internal/ledger"
 )
 
 // Investment represents a single investment position
 type Investment struct {
-	Symbol       string
-	Name         string
-	Type         string // stock, gold, crypto, etc.
-	TotalShares  float64
-	TotalCost    float64
-	Currency     string
-	Transactions []InvestmentTransaction
+	Symbol       string                  `json:"symbol" yaml:"symbol"`
+	Name         string                  `json:"name" yaml:"name"`
+	Type         string                  `json:"type" yaml:"type"` // stock, gold, crypto, etc.
+	TotalShares  float64                 `json:"total_shares" yaml:"total_shares"`
+	TotalCost    float64                 `json:"total_cost" yaml:"total_cost"`
+	Currency     string                  `json:"currency" yaml:"currency"`
+	Transactions []InvestmentTransaction `json:"transactions" yaml:"transactions"`
 }
 
 // InvestmentTransaction represents a single buy transaction
 type InvestmentTransaction struct {
-	Date     time.Time
-	Shares   float64
-	Price    float64
-	Currency string
+	Date     time.Time `json:"date" yaml:"date"`
+	Shares   float64   `json:"shares" yaml:"shares"`
+	Price    float64   `json:"price" yaml:"price"`
+	Currency string    `json:"currency" yaml:"currency"`
 }
 
 // Portfolio holds all investments
@@ -79,27 +77,21 @@ func ParseInvestmentFormat(desc string) (*InvestmentTransaction, string, bool) {
 }
 
 // CalculatePortfolio scans all transactions and calculates portfolio
-type CalculatePortfolio func() (*Portfolio, error)
+type CalculatePortfolio func(l *ledger.Ledger) (*Portfolio, error)
 
-func CalculatePortfolioFromTransactions() (*Portfolio, error) {
+func CalculatePortfolioFromTransactions(l *ledger.Ledger) (*Portfolio, error) {
 	portfolio := make(Portfolio)
 
 	// Get current year
 	now := time.Now()
-	year := strconv.Itoa(now.Year())
 
 	// Parse all month files
 	for month := 1; month <= 12; month++ {
-		monthFile := parser.GetMonthFile(month)
-		filePath := filepath.Join(year, monthFile)
-
-		content, err := os.ReadFile(filePath)
+		parsed, _, err := l.LoadMonth(now.Year(), month)
 		if err != nil {
-			continue // Skip if file doesn't exist
+			continue // Skip unreadable files
 		}
 
-		parsed, _ := parser.ParseMonthFile(string(content))
-
 		for _, tx := range parsed {
 			// Check if this is an investment transaction
 			// Look for #invesment# tag (system tag)
@@ -151,11 +143,12 @@ func CalculatePortfolioFromTransactions() (*Portfolio, error) {
 
 // GenerateInvestmentReport generates the investment report
 func GenerateInvestmentReport() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
-	portfolio, err := CalculatePortfolioFromTransactions()
+	portfolio, err := CalculatePortfolioFromTransactions(l)
 	if err != nil {
 		return err
 	}
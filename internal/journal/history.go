@@ -0,0 +1,72 @@
+package journal
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/output"
+)
+
+// HistoryItem describes a journal entry without the file contents
+type HistoryItem struct {
+	ID      string   `json:"id" yaml:"id"`
+	Time    string   `json:"time" yaml:"time"`
+	Command string   `json:"command" yaml:"command"`
+	Summary string   `json:"summary" yaml:"summary"`
+	Files   []string `json:"files" yaml:"files"`
+	Undone  bool     `json:"undone" yaml:"undone"`
+}
+
+// History returns the newest entries first, limit <= 0 returns all
+func History(limit int) ([]*HistoryItem, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*HistoryItem{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if limit > 0 && len(items) == limit {
+			break
+		}
+
+		entry := entries[i]
+		item := &HistoryItem{
+			ID:      entry.ID,
+			Time:    entry.Time.Format("2006-01-02 15:04:05"),
+			Command: entry.Command,
+			Summary: entry.Summary,
+			Undone:  entry.Undone,
+		}
+		for _, c := range entry.Changes {
+			item.Files = append(item.Files, c.Path)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// ShowHistory prints journal entries
+func ShowHistory(items []*HistoryItem) {
+	fmt.Println()
+	if len(items) == 0 {
+		fmt.Println(i18n.T("undo.empty"))
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("%-19s  %-40s  %s\n", "Time", "Change", "Files")
+	fmt.Println(strings.Repeat("-", 90))
+	for _, item := range items {
+		line := fmt.Sprintf("%-19s  %-40s  %s", item.Time, output.Truncate(item.Summary, 40), strings.Join(item.Files, ", "))
+		if item.Undone {
+			color.New(color.Faint).Println(line + "  " + i18n.T("undo.undone"))
+		} else {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
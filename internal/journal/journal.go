@@ -0,0 +1,294 @@
+package journal
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+)
+
+// FileChange is the content of a file before and after an operation
+type FileChange struct {
+	Path    string `json:"path"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Existed bool   `json:"existed"` // false if the operation created the file
+	Deleted bool   `json:"deleted"` // true if the operation removed the file
+}
+
+// Entry is a single recorded operation
+type Entry struct {
+	ID      string       `json:"id"`
+	Time    time.Time    `json:"time"`
+	Command string       `json:"command"`
+	Summary string       `json:"summary"`
+	Undone  bool         `json:"undone"`
+	Changes []FileChange `json:"changes"`
+}
+
+// maxEntries is the number of entries kept, older ones are dropped
+const maxEntries = 200
+
+// OnChange is called after changes were recorded, undone or redone, e.g. to commit them
+var OnChange func(subject string, changes []FileChange)
+
+// Dir returns the journal directory of the current ledger
+func Dir() string {
+	return filepath.Join(ledger.StateDir, "journal")
+}
+
+// Record writes a journal entry for changes that were just applied
+// Changes whose content did not change are dropped; nothing is recorded if none remain
+// Undone entries can no longer be redone after a new change and are removed
+func Record(command, summary string, changes []FileChange) (*Entry, error) {
+	var kept []FileChange
+	for _, c := range changes {
+		if c.Before != c.After || c.Existed != !c.Deleted {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, nil
+	}
+
+	if err := ledger.EnsureStateDir(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(storage.Path(Dir()), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create journal: %v", err)
+	}
+
+	now := time.Now()
+	entry := &Entry{
+		ID:      now.Format("20060102-150405.000000"),
+		Time:    now,
+		Command: command,
+		Summary: summary,
+		Changes: kept,
+	}
+
+	if err := save(entry); err != nil {
+		return nil, err
+	}
+	if err := prune(entry.ID); err != nil {
+		return nil, err
+	}
+	if OnChange != nil {
+		OnChange(summary, kept)
+	}
+	return entry, nil
+}
+
+// prune removes undone entries older than the new one and the oldest entries beyond maxEntries
+func prune(newID string) error {
+	entries, err := Entries()
+	if err != nil {
+		return err
+	}
+
+	var kept []*Entry
+	for _, entry := range entries {
+		if entry.Undone && entry.ID != newID {
+			if err := remove(entry); err != nil {
+				return err
+			}
+			continue
+		}
+		kept = append(kept, entry)
+	}
+
+	for len(kept) > maxEntries {
+		if err := remove(kept[0]); err != nil {
+			return err
+		}
+		kept = kept[1:]
+	}
+	return nil
+}
+
+// Entries returns all journal entries, oldest first
+func Entries() ([]*Entry, error) {
+	dirEntries, err := storage.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal: %v", err)
+	}
+
+	var entries []*Entry
+	for _, de := range dirEntries {
+		if de.IsDir() || !strings.HasSuffix(de.Name(), ".json") {
+			continue
+		}
+		data, err := storage.Peek(filepath.Join(Dir(), de.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal entry %s: %v", de.Name(), err)
+		}
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse journal entry %s: %v", de.Name(), err)
+		}
+		entries = append(entries, &entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	return entries, nil
+}
+
+// Undo reverts the newest entry that is not undone yet
+// Every file must still have the content the entry left behind, otherwise nothing is changed
+func Undo() (*Entry, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *Entry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if !entries[i].Undone {
+			entry = entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+
+	if err := storage.Lock(); err != nil {
+		return nil, err
+	}
+	defer storage.Unlock()
+
+	for _, c := range entry.Changes {
+		if err := checkContent(c.Path, c.After, !c.Deleted); err != nil {
+			return nil, fmt.Errorf("cannot undo %q: %v", entry.Summary, err)
+		}
+	}
+
+	if err := apply(entry.Changes, true); err != nil {
+		return nil, err
+	}
+
+	entry.Undone = true
+	if err := save(entry); err != nil {
+		return nil, err
+	}
+	if OnChange != nil {
+		OnChange("undo: "+entry.Summary, reverse(entry.Changes))
+	}
+	return entry, nil
+}
+
+// Redo applies the oldest undone entry again
+// Every file must still have the content from before the entry, otherwise nothing is changed
+func Redo() (*Entry, error) {
+	entries, err := Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *Entry
+	for _, e := range entries {
+		if e.Undone {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+
+	if err := storage.Lock(); err != nil {
+		return nil, err
+	}
+	defer storage.Unlock()
+
+	for _, c := range entry.Changes {
+		if err := checkContent(c.Path, c.Before, c.Existed); err != nil {
+			return nil, fmt.Errorf("cannot redo %q: %v", entry.Summary, err)
+		}
+	}
+
+	if err := apply(entry.Changes, false); err != nil {
+		return nil, err
+	}
+
+	entry.Undone = false
+	if err := save(entry); err != nil {
+		return nil, err
+	}
+	if OnChange != nil {
+		OnChange("redo: "+entry.Summary, entry.Changes)
+	}
+	return entry, nil
+}
+
+// reverse returns the changes that undo changes
+func reverse(changes []FileChange) []FileChange {
+	reversed := make([]FileChange, 0, len(changes))
+	for _, c := range changes {
+		reversed = append(reversed, FileChange{Path: c.Path, Before: c.After, After: c.Before, Existed: !c.Deleted, Deleted: !c.Existed})
+	}
+	return reversed
+}
+
+// apply writes the before (undo) or after content of every change in one go
+func apply(changes []FileChange, undo bool) error {
+	files := make([]storage.File, 0, len(changes))
+	for _, c := range changes {
+		if undo {
+			files = append(files, storage.File{Path: c.Path, Data: []byte(c.Before), Deleted: !c.Existed})
+		} else {
+			files = append(files, storage.File{Path: c.Path, Data: []byte(c.After), Deleted: c.Deleted})
+		}
+	}
+	return storage.WriteFiles(files, 0644)
+}
+
+// checkContent returns an error if a file does not have the expected content
+func checkContent(path, want string, exists bool) error {
+	data, err := storage.ReadFile(path)
+	if os.IsNotExist(err) {
+		if exists {
+			return fmt.Errorf("%s was deleted since", path)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s was created again since", path)
+	}
+	if string(data) != want {
+		return fmt.Errorf("%s was changed since", path)
+	}
+	return nil
+}
+
+func remove(entry *Entry) error {
+	if err := storage.Remove(filepath.Join(Dir(), entry.ID+".json")); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal entry: %v", err)
+	}
+	return nil
+}
+
+func save(entry *Entry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal entry: %v", err)
+	}
+
+	path := filepath.Join(Dir(), entry.ID+".json")
+	if err := storage.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal entry: %v", err)
+	}
+	return nil
+}
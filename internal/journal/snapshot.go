@@ -0,0 +1,153 @@
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+)
+
+// Snapshot holds the content of the ledger files by path
+type Snapshot map[string]string
+
+// TakeSnapshot reads the month files, _config and _pool of the current ledger
+// Outside a ledger the snapshot is empty
+func TakeSnapshot() (Snapshot, error) {
+	snap := make(Snapshot)
+	if _, err := storage.Stat(ledger.StateDir); err != nil {
+		return snap, nil
+	}
+
+	years, err := ledger.Years()
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := []string{"_config", "_pool"}
+	for _, year := range years {
+		dirs = append(dirs, fmt.Sprintf("%d", year))
+	}
+
+	for _, dir := range dirs {
+		files, err := storage.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read %s: %v", dir, err)
+		}
+		for _, f := range files {
+			// Skip directories and temporary files of a write in progress
+			if f.IsDir() || strings.HasPrefix(f.Name(), ".") {
+				continue
+			}
+			path := filepath.Join(dir, f.Name())
+			data, err := storage.Peek(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %v", path, err)
+			}
+			snap[path] = string(data)
+		}
+	}
+
+	return snap, nil
+}
+
+// Changes returns the files that differ between s and a later snapshot
+func (s Snapshot) Changes(after Snapshot) []FileChange {
+	var changes []FileChange
+	for path, before := range s {
+		content, ok := after[path]
+		switch {
+		case !ok:
+			changes = append(changes, FileChange{Path: path, Before: before, Existed: true, Deleted: true})
+		case content != before:
+			changes = append(changes, FileChange{Path: path, Before: before, After: content, Existed: true})
+		}
+	}
+	for path, content := range after {
+		if _, ok := s[path]; !ok {
+			changes = append(changes, FileChange{Path: path, After: content})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Recorder records the changes a command makes to the ledger
+type Recorder struct {
+	command string
+	tracker *storage.Tracker
+}
+
+// Begin starts collecting the files a command writes
+func Begin(command string) (*Recorder, error) {
+	return &Recorder{command: command, tracker: storage.Track()}, nil
+}
+
+// Finish records what changed since Begin, nothing is recorded if no file changed
+// Only the month files, _config and _pool of the current ledger are recorded
+func (r *Recorder) Finish(summary string) (*Entry, error) {
+	touched := r.tracker.Stop()
+	if _, err := storage.Stat(ledger.StateDir); err != nil {
+		return nil, nil
+	}
+	root, err := storage.Root()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %v", err)
+	}
+
+	var changes []FileChange
+	for path, original := range touched {
+		name, ok := journaledName(root, path)
+		if !ok {
+			continue
+		}
+		c := FileChange{Path: name, Before: string(original.Data), Existed: original.Existed}
+		data, err := storage.Peek(path)
+		switch {
+		case err == nil:
+			c.After = string(data)
+		case os.IsNotExist(err):
+			c.Deleted = true
+		default:
+			return nil, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		changes = append(changes, c)
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return Record(r.command, summary, changes)
+}
+
+// journaledName returns the path of a file relative to the ledger root if it is
+// one of the files a snapshot holds
+func journaledName(root, path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	name, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", false
+	}
+
+	dir, file := filepath.Split(name)
+	dir = filepath.Clean(dir)
+	if strings.HasPrefix(file, ".") {
+		return "", false
+	}
+	if dir == "_config" || dir == "_pool" {
+		return name, true
+	}
+	if _, err := strconv.Atoi(dir); err == nil && len(dir) == 4 {
+		return name, true
+	}
+	return "", false
+}
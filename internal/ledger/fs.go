@@ -0,0 +1,227 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"spendgrid/internal/storage"
+)
+
+// ErrReadOnly is returned when writing to a read-only ledger
+var ErrReadOnly = errors.New("ledger is read-only")
+
+// FS is the file system a ledger lives on
+// Names are slash-separated and relative to the ledger root, e.g. 2026/10.md
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	// WriteFile replaces a file, creating parent directories as needed
+	WriteFile(name string, data []byte) error
+	Remove(name string) error
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+}
+
+// dirFS is a ledger directory on disk
+type dirFS struct {
+	root string
+}
+
+// DirFS returns the file system of a ledger directory
+// Writes are atomic and fail if the file changed on disk since it was read
+// Files go through storage, so an encrypted ledger is read and written in plain text
+func DirFS(root string) FS {
+	return &dirFS{root: root}
+}
+
+func (d *dirFS) path(name string) string {
+	if d.root == "." {
+		return filepath.FromSlash(name)
+	}
+	return filepath.Join(d.root, filepath.FromSlash(name))
+}
+
+func (d *dirFS) ReadFile(name string) ([]byte, error) {
+	return storage.ReadFile(d.path(name))
+}
+
+func (d *dirFS) WriteFile(name string, data []byte) error {
+	return storage.WriteFile(d.path(name), data, 0644)
+}
+
+func (d *dirFS) Remove(name string) error {
+	return storage.Remove(d.path(name))
+}
+
+func (d *dirFS) Stat(name string) (fs.FileInfo, error) {
+	return storage.Stat(d.path(name))
+}
+
+func (d *dirFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return storage.ReadDir(d.path(name))
+}
+
+// memFS keeps a ledger in memory, e.g. for tests or a ledger embedded in another program
+type memFS struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+// MemFS returns an in-memory file system holding files (slash-separated name -> content)
+func MemFS(files map[string][]byte) FS {
+	m := &memFS{files: make(map[string][]byte, len(files))}
+	for name, data := range files {
+		m.files[clean(name)] = append([]byte(nil), data...)
+	}
+	return m
+}
+
+func (m *memFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.files[clean(name)]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+func (m *memFS) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[clean(name)] = append([]byte(nil), data...)
+	return nil
+}
+
+func (m *memFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = clean(name)
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *memFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = clean(name)
+	if data, ok := m.files[name]; ok {
+		return &memInfo{name: path.Base(name), size: int64(len(data))}, nil
+	}
+	if name == "." || m.hasDir(name) {
+		return &memInfo{name: path.Base(name), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *memFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = clean(name)
+	if name != "." && !m.hasDir(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	children := make(map[string]*memInfo)
+	for file, data := range m.files {
+		rest, ok := strings.CutPrefix(file, prefix)
+		if !ok {
+			continue
+		}
+		if child, _, isDir := strings.Cut(rest, "/"); isDir {
+			children[child] = &memInfo{name: child, dir: true}
+		} else {
+			children[child] = &memInfo{name: child, size: int64(len(data))}
+		}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(children))
+	for _, info := range children {
+		entries = append(entries, fs.FileInfoToDirEntry(info))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// hasDir returns true if a file lies below dir, directories exist only through their files
+func (m *memFS) hasDir(dir string) bool {
+	for file := range m.files {
+		if strings.HasPrefix(file, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// memInfo describes a file or directory of a memFS
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) ModTime() time.Time { return time.Time{} }
+func (i *memInfo) IsDir() bool        { return i.dir }
+func (i *memInfo) Sys() interface{}   { return nil }
+
+func (i *memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// readOnlyFS serves a ledger from another file system and refuses writes
+type readOnlyFS struct {
+	fsys FS
+}
+
+// ReadOnly returns a view of fsys whose writes fail with ErrReadOnly, e.g. for a backup archive
+func ReadOnly(fsys FS) FS {
+	return &readOnlyFS{fsys: fsys}
+}
+
+func (r *readOnlyFS) ReadFile(name string) ([]byte, error) {
+	return r.fsys.ReadFile(name)
+}
+
+func (r *readOnlyFS) WriteFile(name string, data []byte) error {
+	return fmt.Errorf("cannot write %s: %w", name, ErrReadOnly)
+}
+
+func (r *readOnlyFS) Remove(name string) error {
+	return fmt.Errorf("cannot remove %s: %w", name, ErrReadOnly)
+}
+
+func (r *readOnlyFS) Stat(name string) (fs.FileInfo, error) {
+	return r.fsys.Stat(name)
+}
+
+func (r *readOnlyFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return r.fsys.ReadDir(name)
+}
+
+// clean turns a ledger file name into a slash-separated name relative to the root
+func clean(name string) string {
+	name = path.Clean(filepath.ToSlash(name))
+	name = strings.TrimPrefix(name, "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
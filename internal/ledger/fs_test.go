@@ -0,0 +1,74 @@
+package ledger
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	l := New(MemFS(map[string][]byte{
+		".spendgrid/version": []byte("1"),
+		"2025/12.md":         []byte("# 2025 Aralık\n\n## ROWS\n- 31 | Market | -100 TRY | #market\n\n## RULES\n"),
+		"2026/01.md":         []byte(NewMonthFile(2026, 1)),
+		"_config/rules.yml":  []byte("rules: []\n"),
+	}))
+
+	if err := l.EnsureInitialized(); err != nil {
+		t.Fatal(err)
+	}
+
+	years, err := l.Years()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(years) != 2 || years[0] != 2025 || years[1] != 2026 {
+		t.Errorf("Years: got %v, want [2025 2026]", years)
+	}
+
+	if err := l.ReplaceLines(MonthPath(2025, 12), map[int]string{4: "- 31 | Market | -120 TRY | #market"}); err != nil {
+		t.Fatal(err)
+	}
+	parsed, _, err := l.LoadMonth(2025, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 1 || parsed[0].Amount != -120 {
+		t.Errorf("LoadMonth after ReplaceLines: got %+v", parsed)
+	}
+
+	if _, err := l.FS().Stat("2027"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Stat of a missing directory: got %v, want ErrNotExist", err)
+	}
+	entries, err := l.FS().ReadDir("_config")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "rules.yml" || entries[0].IsDir() {
+		t.Errorf("ReadDir: got %v, %v", entries, err)
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	mem := MemFS(map[string][]byte{"2026/01.md": []byte(NewMonthFile(2026, 1))})
+	l := New(ReadOnly(mem))
+
+	tests := []struct {
+		name string
+		op   func() error
+	}{
+		{"write", func() error { return l.WriteFile("2026/02.md", []byte("x")) }},
+		{"replace lines", func() error { return l.ReplaceLines("2026/01.md", map[int]string{1: "x"}) }},
+		{"remove", func() error { return l.FS().Remove("2026/01.md") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.op(); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("got %v, want ErrReadOnly", err)
+			}
+		})
+	}
+
+	data, err := mem.ReadFile("2026/01.md")
+	if err != nil || string(data) != NewMonthFile(2026, 1) {
+		t.Errorf("the file under the read-only view changed: %q, %v", data, err)
+	}
+}
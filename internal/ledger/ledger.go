@@ -0,0 +1,251 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/parser"
+)
+
+// Entry is a parsed transaction together with the month file it lives in
+type Entry struct {
+	Year  int
+	Month int
+	Path  string
+	Tx    *parser.Transaction
+}
+
+// Date returns the calendar date of the entry
+func (e *Entry) Date() time.Time {
+	return time.Date(e.Year, time.Month(e.Month), e.Tx.Day, 0, 0, 0, 0, time.UTC)
+}
+
+// IsPlanned returns true for rule lines that are not completed yet
+func (e *Entry) IsPlanned() bool {
+	return e.Tx.IsRule && !e.Tx.Completed
+}
+
+// Ledger is a SpendGrid ledger on a file system
+type Ledger struct {
+	fs FS
+}
+
+// New returns a ledger on the given file system
+func New(fsys FS) *Ledger {
+	return &Ledger{fs: fsys}
+}
+
+// Open returns the ledger in a directory
+func Open(root string) *Ledger {
+	return New(DirFS(root))
+}
+
+var current = Open(".")
+
+// Current returns the ledger commands work on, by default the current directory
+func Current() *Ledger {
+	return current
+}
+
+// SetCurrent changes the ledger returned by Current
+func SetCurrent(l *Ledger) {
+	current = l
+}
+
+// FS returns the file system of the ledger
+func (l *Ledger) FS() FS {
+	return l.fs
+}
+
+// ReadFile reads a file of the ledger
+func (l *Ledger) ReadFile(name string) ([]byte, error) {
+	return l.fs.ReadFile(name)
+}
+
+// WriteFile replaces a file of the ledger
+func (l *Ledger) WriteFile(name string, data []byte) error {
+	return l.fs.WriteFile(name, data)
+}
+
+// Exists returns true if a file or directory exists in the ledger
+func (l *Ledger) Exists(name string) bool {
+	_, err := l.fs.Stat(name)
+	return err == nil
+}
+
+// EnsureInitialized returns an error if the ledger has no state directory
+func (l *Ledger) EnsureInitialized() error {
+	if !l.Exists(StateDir) {
+		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	}
+	return nil
+}
+
+// MonthPath returns the path of a month file, e.g. 2026/10.md
+func MonthPath(year, month int) string {
+	return filepath.Join(strconv.Itoa(year), parser.GetMonthFile(month))
+}
+
+// monthNames are the month names used in the heading of month files
+var monthNames = []string{
+	"", "Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran",
+	"Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık",
+}
+
+// MonthName returns the name of a month as written in month file headings, e.g. Ekim
+func MonthName(month int) string {
+	if month >= 1 && month <= 12 {
+		return monthNames[month]
+	}
+	return ""
+}
+
+// NewMonthFile returns the content of an empty month file
+func NewMonthFile(year, month int) string {
+	return fmt.Sprintf("# %d %s\n\n## ROWS\n\n## RULES\n", year, MonthName(month))
+}
+
+// Years returns all year directories in the ledger, sorted ascending
+func (l *Ledger) Years() ([]int, error) {
+	dirEntries, err := l.fs.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger directory: %v", err)
+	}
+
+	var years []int
+	for _, de := range dirEntries {
+		if !de.IsDir() || len(de.Name()) != 4 {
+			continue
+		}
+		year, err := strconv.Atoi(de.Name())
+		if err != nil {
+			continue
+		}
+		years = append(years, year)
+	}
+
+	sort.Ints(years)
+	return years, nil
+}
+
+// LoadMonth parses a single month file
+// Returns parsed and unparsed transactions; a missing file is not an error
+func (l *Ledger) LoadMonth(year, month int) ([]*parser.Transaction, []*parser.Transaction, error) {
+	content, err := l.fs.ReadFile(MonthPath(year, month))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, nil
+		}
+		return nil, nil, fmt.Errorf("failed to read month file: %v", err)
+	}
+
+	parsed, unparsed := parser.ParseMonthFile(string(content))
+	return parsed, unparsed, nil
+}
+
+// MonthEntries returns the parsed transactions of a month as entries
+func (l *Ledger) MonthEntries(year, month int) ([]*Entry, error) {
+	parsed, _, err := l.LoadMonth(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(parsed))
+	path := MonthPath(year, month)
+	for _, tx := range parsed {
+		entries = append(entries, &Entry{Year: year, Month: month, Path: path, Tx: tx})
+	}
+	return entries, nil
+}
+
+// AllEntries returns every parsed transaction in every year directory,
+// ordered by year and month
+func (l *Ledger) AllEntries() ([]*Entry, error) {
+	years, err := l.Years()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*Entry
+	for _, year := range years {
+		for month := 1; month <= 12; month++ {
+			monthEntries, err := l.MonthEntries(year, month)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, monthEntries...)
+		}
+	}
+
+	return entries, nil
+}
+
+// FormatRow formats a row as it is written back to a month file, rule rows keep their checkbox
+func FormatRow(tx *parser.Transaction) string {
+	line := parser.FormatTransaction(tx)
+	if !tx.IsRule {
+		return line
+	}
+	checkbox := "[ ]"
+	if tx.Completed {
+		checkbox = "[x]"
+	}
+	return "- " + checkbox + " " + strings.TrimPrefix(line, "- ")
+}
+
+// ReplaceLines rewrites the given lines (1-based line number -> new content) in a file
+func (l *Ledger) ReplaceLines(path string, replacements map[int]string) error {
+	content, err := l.fs.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for lineNum, line := range replacements {
+		if lineNum < 1 || lineNum > len(lines) {
+			return fmt.Errorf("line %d out of range in %s", lineNum, path)
+		}
+		lines[lineNum-1] = line
+	}
+
+	if err := l.fs.WriteFile(path, []byte(strings.Join(lines, "\n"))); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// EnsureInitialized returns an error if the current ledger is not a SpendGrid directory
+func EnsureInitialized() error {
+	return current.EnsureInitialized()
+}
+
+// Years returns the year directories of the current ledger
+func Years() ([]int, error) {
+	return current.Years()
+}
+
+// LoadMonth parses a month file of the current ledger
+func LoadMonth(year, month int) ([]*parser.Transaction, []*parser.Transaction, error) {
+	return current.LoadMonth(year, month)
+}
+
+// MonthEntries returns the entries of a month of the current ledger
+func MonthEntries(year, month int) ([]*Entry, error) {
+	return current.MonthEntries(year, month)
+}
+
+// AllEntries returns every entry of the current ledger
+func AllEntries() ([]*Entry, error) {
+	return current.AllEntries()
+}
+
+// ReplaceLines rewrites lines of a file of the current ledger
+func ReplaceLines(path string, replacements map[int]string) error {
+	return current.ReplaceLines(path, replacements)
+}
@@ -0,0 +1,54 @@
+package ledger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"spendgrid/internal/storage"
+)
+
+// StateDir is the directory holding SpendGrid's own state: the version file and the journal
+const StateDir = ".spendgrid"
+
+// VersionFile is the schema version file inside StateDir
+const VersionFile = "version"
+
+// SchemaVersion is the ledger format written by this build
+// Older ledgers are upgraded with 'spendgrid migrate'
+const SchemaVersion = 4
+
+// EnsureStateDir makes sure StateDir is a directory
+// Ledgers created by older versions have .spendgrid as a plain version file,
+// which is moved to .spendgrid/version
+func EnsureStateDir() error {
+	dir := storage.Path(StateDir)
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	}
+	if info.IsDir() {
+		return nil
+	}
+
+	version, err := os.ReadFile(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", StateDir, err)
+	}
+
+	tmp := dir + ".tmp"
+	if err := os.MkdirAll(tmp, 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", StateDir+".tmp", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, VersionFile), version, 0644); err != nil {
+		return fmt.Errorf("failed to write version file: %v", err)
+	}
+	if err := os.Remove(dir); err != nil {
+		return fmt.Errorf("failed to remove %s: %v", StateDir, err)
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		return fmt.Errorf("failed to create %s: %v", StateDir, err)
+	}
+
+	return nil
+}
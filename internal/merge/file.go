@@ -0,0 +1,80 @@
+package merge
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// Load reads a version of a month file, decrypting it if it is encrypted
+// name is the path of the file in the ledger, e.g. 2026/03.md.enc as git passes it;
+// it is only needed for encrypted files. sealed tells whether the file was encrypted
+func Load(path, name string) (content string, sealed bool, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if !vault.IsSealed(data) {
+		return string(data), false, nil
+	}
+
+	root, plain, err := locate(name)
+	if err != nil {
+		return "", false, err
+	}
+	key, err := vault.Unlock(root)
+	if err != nil {
+		return "", false, err
+	}
+	data, err = vault.Open(key, plain, data)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// Store writes the merged content to path, encrypted if seal is set
+func Store(path, name, content string, seal bool) error {
+	data := []byte(content)
+	if seal {
+		root, plain, err := locate(name)
+		if err != nil {
+			return err
+		}
+		key, err := vault.Unlock(root)
+		if err != nil {
+			return err
+		}
+		if data, err = vault.Seal(key, plain, data); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// locate returns the ledger root of an encrypted file and its plain name in the ledger
+func locate(name string) (string, string, error) {
+	if name == "" {
+		return "", "", fmt.Errorf("file is encrypted, give its path in the ledger with --name")
+	}
+	root := vault.Root(name)
+	if root == "" || !vault.Enabled(root) {
+		return "", "", fmt.Errorf("%s is not in an encrypted ledger", name)
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to resolve %s: %v", name, err)
+	}
+	return root, strings.TrimSuffix(filepath.ToSlash(rel), storage.EncryptedExt), nil
+}
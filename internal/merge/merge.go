@@ -0,0 +1,421 @@
+package merge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"spendgrid/internal/parser"
+)
+
+// Conflict markers, as written by git
+const (
+	markerOurs   = "<<<<<<< "
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> "
+)
+
+// checkbox matches the checkbox of a rule line
+var checkbox = regexp.MustCompile(`^(\s*-\s*\[)([ xX])(\])`)
+
+// Conflict is a line both sides changed in different ways
+// A side that removed the line has an empty value
+type Conflict struct {
+	Section string `json:"section" yaml:"section"`
+	Base    string `json:"base" yaml:"base"`
+	Ours    string `json:"ours" yaml:"ours"`
+	Theirs  string `json:"theirs" yaml:"theirs"`
+}
+
+// Result is a merged month file
+type Result struct {
+	Content   string      `json:"-" yaml:"-"`
+	Added     int         `json:"added" yaml:"added"`     // lines taken from theirs
+	Removed   int         `json:"removed" yaml:"removed"` // lines removed because theirs removed them
+	Changed   int         `json:"changed" yaml:"changed"` // lines changed by theirs, or by both sides
+	Conflicts []*Conflict `json:"conflicts" yaml:"conflicts"`
+}
+
+// Labels name the sides in conflict markers
+type Labels struct {
+	Ours   string
+	Theirs string
+}
+
+// section is a "## " section of a month file, or the lines before the first one
+type section struct {
+	header string
+	lines  []string
+}
+
+// item is a line that merges on its own, identified by key
+type item struct {
+	key  string
+	line string
+}
+
+// Month merges two versions of a month file that both started from base
+// Rows are matched by identity rather than position: rule lines by rule ID,
+// envelope lines by envelope, rows with an ID meta by ID and other rows by
+// content, so additions on both sides and checkbox changes merge cleanly
+// Lines changed differently on both sides are conflicts, written between
+// conflict markers at the place of our line
+func Month(base, ours, theirs string, labels Labels) *Result {
+	if labels.Ours == "" {
+		labels.Ours = "ours"
+	}
+	if labels.Theirs == "" {
+		labels.Theirs = "theirs"
+	}
+
+	baseSections := index(split(base))
+	theirSections := split(theirs)
+	theirIndex := index(theirSections)
+
+	result := &Result{Conflicts: []*Conflict{}}
+	var out []string
+	done := make(map[string]bool)
+	for _, s := range split(ours) {
+		done[s.header] = true
+		out = append(out, mergeSection(s.header, baseSections[s.header], s, theirIndex[s.header], labels, result)...)
+	}
+
+	// Sections only theirs has are added unless ours removed them
+	for _, s := range theirSections {
+		if done[s.header] {
+			continue
+		}
+		empty := &section{header: s.header}
+		lines := mergeSection(s.header, baseSections[s.header], empty, s, labels, result)
+		if len(items(s.header, lines)) == 0 {
+			continue
+		}
+		if s.header != "" {
+			lines = append([]string{s.lines[0]}, lines...)
+		}
+		if len(out) > 0 && strings.TrimSpace(out[len(out)-1]) != "" {
+			out = append(out, "")
+		}
+		out = append(out, lines...)
+	}
+
+	result.Content = strings.Join(out, "\n")
+	if strings.HasSuffix(ours, "\n") && !strings.HasSuffix(result.Content, "\n") {
+		result.Content += "\n"
+	}
+	return result
+}
+
+// split cuts a month file into its sections
+func split(content string) []*section {
+	content = strings.TrimSuffix(content, "\n")
+	if content == "" {
+		return nil
+	}
+
+	sections := []*section{{}}
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "## ") {
+			sections = append(sections, &section{header: trimmed})
+		}
+		current := sections[len(sections)-1]
+		current.lines = append(current.lines, line)
+	}
+	if len(sections[0].lines) == 0 {
+		sections = sections[1:]
+	}
+	return sections
+}
+
+func index(sections []*section) map[string]*section {
+	byHeader := make(map[string]*section, len(sections))
+	for _, s := range sections {
+		byHeader[s.header] = s
+	}
+	return byHeader
+}
+
+// items returns the lines of a section that merge on their own
+// Headers, titles and blank lines are the layout, which is taken from ours
+func items(header string, lines []string) []item {
+	var found []item
+	seen := make(map[string]int)
+	for _, line := range lines {
+		key, ok := lineKey(header, line)
+		if !ok {
+			continue
+		}
+		// The same key may appear more than once, e.g. two identical rows
+		seen[key]++
+		if n := seen[key]; n > 1 {
+			key = fmt.Sprintf("%s#%d", key, n)
+		}
+		found = append(found, item{key: key, line: line})
+	}
+	return found
+}
+
+// lineKey returns the identity of a line, ok is false for layout lines
+func lineKey(header, line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", false
+	}
+
+	if header == parser.EnvelopesSection {
+		name, _, _ := strings.Cut(strings.TrimPrefix(trimmed, "-"), "|")
+		return "envelope:" + strings.TrimSpace(name), true
+	}
+
+	tx := parser.ParseTransaction(line, 0)
+	if tx == nil || tx.IsUnparsed {
+		return "line:" + trimmed, true
+	}
+	if id := tx.RuleID(); id != "" {
+		return "rule:" + id, true
+	}
+	if id := tx.Meta["ID"]; id != "" {
+		return "id:" + id, true
+	}
+	return "row:" + parser.FormatTransaction(tx), true
+}
+
+// identify gives the rows a side edited the key of the base row they replace,
+// so an edit merges as a change instead of a removal and an addition
+// A removed and an added row of the same day and description are taken as an edit
+func identify(base, side []item) {
+	inBase := make(map[string]item, len(base))
+	for _, it := range base {
+		inBase[it.key] = it
+	}
+	inSide := make(map[string]bool, len(side))
+	for _, it := range side {
+		inSide[it.key] = true
+	}
+
+	var removed []item
+	for _, it := range base {
+		if !inSide[it.key] && strings.HasPrefix(it.key, "row:") {
+			removed = append(removed, it)
+		}
+	}
+	for i, it := range side {
+		if _, ok := inBase[it.key]; ok || !strings.HasPrefix(it.key, "row:") {
+			continue
+		}
+		tx := parser.ParseTransaction(it.line, 0)
+		for j, old := range removed {
+			was := parser.ParseTransaction(old.line, 0)
+			if was.Day == tx.Day && was.Description == tx.Description {
+				side[i].key = old.key
+				removed = append(removed[:j], removed[j+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// mergeSection merges the items of a section, keeping the layout and order of ours
+// Items only theirs has are placed after the item that precedes them in theirs
+func mergeSection(header string, base, ours, theirs *section, labels Labels, result *Result) []string {
+	var baseLines, theirLines []string
+	if base != nil {
+		baseLines = base.lines
+	}
+	if theirs != nil {
+		theirLines = theirs.lines
+	}
+	baseItems := items(header, baseLines)
+	ourItems := items(header, ours.lines)
+	theirItems := items(header, theirLines)
+	identify(baseItems, ourItems)
+	identify(baseItems, theirItems)
+
+	baseByKey := make(map[string]string, len(baseItems))
+	for _, it := range baseItems {
+		baseByKey[it.key] = it.line
+	}
+	theirByKey := make(map[string]string, len(theirItems))
+	for _, it := range theirItems {
+		theirByKey[it.key] = it.line
+	}
+	ourByKey := make(map[string]string, len(ourItems))
+	for _, it := range ourItems {
+		ourByKey[it.key] = it.line
+	}
+
+	// Items only theirs has, by the key of the item before them
+	after := make(map[string][]string)
+	previous := ""
+	for _, it := range theirItems {
+		if _, ok := ourByKey[it.key]; !ok {
+			after[previous] = append(after[previous], it.key)
+		} else {
+			previous = it.key
+		}
+	}
+
+	var out []string
+	emit := func(key string) {
+		baseLine, inBase := baseByKey[key]
+		ourLine, inOurs := ourByKey[key]
+		theirLine, inTheirs := theirByKey[key]
+		lines, conflict := mergeLine(baseLine, ourLine, theirLine, inBase, inOurs, inTheirs, result)
+		if conflict {
+			result.Conflicts = append(result.Conflicts, &Conflict{Section: header, Base: baseLine, Ours: ourLine, Theirs: theirLine})
+			out = append(out, markerOurs+labels.Ours)
+			out = append(out, lines[0]...)
+			out = append(out, markerSep)
+			out = append(out, lines[1]...)
+			out = append(out, markerTheirs+labels.Theirs)
+			return
+		}
+		out = append(out, lines[0]...)
+	}
+	// Their items wait until the lines ours added after the same item are written,
+	// so rows appended on both sides keep their order
+	var pending []string
+	emitAfter := func(key string) {
+		pending = append(pending, after[key]...)
+	}
+	flush := func() {
+		for _, k := range pending {
+			emit(k)
+		}
+		pending = nil
+	}
+
+	// Our item keys in order, aligned with the lines they came from
+	ourKeys := make([]string, 0, len(ourItems))
+	for _, it := range ourItems {
+		ourKeys = append(ourKeys, it.key)
+	}
+
+	next := 0
+	started := false
+	for _, line := range ours.lines {
+		if _, ok := lineKey(header, line); !ok {
+			flush()
+			out = append(out, line)
+			// Items theirs added before all of ours go after the header
+			if !started && strings.TrimSpace(line) == header && header != "" {
+				emitAfter("")
+				started = true
+			}
+			continue
+		}
+		if !started {
+			emitAfter("")
+			started = true
+		}
+		key := ourKeys[next]
+		next++
+		if _, ok := theirByKey[key]; ok || baseByKey[key] != "" {
+			flush()
+		}
+		emit(key)
+		emitAfter(key)
+	}
+	if !started {
+		emitAfter("")
+	}
+	flush()
+	return out
+}
+
+// mergeLine merges one item; it returns the result lines in lines[0], or on a
+// conflict our and their lines in lines[0] and lines[1]
+func mergeLine(base, ours, theirs string, inBase, inOurs, inTheirs bool, result *Result) ([2][]string, bool) {
+	keep := func(line string, ok bool) []string {
+		if !ok {
+			return nil
+		}
+		return []string{line}
+	}
+	conflict := [2][]string{keep(ours, inOurs), keep(theirs, inTheirs)}
+
+	switch {
+	case !inBase && inOurs && inTheirs:
+		// Both sides added the line, e.g. a rule synced on two devices
+		if ours == theirs {
+			return [2][]string{{ours}}, false
+		}
+		if merged, ok := mergeRule(uncheck(ours), ours, theirs); ok && sameRule(ours, theirs) {
+			result.Changed++
+			return [2][]string{{merged}}, false
+		}
+		return conflict, true
+	case !inBase && inOurs:
+		return [2][]string{{ours}}, false
+	case !inBase:
+		result.Added++
+		return [2][]string{{theirs}}, false
+	case !inOurs && !inTheirs:
+		return [2][]string{}, false
+	case !inOurs:
+		if theirs == base {
+			return [2][]string{}, false
+		}
+		return conflict, true
+	case !inTheirs:
+		if ours == base {
+			result.Removed++
+			return [2][]string{}, false
+		}
+		return conflict, true
+	}
+
+	switch {
+	case ours == theirs || theirs == base:
+		return [2][]string{{ours}}, false
+	case ours == base:
+		result.Changed++
+		return [2][]string{{theirs}}, false
+	}
+	if merged, ok := mergeRule(base, ours, theirs); ok {
+		result.Changed++
+		return [2][]string{{merged}}, false
+	}
+	return conflict, true
+}
+
+// mergeRule merges the checkbox of a rule line apart from the rest of the line,
+// so one side completing a rule and the other editing it is not a conflict
+func mergeRule(base, ours, theirs string) (string, bool) {
+	b, o, t := checkbox.FindStringSubmatch(base), checkbox.FindStringSubmatch(ours), checkbox.FindStringSubmatch(theirs)
+	if b == nil || o == nil || t == nil {
+		return "", false
+	}
+	rest := func(line string, m []string) string {
+		return line[len(m[0]):]
+	}
+
+	body := rest(ours, o)
+	switch {
+	case rest(ours, o) == rest(theirs, t) || rest(theirs, t) == rest(base, b):
+	case rest(ours, o) == rest(base, b):
+		body = rest(theirs, t)
+	default:
+		return "", false
+	}
+
+	// A completed rule stays completed
+	mark := " "
+	if strings.EqualFold(o[2], "x") || strings.EqualFold(t[2], "x") {
+		mark = "x"
+	}
+	if strings.EqualFold(b[2], "x") && (o[2] == " " || t[2] == " ") {
+		mark = " "
+	}
+	return o[1] + mark + o[3] + body, true
+}
+
+// uncheck returns a rule line with an empty checkbox
+func uncheck(line string) string {
+	return checkbox.ReplaceAllString(line, "${1} ${3}")
+}
+
+// sameRule returns true if two rule lines differ in the checkbox only
+func sameRule(a, b string) bool {
+	return checkbox.MatchString(a) && uncheck(a) == uncheck(b)
+}
@@ -0,0 +1,124 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+)
+
+// month builds a month file from its row and rule lines
+func month(rows, rules []string) string {
+	var b strings.Builder
+	b.WriteString("# 2026 Ocak\n\n## ROWS\n")
+	for _, row := range rows {
+		b.WriteString(row + "\n")
+	}
+	b.WriteString("\n## RULES\n")
+	for _, rule := range rules {
+		b.WriteString(rule + "\n")
+	}
+	return b.String()
+}
+
+func TestMonth(t *testing.T) {
+	const (
+		market = "- 5 | Market | -100.00 TRY | #market"
+		coffee = "- 6 | Coffee | -40.00 TRY | #cafe"
+		rent   = "- [ ] 1 | Rent [kira_001] | -1000.00 TRY | #kira"
+		tagged = "- 7 | Taxi | -80.00 TRY | #ulasim | [ID:t1]"
+	)
+
+	tests := []struct {
+		name      string
+		base      string
+		ours      string
+		theirs    string
+		want      string // empty when a conflict is expected
+		conflicts int
+	}{
+		{
+			name:   "same row edited the same way",
+			base:   month([]string{market}, nil),
+			ours:   month([]string{"- 5 | Market | -120.00 TRY | #market"}, nil),
+			theirs: month([]string{"- 5 | Market | -120.00 TRY | #market"}, nil),
+			want:   month([]string{"- 5 | Market | -120.00 TRY | #market"}, nil),
+		},
+		{
+			name:   "same row edited by one side",
+			base:   month([]string{market, coffee}, nil),
+			ours:   month([]string{market, coffee}, nil),
+			theirs: month([]string{"- 5 | Market | -120.00 TRY | #market", coffee}, nil),
+			want:   month([]string{"- 5 | Market | -120.00 TRY | #market", coffee}, nil),
+		},
+		{
+			name:      "same row edited differently",
+			base:      month([]string{market, coffee}, nil),
+			ours:      month([]string{"- 5 | Market | -120.00 TRY | #market", coffee}, nil),
+			theirs:    month([]string{"- 5 | Market | -150.00 TRY | #market", coffee}, nil),
+			conflicts: 1,
+		},
+		{
+			name:      "row with an ID edited differently",
+			base:      month([]string{tagged}, nil),
+			ours:      month([]string{"- 7 | Taxi home | -80.00 TRY | #ulasim | [ID:t1]"}, nil),
+			theirs:    month([]string{"- 7 | Taxi | -95.00 TRY | #ulasim | [ID:t1]"}, nil),
+			conflicts: 1,
+		},
+		{
+			name:      "row edited by one side and removed by the other",
+			base:      month([]string{market, coffee}, nil),
+			ours:      month([]string{coffee}, nil),
+			theirs:    month([]string{"- 5 | Market | -120.00 TRY | #market", coffee}, nil),
+			conflicts: 1,
+		},
+		{
+			name:   "rows added on both sides",
+			base:   month([]string{market}, nil),
+			ours:   month([]string{market, coffee}, nil),
+			theirs: month([]string{market, tagged}, nil),
+			want:   month([]string{market, coffee, tagged}, nil),
+		},
+		{
+			name:   "rule completed by one side and edited by the other",
+			base:   month(nil, []string{rent}),
+			ours:   month(nil, []string{"- [x] 1 | Rent [kira_001] | -1000.00 TRY | #kira"}),
+			theirs: month(nil, []string{"- [ ] 1 | Rent [kira_001] | -1100.00 TRY | #kira"}),
+			want:   month(nil, []string{"- [x] 1 | Rent [kira_001] | -1100.00 TRY | #kira"}),
+		},
+		{
+			name:      "rule edited differently",
+			base:      month(nil, []string{rent}),
+			ours:      month(nil, []string{"- [ ] 1 | Rent [kira_001] | -1100.00 TRY | #kira"}),
+			theirs:    month(nil, []string{"- [ ] 2 | Rent [kira_001] | -1000.00 TRY | #kira"}),
+			conflicts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Month(tt.base, tt.ours, tt.theirs, Labels{Ours: "HEAD", Theirs: "phone"})
+			if len(result.Conflicts) != tt.conflicts {
+				t.Fatalf("got %d conflicts, want %d:\n%s", len(result.Conflicts), tt.conflicts, result.Content)
+			}
+
+			if tt.conflicts == 0 {
+				if result.Content != tt.want {
+					t.Errorf("got\n%s\nwant\n%s", result.Content, tt.want)
+				}
+				return
+			}
+
+			for _, marker := range []string{markerOurs + "HEAD", markerSep, markerTheirs + "phone"} {
+				if !strings.Contains(result.Content, marker+"\n") {
+					t.Errorf("missing conflict marker %q in\n%s", marker, result.Content)
+				}
+			}
+			c := result.Conflicts[0]
+			if c.Section != "## ROWS" && c.Section != "## RULES" {
+				t.Errorf("conflict in unexpected section %q", c.Section)
+			}
+			if c.Base == "" || c.Ours == c.Theirs {
+				t.Errorf("conflict does not record both sides: %+v", c)
+			}
+		})
+	}
+}
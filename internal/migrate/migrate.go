@@ -0,0 +1,224 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/backup"
+	"spendgrid/internal/filesystem"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+)
+
+// Step is a numbered, reversible change of the ledger format
+// Up migrates files from Version-1 to Version, Down goes back
+type Step struct {
+	Version     int
+	Description string
+	Up          func(files journal.Snapshot) error
+	Down        func(files journal.Snapshot) error
+}
+
+// steps are ordered by version, the last one is ledger.SchemaVersion
+var steps = []Step{ruleIDs, accountMeta, sectionNames}
+
+// StepInfo describes a step of a plan
+type StepInfo struct {
+	Version     int    `json:"version" yaml:"version"`
+	Description string `json:"description" yaml:"description"`
+	Direction   string `json:"direction" yaml:"direction"` // up or down
+}
+
+// Plan is a migration between two schema versions, computed without writing anything
+type Plan struct {
+	From    int                  `json:"from" yaml:"from"`
+	To      int                  `json:"to" yaml:"to"`
+	Steps   []StepInfo           `json:"steps" yaml:"steps"`
+	Files   []string             `json:"files" yaml:"files"`
+	changes []journal.FileChange // Month and config files, without the version file
+	stamp   string               // Build timestamp of the version file, kept as is
+}
+
+// CurrentVersion returns the schema version and build timestamp of the current ledger
+func CurrentVersion() (int, string, error) {
+	schema, timestamp, err := filesystem.CheckSchemaVersion()
+	if err != nil {
+		return 0, "", err
+	}
+
+	version, err := strconv.Atoi(schema)
+	if err != nil || version < 1 {
+		return 0, "", fmt.Errorf("invalid schema version '%s'", schema)
+	}
+	return version, strconv.FormatInt(timestamp, 10), nil
+}
+
+// CheckCompatible returns an error if the ledger was written by a newer spendgrid
+func CheckCompatible() error {
+	version, _, err := CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if version > ledger.SchemaVersion {
+		return fmt.Errorf("this ledger uses schema %d but this spendgrid only knows schema %d, please upgrade spendgrid", version, ledger.SchemaVersion)
+	}
+	return nil
+}
+
+// NewPlan computes the migration of the current ledger to the target schema
+func NewPlan(target int) (*Plan, error) {
+	if err := CheckCompatible(); err != nil {
+		return nil, err
+	}
+	from, stamp, err := CurrentVersion()
+	if err != nil {
+		return nil, err
+	}
+	if target < 1 || target > ledger.SchemaVersion {
+		return nil, fmt.Errorf("schema %d does not exist, use 1 to %d", target, ledger.SchemaVersion)
+	}
+
+	before, err := journal.TakeSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	after := make(journal.Snapshot, len(before))
+	for path, content := range before {
+		after[path] = content
+	}
+
+	plan := &Plan{From: from, To: target, stamp: stamp}
+	if target >= from {
+		for _, step := range steps {
+			if step.Version <= from || step.Version > target {
+				continue
+			}
+			if err := step.Up(after); err != nil {
+				return nil, fmt.Errorf("migration %d failed: %v", step.Version, err)
+			}
+			plan.Steps = append(plan.Steps, StepInfo{Version: step.Version, Description: step.Description, Direction: "up"})
+		}
+	} else {
+		for i := len(steps) - 1; i >= 0; i-- {
+			step := steps[i]
+			if step.Version <= target || step.Version > from {
+				continue
+			}
+			if err := step.Down(after); err != nil {
+				return nil, fmt.Errorf("migration %d failed: %v", step.Version, err)
+			}
+			plan.Steps = append(plan.Steps, StepInfo{Version: step.Version, Description: step.Description, Direction: "down"})
+		}
+	}
+
+	plan.changes = before.Changes(after)
+	plan.Files = []string{}
+	for _, c := range plan.changes {
+		plan.Files = append(plan.Files, c.Path)
+	}
+	return plan, nil
+}
+
+// Empty returns true if the ledger is already at the target schema
+func (p *Plan) Empty() bool {
+	return p.From == p.To
+}
+
+// Apply backs up the ledger to an archive, then writes the migrated files and the new version in one go
+// The migration is journaled, so it can also be undone with 'spendgrid undo'
+// Returns the backup archive
+func (p *Plan) Apply() (string, error) {
+	if err := storage.Lock(); err != nil {
+		return "", err
+	}
+	defer storage.Unlock()
+
+	// Nothing may have changed since the plan was made
+	for _, c := range p.changes {
+		data, err := storage.ReadFile(c.Path)
+		if err != nil || string(data) != c.Before {
+			return "", fmt.Errorf("%s %v", c.Path, storage.ErrChanged)
+		}
+	}
+
+	archive, err := backup.Auto("migrate")
+	if err != nil {
+		return "", err
+	}
+
+	versionPath := filepath.Join(ledger.StateDir, ledger.VersionFile)
+	oldVersion, err := storage.ReadFile(versionPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read version file: %v", err)
+	}
+	newVersion := fmt.Sprintf("%d %s\n", p.To, p.stamp)
+
+	changes := append(p.changes, journal.FileChange{Path: versionPath, Before: string(oldVersion), After: newVersion, Existed: true})
+	files := make([]storage.File, 0, len(changes))
+	for _, c := range changes {
+		files = append(files, storage.File{Path: c.Path, Data: []byte(c.After)})
+	}
+	if err := storage.WriteFiles(files, 0644); err != nil {
+		return "", err
+	}
+
+	if _, err := journal.Record("migrate", fmt.Sprintf("migrate schema %d → %d", p.From, p.To), changes); err != nil {
+		return archive, fmt.Errorf("migrated, but journal failed: %v", err)
+	}
+	return archive, nil
+}
+
+// Preview prints the steps of a plan and the lines each file would change
+func (p *Plan) Preview() {
+	fmt.Println()
+	if p.Empty() {
+		color.Green("✓ Ledger is at schema %d, nothing to migrate", p.From)
+		fmt.Println()
+		return
+	}
+
+	color.Cyan("Schema %d → %d", p.From, p.To)
+	for _, s := range p.Steps {
+		arrow := "↑"
+		if s.Direction == "down" {
+			arrow = "↓"
+		}
+		fmt.Printf("  %s %d  %s\n", arrow, s.Version, s.Description)
+	}
+	fmt.Println()
+
+	if len(p.changes) == 0 {
+		fmt.Println("No file needs changes, only the version is updated")
+		fmt.Println()
+		return
+	}
+
+	for _, c := range p.changes {
+		color.New(color.Bold).Println(c.Path)
+		before := strings.Split(c.Before, "\n")
+		after := strings.Split(c.After, "\n")
+		for i := 0; i < len(before) || i < len(after); i++ {
+			var old, new string
+			if i < len(before) {
+				old = before[i]
+			}
+			if i < len(after) {
+				new = after[i]
+			}
+			if old == new {
+				continue
+			}
+			if i < len(before) {
+				color.Red("  %4d - %s", i+1, old)
+			}
+			if i < len(after) {
+				color.Green("  %4d + %s", i+1, new)
+			}
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,202 @@
+package migrate
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/rules"
+)
+
+// monthFile matches the path of a month file, e.g. 2026/10.md
+var monthFile = regexp.MustCompile(`^\d{4}[/\\]\d{2}\.md$`)
+
+// ruleLine matches a rule line, capturing the checkbox prefix, the name and the rest
+var ruleLine = regexp.MustCompile(`^(\s*- \[[ x]\] \d+ \|)([^|]*)(\|.*)$`)
+
+// trailingID matches a rule ID at the end of a rule name
+var trailingID = regexp.MustCompile(`^(.*\S)\s+\[([^\]]+)\]$`)
+
+// ruleIDs adds the rule ID to rule lines written before sync matched rules by ID,
+// so a renamed rule is no longer added a second time
+var ruleIDs = Step{
+	Version:     2,
+	Description: "Add rule IDs to rule lines",
+	Up: func(files journal.Snapshot) error {
+		byName, _, err := loadRules(files)
+		if err != nil {
+			return err
+		}
+		return editLines(files, true, func(line string) string {
+			m := ruleLine.FindStringSubmatch(line)
+			if m == nil || trailingID.MatchString(strings.TrimSpace(m[2])) {
+				return line
+			}
+			id, ok := byName[strings.TrimSpace(m[2])]
+			if !ok {
+				return line
+			}
+			return m[1] + " " + strings.TrimSpace(m[2]) + " [" + id + "] " + m[3]
+		})
+	},
+	Down: func(files journal.Snapshot) error {
+		_, ids, err := loadRules(files)
+		if err != nil {
+			return err
+		}
+		return editLines(files, true, func(line string) string {
+			m := ruleLine.FindStringSubmatch(line)
+			if m == nil {
+				return line
+			}
+			id := trailingID.FindStringSubmatch(strings.TrimSpace(m[2]))
+			if id == nil || !ids[id[2]] {
+				return line
+			}
+			return m[1] + " " + id[1] + " " + m[3]
+		})
+	},
+}
+
+// loadRules returns rule IDs by unique rule name, and the set of all rule IDs
+func loadRules(files journal.Snapshot) (map[string]string, map[string]bool, error) {
+	byName := make(map[string]string)
+	ids := make(map[string]bool)
+
+	content, ok := files[rules.GetRulesFilePath()]
+	if !ok {
+		return byName, ids, nil
+	}
+
+	var ruleSet rules.RuleSet
+	if err := yaml.Unmarshal([]byte(content), &ruleSet); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse rules: %v", err)
+	}
+
+	duplicate := make(map[string]bool)
+	for _, r := range ruleSet.Rules {
+		ids[r.ID] = true
+		if _, ok := byName[r.Name]; ok {
+			duplicate[r.Name] = true
+		}
+		byName[r.Name] = r.ID
+	}
+	// Lines of rules sharing a name cannot be told apart
+	for name := range duplicate {
+		delete(byName, name)
+	}
+	return byName, ids, nil
+}
+
+// accountMeta moves [ACCOUNT:name] meta into the &account field
+var accountMeta = Step{
+	Version:     3,
+	Description: "Move ACCOUNT meta into the &account field",
+	Up: func(files journal.Snapshot) error {
+		return editLines(files, false, func(line string) string {
+			tx := parser.ParseTransaction(line, 0)
+			if tx == nil || tx.IsUnparsed || tx.Account != "" || tx.Meta["ACCOUNT"] == "" {
+				return line
+			}
+			tx.Account = tx.Meta["ACCOUNT"]
+			delete(tx.Meta, "ACCOUNT")
+			return indent(line) + ledger.FormatRow(tx)
+		})
+	},
+	Down: func(files journal.Snapshot) error {
+		return editLines(files, false, func(line string) string {
+			tx := parser.ParseTransaction(line, 0)
+			if tx == nil || tx.IsUnparsed || tx.Account == "" {
+				return line
+			}
+			tx.Meta["ACCOUNT"] = tx.Account
+			tx.Account = ""
+			return indent(line) + ledger.FormatRow(tx)
+		})
+	},
+}
+
+// sectionHeadings maps older spellings of month file sections to their headings
+var sectionHeadings = map[string]string{
+	"rows":      "## ROWS",
+	"satırlar":  "## ROWS",
+	"satirlar":  "## ROWS",
+	"işlemler":  "## ROWS",
+	"islemler":  "## ROWS",
+	"rules":     "## RULES",
+	"kurallar":  "## RULES",
+	"envelopes": "## ENVELOPES",
+	"zarflar":   "## ENVELOPES",
+}
+
+// heading returns the canonical heading of a section line, ok is false for other lines
+func heading(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "##") || strings.HasPrefix(trimmed, "###") {
+		return "", false
+	}
+	name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "##")))
+	if canonical, ok := sectionHeadings[name]; ok {
+		return canonical, true
+	}
+	return trimmed, true
+}
+
+// sectionNames renames sections spelled differently, which sync did not recognize
+var sectionNames = Step{
+	Version:     4,
+	Description: "Rename month file sections to ## ROWS, ## RULES and ## ENVELOPES",
+	Up: func(files journal.Snapshot) error {
+		for path, content := range files {
+			if !monthFile.MatchString(path) {
+				continue
+			}
+			lines := strings.Split(content, "\n")
+			for i, line := range lines {
+				if canonical, ok := heading(line); ok && canonical != strings.TrimSpace(line) {
+					lines[i] = canonical
+				}
+			}
+			files[path] = strings.Join(lines, "\n")
+		}
+		return nil
+	},
+	// The canonical headings are valid in every schema, there is nothing to undo
+	Down: func(files journal.Snapshot) error {
+		return nil
+	},
+}
+
+// editLines applies edit to the lines of every month file,
+// only to the RULES section if rulesOnly is set
+func editLines(files journal.Snapshot, rulesOnly bool, edit func(line string) string) error {
+	for path, content := range files {
+		if !monthFile.MatchString(path) {
+			continue
+		}
+
+		lines := strings.Split(content, "\n")
+		section := ""
+		for i, line := range lines {
+			if canonical, ok := heading(line); ok {
+				section = canonical
+				continue
+			}
+			if section == parser.EnvelopesSection || (rulesOnly && section != "## RULES") {
+				continue
+			}
+			lines[i] = edit(line)
+		}
+		files[path] = strings.Join(lines, "\n")
+	}
+	return nil
+}
+
+// indent returns the leading white space of a line
+func indent(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
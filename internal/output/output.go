@@ -0,0 +1,122 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	Text = "text"
+	JSON = "json"
+	CSV  = "csv"
+	YAML = "yaml"
+)
+
+// SchemaVersion is the version of the structured output schemas
+// Bump it when a field is renamed or removed; adding fields keeps the version
+const SchemaVersion = 1
+
+var current = Text
+
+// Document wraps structured data with its schema name and version
+type Document struct {
+	Schema      string      `json:"schema" yaml:"schema"`
+	Version     int         `json:"version" yaml:"version"`
+	GeneratedAt string      `json:"generated_at" yaml:"generated_at"`
+	Data        interface{} `json:"data" yaml:"data"`
+}
+
+// Tabular is implemented by data that has a natural row form for CSV
+// Table returns a slice (or map) whose elements become the CSV rows
+type Tabular interface {
+	Table() interface{}
+}
+
+// Grid is a ready-made CSV table for data whose columns are only known at runtime
+type Grid struct {
+	Header []string
+	Rows   [][]string
+}
+
+// SetFormat sets the output format for the current run
+func SetFormat(format string) error {
+	switch format {
+	case "", Text:
+		current = Text
+	case JSON, CSV, YAML:
+		current = format
+	default:
+		return fmt.Errorf("unknown output format: %s (use text, json, csv or yaml)", format)
+	}
+	return nil
+}
+
+// Current returns the output format of the current run
+func Current() string {
+	return current
+}
+
+// IsStructured returns true if commands should emit data instead of text
+func IsStructured() bool {
+	return current != Text
+}
+
+// Write writes data to stdout in the current format
+// kind names the schema, e.g. "report.monthly" becomes "spendgrid.report.monthly"
+func Write(kind string, data interface{}) error {
+	return WriteTo(os.Stdout, kind, data)
+}
+
+// NewDocument wraps data in a document of the given schema kind
+func NewDocument(kind string, data interface{}) Document {
+	return Document{
+		Schema:      "spendgrid." + kind,
+		Version:     SchemaVersion,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Data:        data,
+	}
+}
+
+// WriteTo writes data to w in the current format
+func WriteTo(w io.Writer, kind string, data interface{}) error {
+	doc := NewDocument(kind, data)
+
+	switch current {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode json: %v", err)
+		}
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode yaml: %v", err)
+		}
+		return enc.Close()
+	case CSV:
+		if t, ok := data.(Tabular); ok {
+			data = t.Table()
+		}
+		header, rows := Rows(data)
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return fmt.Errorf("failed to write csv: %v", err)
+		}
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("failed to write csv: %v", err)
+		}
+	default:
+		return fmt.Errorf("%s is not a structured output format", current)
+	}
+
+	return nil
+}
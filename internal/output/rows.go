@@ -0,0 +1,183 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rows flattens data into a CSV header and rows
+// Slices and maps of structs give one row per element with the json field names as header,
+// a single struct gives field/value rows; string lists are space separated, other nested values are JSON
+func Rows(data interface{}) ([]string, [][]string) {
+	if grid, ok := data.(*Grid); ok {
+		return grid.Header, grid.Rows
+	}
+
+	v := indirect(reflect.ValueOf(data))
+	if !v.IsValid() {
+		return []string{"value"}, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems := make([]reflect.Value, v.Len())
+		for i := range elems {
+			elems[i] = v.Index(i)
+		}
+		return elementRows(v.Type().Elem(), elems)
+
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		if elemType := derefType(v.Type().Elem()); elemType.Kind() == reflect.Struct && elemType != timeType {
+			elems := make([]reflect.Value, len(keys))
+			for i, key := range keys {
+				elems[i] = v.MapIndex(key)
+			}
+			return elementRows(elemType, elems)
+		}
+		rows := make([][]string, len(keys))
+		for i, key := range keys {
+			rows[i] = []string{cell(key), cell(v.MapIndex(key))}
+		}
+		return []string{"key", "value"}, rows
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return []string{"value"}, [][]string{{cell(v)}}
+		}
+		var rows [][]string
+		for _, f := range fields(v.Type()) {
+			rows = append(rows, []string{f.name, cell(v.Field(f.index))})
+		}
+		return []string{"field", "value"}, rows
+	}
+
+	return []string{"value"}, [][]string{{cell(v)}}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type field struct {
+	name  string
+	index int
+}
+
+// elementRows writes one row per element, one column per field for structs
+func elementRows(elemType reflect.Type, elems []reflect.Value) ([]string, [][]string) {
+	elemType = derefType(elemType)
+	if elemType.Kind() != reflect.Struct || elemType == timeType {
+		rows := make([][]string, len(elems))
+		for i, elem := range elems {
+			rows[i] = []string{cell(elem)}
+		}
+		return []string{"value"}, rows
+	}
+
+	fs := fields(elemType)
+	header := make([]string, len(fs))
+	for i, f := range fs {
+		header[i] = f.name
+	}
+
+	rows := make([][]string, 0, len(elems))
+	for _, elem := range elems {
+		elem = indirect(elem)
+		row := make([]string, len(fs))
+		if elem.IsValid() {
+			for i, f := range fs {
+				row[i] = cell(elem.Field(f.index))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return header, rows
+}
+
+// fields returns the exported fields of a struct type with their json names
+func fields(t reflect.Type) []field {
+	var fs []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := sf.Name
+		if tag := sf.Tag.Get("json"); tag != "" {
+			tagName := strings.Split(tag, ",")[0]
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+		fs = append(fs, field{name: name, index: i})
+	}
+	return fs
+}
+
+// cell formats a single value for CSV
+func cell(v reflect.Value) string {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ""
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0 {
+		return ""
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String {
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = v.Index(i).String()
+		}
+		return strings.Join(parts, " ")
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Sprint(v.Interface())
+	}
+	return string(data)
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,11 @@
+package output
+
+// Truncate shortens s to maxLen characters for a table column, ending it with "..."
+// Characters are counted as runes, so names like #eğitim are never cut mid-character
+func Truncate(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		in     string
+		maxLen int
+		want   string
+	}{
+		{"market", 10, "market"},
+		{"market", 6, "market"},
+		{"supermarket", 8, "super..."},
+		{"#eğitim:üniversite", 12, "#eğitim:ü..."},
+		{"çğıöşü çğıöşü", 8, "çğıöş..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got := Truncate(tt.in, tt.maxLen)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("%q is not valid UTF-8", got)
+			}
+		})
+	}
+}
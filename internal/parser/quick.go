@@ -120,10 +120,10 @@ func extractAmount(input string) (float64, string, string) {
 	return 0, "", input
 }
 
-// extractTags finds all #tags in input
+// extractTags finds all #tags in input, including tag paths like #yemek:market
 // Returns: tags list, remaining text
 func extractTags(input string) ([]string, string) {
-	re := regexp.MustCompile(`#(\w+)`)
+	re := regexp.MustCompile(`#([\p{L}\p{N}_]+(?::[\p{L}\p{N}_]+)*)`)
 	matches := re.FindAllStringSubmatchIndex(input, -1)
 
 	if matches == nil {
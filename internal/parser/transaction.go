@@ -3,6 +3,7 @@ package parser
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -10,19 +11,20 @@ import (
 
 // Transaction represents a single financial transaction
 type Transaction struct {
-	Day         int
-	Description string
-	Amount      float64
-	Currency    string
-	Rate        float64 // Manual rate if specified (@rate)
-	Tags        []string
-	Projects    []string
-	Meta        map[string]string
-	Raw         string
-	IsUnparsed  bool
-	LineNumber  int
-	IsRule      bool // True if this transaction is from RULES section
-	Completed   bool // True if checkbox is [x], false if [ ] or no checkbox
+	Day         int               `json:"day" yaml:"day"`
+	Description string            `json:"description" yaml:"description"`
+	Amount      float64           `json:"amount" yaml:"amount"`
+	Currency    string            `json:"currency" yaml:"currency"`
+	Rate        float64           `json:"rate,omitempty" yaml:"rate,omitempty"` // Manual rate if specified (@rate)
+	Tags        []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Projects    []string          `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Account     string            `json:"account,omitempty" yaml:"account,omitempty"` // Account the row belongs to (&account)
+	Meta        map[string]string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Raw         string            `json:"raw" yaml:"raw"`
+	IsUnparsed  bool              `json:"unparsed,omitempty" yaml:"unparsed,omitempty"`
+	LineNumber  int               `json:"line" yaml:"line"`
+	IsRule      bool              `json:"rule" yaml:"rule"`           // True if this transaction is from RULES section
+	Completed   bool              `json:"completed" yaml:"completed"` // True if checkbox is [x], false if [ ] or no checkbox
 }
 
 // IsExpense returns true if the amount is negative
@@ -35,8 +37,22 @@ func (t *Transaction) IsIncome() bool {
 	return t.Amount > 0
 }
 
+// ruleIDPattern matches the rule ID at the end of a rule description, e.g. "Kira [kira_001]"
+var ruleIDPattern = regexp.MustCompile(`\[([^\]]+)\]\s*$`)
+
+// RuleID returns the ID of the rule a rule row was synced from, or "" for other rows
+func (t *Transaction) RuleID() string {
+	if !t.IsRule {
+		return ""
+	}
+	if m := ruleIDPattern.FindStringSubmatch(t.Description); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
 // ParseTransaction parses a single transaction line
-// Format: - DAY | DESCRIPTION | AMOUNT CURRENCY [@RATE] | TAGS | [META]
+// Format: - DAY | DESCRIPTION | AMOUNT CURRENCY [@RATE] | TAGS [&ACCOUNT] | [META]
 // Example: - 15 | Market Alışverişi | -3.200,50 TRY | #mutfak | [NOTE:Misafir geldi]
 func ParseTransaction(line string, lineNum int) *Transaction {
 	line = strings.TrimSpace(line)
@@ -223,16 +239,21 @@ func parseAmountAndCurrency(part string, tx *Transaction) error {
 	return nil
 }
 
-// parseTagsAndProjects parses tags (#tag) and projects (@project)
+// parseTagsAndProjects parses tags (#tag), projects (@project) and the account (&account)
 func parseTagsAndProjects(part string, tx *Transaction) {
 	words := strings.Fields(part)
 	for _, word := range words {
 		if strings.HasPrefix(word, "#") {
-			tag := strings.TrimPrefix(word, "#")
-			tx.Tags = append(tx.Tags, tag)
+			// Tag paths keep their colons: #yemek:market
+			tag := strings.Trim(strings.TrimPrefix(word, "#"), ":")
+			if tag != "" {
+				tx.Tags = append(tx.Tags, tag)
+			}
 		} else if strings.HasPrefix(word, "@") {
 			project := strings.TrimPrefix(word, "@")
 			tx.Projects = append(tx.Projects, project)
+		} else if strings.HasPrefix(word, "&") && len(word) > 1 {
+			tx.Account = strings.TrimPrefix(word, "&")
 		}
 	}
 }
@@ -292,13 +313,22 @@ func FormatTransaction(tx *Transaction) string {
 	for _, project := range tx.Projects {
 		tagsParts = append(tagsParts, "@"+project)
 	}
+	if tx.Account != "" {
+		tagsParts = append(tagsParts, "&"+tx.Account)
+	}
 	parts = append(parts, strings.Join(tagsParts, " "))
 
 	// Meta
 	if len(tx.Meta) > 0 {
+		keys := make([]string, 0, len(tx.Meta))
+		for key := range tx.Meta {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
 		var metaParts []string
-		for key, value := range tx.Meta {
-			metaParts = append(metaParts, fmt.Sprintf("%s:%s", key, value))
+		for _, key := range keys {
+			metaParts = append(metaParts, fmt.Sprintf("%s:%s", key, tx.Meta[key]))
 		}
 		parts = append(parts, fmt.Sprintf("[%s]", strings.Join(metaParts, ",")))
 	}
@@ -306,6 +336,18 @@ func FormatTransaction(tx *Transaction) string {
 	return "- " + strings.Join(parts, " | ")
 }
 
+// ParseAmount parses an amount with currency such as "12345.67TRY" or "-120 USD"
+func ParseAmount(input string) (float64, string, error) {
+	// Separate a glued currency code so the word boundaries match
+	input = regexp.MustCompile(`([0-9])([A-Za-z$€₺])`).ReplaceAllString(input, "$1 $2")
+
+	tx := &Transaction{}
+	if err := parseAmountAndCurrency(input, tx); err != nil {
+		return 0, "", err
+	}
+	return tx.Amount, tx.Currency, nil
+}
+
 // formatAmount formats the amount with proper formatting
 func formatAmount(amount float64, currency string) string {
 	sign := ""
@@ -319,13 +361,25 @@ func formatAmount(amount float64, currency string) string {
 	return fmt.Sprintf("%s%.2f %s", sign, amount, currency)
 }
 
+// EnvelopesSection is the month file section holding envelope allocations
+const EnvelopesSection = "## ENVELOPES"
+
 // ParseMonthFile parses all transactions from a month file
 func ParseMonthFile(content string) ([]*Transaction, []*Transaction) {
 	var parsed []*Transaction
 	var unparsed []*Transaction
 
 	lines := strings.Split(content, "\n")
+	inEnvelopes := false
 	for i, line := range lines {
+		// Envelope allocations are not transactions
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "##") {
+			inEnvelopes = trimmed == EnvelopesSection
+		}
+		if inEnvelopes {
+			continue
+		}
+
 		tx := ParseTransaction(line, i+1)
 		if tx == nil {
 			continue
@@ -2,14 +2,16 @@ package pool
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
 )
 
@@ -17,13 +19,14 @@ const backlogFile = "_pool/backlog.md"
 
 // ShowPool displays all items in the backlog
 func ShowPool() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
-	content, err := os.ReadFile(backlogFile)
+	content, err := l.ReadFile(backlogFile)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			fmt.Println(i18n.T("pool.empty"))
 			return nil
 		}
@@ -71,8 +74,9 @@ func ShowPool() error {
 
 // AddPoolItem adds a new item to the backlog
 func AddPoolItem() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
 	reader := bufio.NewReader(os.Stdin)
@@ -103,27 +107,54 @@ func AddPoolItem() error {
 	// Format: - DESC | AMOUNT | MONTH | TAGS
 	line := fmt.Sprintf("- %s | %s | %s | %s", desc, amountStr, monthStr, tagsStr)
 
-	// Append to file
-	f, err := os.OpenFile(backlogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
+	if err := appendLine(l, line); err != nil {
+		return err
+	}
+
+	fmt.Println(i18n.T("pool.add_success"))
+	return nil
+}
+
+// AddItem appends an item to the backlog in the pool format
+func AddItem(l *ledger.Ledger, item *Item) error {
+	if err := l.EnsureInitialized(); err != nil {
+		return err
+	}
+	if strings.TrimSpace(item.Description) == "" {
+		return fmt.Errorf("description cannot be empty")
+	}
+
+	amount := ""
+	if item.Amount != 0 || item.Currency != "" {
+		amount = strings.TrimSpace(fmt.Sprintf("%.2f %s", item.Amount, item.Currency))
+	}
+	month := ""
+	if item.IsDated() {
+		month = fmt.Sprintf("%04d-%02d", item.Year, item.Month)
+	}
+	tags := make([]string, 0, len(item.Tags))
+	for _, tag := range item.Tags {
+		tags = append(tags, "#"+strings.TrimPrefix(tag, "#"))
+	}
+
+	return appendLine(l, fmt.Sprintf("- %s | %s | %s | %s", strings.TrimSpace(item.Description), amount, month, strings.Join(tags, " ")))
+}
+
+// appendLine adds a line to the end of the backlog
+func appendLine(l *ledger.Ledger, line string) error {
+	content, err := l.ReadFile(backlogFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("failed to open backlog: %v", err)
 	}
-	defer f.Close()
 
-	if _, err := f.WriteString(line + "\n"); err != nil {
+	if err := l.WriteFile(backlogFile, append(content, []byte(line+"\n")...)); err != nil {
 		return fmt.Errorf("failed to write to backlog: %v", err)
 	}
-
-	fmt.Println(i18n.T("pool.add_success"))
 	return nil
 }
 
 // MovePoolItem moves an item from backlog to a specific month
-func MovePoolItem(lineNumStr, monthStr string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
-	}
-
+func MovePoolItem(l *ledger.Ledger, lineNumStr, monthStr string) error {
 	lineNum, err := strconv.Atoi(lineNumStr)
 	if err != nil || lineNum < 1 {
 		return fmt.Errorf("invalid line number: %s", lineNumStr)
@@ -134,67 +165,78 @@ func MovePoolItem(lineNumStr, monthStr string) error {
 		return fmt.Errorf("invalid month: %s", monthStr)
 	}
 
+	if _, err := MoveItem(l, lineNum, time.Now().Year(), month, 0); err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.T("pool.move_success"), lineNum, month)
+	fmt.Println()
+	return nil
+}
+
+// MoveItem moves the n-th backlog item (1-based) to the ROWS section of a month
+// on the given day, or on its own day (the 1st if it has none) if day is 0
+// Returns the transaction that was added to the month file
+func MoveItem(l *ledger.Ledger, n, year, month, day int) (*parser.Transaction, error) {
+	if err := l.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
 	// Read backlog
-	content, err := os.ReadFile(backlogFile)
+	content, err := l.ReadFile(backlogFile)
 	if err != nil {
-		return fmt.Errorf("failed to read backlog: %v", err)
+		return nil, fmt.Errorf("failed to read backlog: %v", err)
 	}
 
 	lines := strings.Split(string(content), "\n")
 
-	// Find the line
-	txLine := 0
-	actualLine := -1
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "-") {
-			txLine++
-			if txLine == lineNum {
-				actualLine = i
-				break
-			}
-		}
-	}
-
+	actualLine := findItem(lines, n)
 	if actualLine == -1 {
-		return fmt.Errorf("item not found at line %d", lineNum)
+		return nil, fmt.Errorf("item not found at line %d", n)
 	}
 
-	// Parse the transaction
+	// Parse the transaction, an item in the pool format becomes a row if it has an amount
 	tx := parser.ParseTransaction(lines[actualLine], actualLine+1)
+	if item := parseItem(lines[actualLine], actualLine+1); tx != nil && tx.IsUnparsed && item.Currency != "" {
+		tx = &parser.Transaction{
+			Description: item.Description,
+			Amount:      item.Amount,
+			Currency:    item.Currency,
+			Tags:        item.Tags,
+			Meta:        make(map[string]string),
+		}
+	}
 	if tx == nil || tx.IsUnparsed {
-		return fmt.Errorf("cannot move unparsed item")
+		return nil, fmt.Errorf("cannot move unparsed item")
 	}
 
 	// Update day if not set
+	if day > 0 {
+		tx.Day = day
+	}
 	if tx.Day == 0 {
 		tx.Day = 1
 	}
 
 	// Add to month file
-	year := strconv.Itoa(time.Now().Year())
-	monthFile := parser.GetMonthFile(month)
-	filePath := filepath.Join(year, monthFile)
-
-	if err := addTransactionToFile(filePath, tx); err != nil {
-		return err
+	if err := addTransactionToFile(l, year, month, tx); err != nil {
+		return nil, err
 	}
 
 	// Remove from backlog
 	lines = append(lines[:actualLine], lines[actualLine+1:]...)
-	if err := os.WriteFile(backlogFile, []byte(strings.Join(lines, "\n")), 0644); err != nil {
-		return fmt.Errorf("failed to update backlog: %v", err)
+	if err := l.WriteFile(backlogFile, []byte(strings.Join(lines, "\n"))); err != nil {
+		return nil, fmt.Errorf("failed to update backlog: %v", err)
 	}
 
-	fmt.Printf(i18n.T("pool.move_success"), lineNum, month)
-	fmt.Println()
-	return nil
+	return tx, nil
 }
 
 // RemovePoolItem removes an item from the backlog
 func RemovePoolItem(lineNumStr string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
 	lineNum, err := strconv.Atoi(lineNumStr)
@@ -203,27 +245,14 @@ func RemovePoolItem(lineNumStr string) error {
 	}
 
 	// Read backlog
-	content, err := os.ReadFile(backlogFile)
+	content, err := l.ReadFile(backlogFile)
 	if err != nil {
 		return fmt.Errorf("failed to read backlog: %v", err)
 	}
 
 	lines := strings.Split(string(content), "\n")
 
-	// Find the line
-	txLine := 0
-	actualLine := -1
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "-") {
-			txLine++
-			if txLine == lineNum {
-				actualLine = i
-				break
-			}
-		}
-	}
-
+	actualLine := findItem(lines, lineNum)
 	if actualLine == -1 {
 		return fmt.Errorf("item not found at line %d", lineNum)
 	}
@@ -239,18 +268,59 @@ func RemovePoolItem(lineNumStr string) error {
 		return nil
 	}
 
-	// Remove
-	lines = append(lines[:actualLine], lines[actualLine+1:]...)
-	if err := os.WriteFile(backlogFile, []byte(strings.Join(lines, "\n")), 0644); err != nil {
-		return fmt.Errorf("failed to update backlog: %v", err)
+	if _, err := RemoveItem(l, lineNum); err != nil {
+		return err
 	}
 
 	fmt.Println(i18n.T("pool.remove_success"))
 	return nil
 }
 
-func addTransactionToFile(filePath string, tx *parser.Transaction) error {
-	content, err := os.ReadFile(filePath)
+// RemoveItem removes the n-th backlog item (1-based) and returns it
+func RemoveItem(l *ledger.Ledger, n int) (*Item, error) {
+	content, err := l.ReadFile(backlogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backlog: %v", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	actualLine := findItem(lines, n)
+	if actualLine == -1 {
+		return nil, fmt.Errorf("item not found at line %d", n)
+	}
+	item := parseItem(lines[actualLine], actualLine+1)
+
+	lines = append(lines[:actualLine], lines[actualLine+1:]...)
+	if err := l.WriteFile(backlogFile, []byte(strings.Join(lines, "\n"))); err != nil {
+		return nil, fmt.Errorf("failed to update backlog: %v", err)
+	}
+
+	return item, nil
+}
+
+// findItem returns the index of the n-th backlog item (1-based) in lines, -1 if there is none
+func findItem(lines []string, n int) int {
+	count := 0
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "-") {
+			count++
+			if count == n {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func addTransactionToFile(l *ledger.Ledger, year, month int, tx *parser.Transaction) error {
+	filePath := ledger.MonthPath(year, month)
+
+	// A missing month file starts with the default structure
+	content, err := l.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		content, err = []byte(ledger.NewMonthFile(year, month)), nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
@@ -284,7 +354,7 @@ func addTransactionToFile(filePath string, tx *parser.Transaction) error {
 	// Insert
 	lines = append(lines[:insertIndex], append([]string{formatted}, lines[insertIndex:]...)...)
 
-	return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644)
+	return l.WriteFile(filePath, []byte(strings.Join(lines, "\n")))
 }
 
 func truncate(s string, maxLen int) string {
@@ -293,3 +363,119 @@ func truncate(s string, maxLen int) string {
 	}
 	return s[:maxLen-3] + "..."
 }
+
+// Item represents a single backlog item
+type Item struct {
+	Description string   `json:"description" yaml:"description"`
+	Amount      float64  `json:"amount" yaml:"amount"`
+	Currency    string   `json:"currency" yaml:"currency"`
+	Year        int      `json:"year,omitempty" yaml:"year,omitempty"`   // 0 if the item has no expected date
+	Month       int      `json:"month,omitempty" yaml:"month,omitempty"` // 0 if the item has no expected date
+	Tags        []string `json:"tags" yaml:"tags"`
+	LineNumber  int      `json:"line" yaml:"line"`
+	Raw         string   `json:"raw" yaml:"raw"`
+}
+
+// IsDated returns true if the item has an expected month
+func (i *Item) IsDated() bool {
+	return i.Month > 0
+}
+
+// LoadItems parses all items in the backlog
+// Supports both the transaction format (- DAY | DESC | AMOUNT | TAGS)
+// and the pool format written by 'pool add' (- DESC | AMOUNT | MONTH | TAGS)
+func LoadItems(l *ledger.Ledger) ([]*Item, error) {
+	content, err := l.ReadFile(backlogFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []*Item{}, nil
+		}
+		return nil, fmt.Errorf("failed to read backlog: %v", err)
+	}
+
+	items := []*Item{}
+	for i, line := range strings.Split(string(content), "\n") {
+		if item := parseItem(line, i+1); item != nil {
+			items = append(items, item)
+		}
+	}
+
+	return items, nil
+}
+
+// parseItem parses a single backlog line, returns nil for non-item lines
+func parseItem(line string, lineNum int) *Item {
+	tx := parser.ParseTransaction(line, lineNum)
+	if tx == nil {
+		return nil
+	}
+
+	if !tx.IsUnparsed {
+		return &Item{
+			Description: tx.Description,
+			Amount:      tx.Amount,
+			Currency:    tx.Currency,
+			Tags:        tx.Tags,
+			LineNumber:  lineNum,
+			Raw:         tx.Raw,
+		}
+	}
+
+	// Pool format: - DESC | AMOUNT | MONTH | TAGS
+	content := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+	parts := strings.Split(content, "|")
+	item := &Item{
+		Description: strings.TrimSpace(parts[0]),
+		Tags:        []string{},
+		LineNumber:  lineNum,
+		Raw:         tx.Raw,
+	}
+
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		if amount, curr, err := parser.ParseAmount(parts[1]); err == nil {
+			item.Amount = amount
+			item.Currency = curr
+		} else if amount, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err == nil {
+			// Amount without currency
+			item.Amount = amount
+		}
+	}
+
+	if len(parts) > 2 {
+		item.Year, item.Month = parseExpectedMonth(strings.TrimSpace(parts[2]))
+	}
+
+	if len(parts) > 3 {
+		for _, word := range strings.Fields(parts[3]) {
+			if strings.HasPrefix(word, "#") {
+				item.Tags = append(item.Tags, strings.TrimPrefix(word, "#"))
+			}
+		}
+	}
+
+	return item
+}
+
+// parseExpectedMonth parses "YYYY-MM" or "MM" into year and month
+// A bare month is resolved to its next occurrence from the current month
+func parseExpectedMonth(value string) (int, int) {
+	if value == "" {
+		return 0, 0
+	}
+
+	if t, err := time.Parse("2006-01", value); err == nil {
+		return t.Year(), int(t.Month())
+	}
+
+	month, err := strconv.Atoi(value)
+	if err != nil || month < 1 || month > 12 {
+		return 0, 0
+	}
+
+	now := time.Now()
+	year := now.Year()
+	if month < int(now.Month()) {
+		year++
+	}
+	return year, month
+}
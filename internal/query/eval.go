@@ -0,0 +1,214 @@
+package query
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"spendgrid/internal/category"
+)
+
+func (c *cond) match(r *row) bool {
+	tx := r.entry.Tx
+
+	switch c.field {
+	case "date":
+		return c.matchDate(r)
+
+	case "year":
+		return c.matchNumber(float64(r.entry.Year))
+	case "month":
+		return c.matchNumber(float64(r.entry.Month))
+	case "day":
+		return c.matchNumber(float64(tx.Day))
+
+	case "amount", "abs":
+		return c.matchAmount(r)
+
+	case "currency":
+		return c.matchStrings([]string{tx.Currency}, strings.EqualFold)
+
+	case "tag":
+		return c.matchStrings(tx.Tags, func(tag, value string) bool {
+			path, want := r.tree.Resolve(tag), r.tree.Resolve(value)
+			return path == want || strings.HasPrefix(path, want+category.Separator)
+		})
+
+	case "project":
+		return c.matchStrings(tx.Projects, strings.EqualFold)
+
+	case "account":
+		if tx.Account == "" {
+			return c.op == "!=" || c.op == "!~"
+		}
+		return c.matchStrings([]string{tx.Account}, strings.EqualFold)
+
+	case "desc":
+		return c.matchStrings([]string{tx.Description}, strings.EqualFold)
+
+	case "meta":
+		value, ok := tx.Meta[c.metaKey]
+		if c.op == "" {
+			return ok
+		}
+		if !ok {
+			return c.op == "!=" || c.op == "!~"
+		}
+		return c.matchMeta(value)
+
+	case "rule":
+		return c.matchBool(tx.IsRule)
+	case "completed":
+		return c.matchBool(tx.Completed)
+	case "planned":
+		return c.matchBool(r.entry.IsPlanned())
+	case "income":
+		return c.matchBool(tx.IsIncome())
+	case "expense":
+		return c.matchBool(tx.IsExpense())
+	}
+
+	return false
+}
+
+// matchStrings compares the candidates of a row (e.g. its tags) with the values
+// = and in match if any candidate equals any value, != if none equals the value
+func (c *cond) matchStrings(candidates []string, equal func(a, b string) bool) bool {
+	switch c.op {
+	case "~", "!~":
+		found := false
+		for _, candidate := range candidates {
+			if c.re.MatchString(candidate) {
+				found = true
+				break
+			}
+		}
+		return found == (c.op == "~")
+	}
+
+	found := false
+	for _, candidate := range candidates {
+		for _, value := range c.values {
+			if equal(candidate, value) {
+				found = true
+			}
+		}
+	}
+	return found == (c.op != "!=")
+}
+
+func (c *cond) matchNumber(n float64) bool {
+	if c.op == "in" {
+		for _, a := range c.amounts {
+			if n == a.value {
+				return true
+			}
+		}
+		return false
+	}
+	return compareNumbers(c.op, n, c.amounts[0].value)
+}
+
+// matchAmount compares the row amount, converted when the value has a currency
+func (c *cond) matchAmount(r *row) bool {
+	for _, a := range c.amounts {
+		amount, ok := r.amountIn(a.currency)
+		if !ok {
+			return false
+		}
+		if c.field == "abs" {
+			amount = math.Abs(amount)
+		}
+
+		if c.op == "in" {
+			if amount == a.value {
+				return true
+			}
+			continue
+		}
+		return compareNumbers(c.op, amount, a.value)
+	}
+	return false
+}
+
+func (c *cond) matchDate(r *row) bool {
+	date := r.entry.Date()
+	in := func(p period) bool {
+		return !date.Before(p.start) && date.Before(p.end)
+	}
+
+	switch c.op {
+	case "=", "in":
+		for _, p := range c.periods {
+			if in(p) {
+				return true
+			}
+		}
+		return false
+	case "!=":
+		return !in(c.periods[0])
+	case ">":
+		return !date.Before(c.periods[0].end)
+	case ">=":
+		return !date.Before(c.periods[0].start)
+	case "<":
+		return date.Before(c.periods[0].start)
+	case "<=":
+		return date.Before(c.periods[0].end)
+	}
+	return false
+}
+
+// matchMeta compares meta values as numbers when both sides are numbers
+func (c *cond) matchMeta(value string) bool {
+	switch c.op {
+	case ">", ">=", "<", "<=":
+		n, err1 := strconv.ParseFloat(value, 64)
+		want, err2 := strconv.ParseFloat(c.values[0], 64)
+		if err1 != nil || err2 != nil {
+			return compareStrings(c.op, value, c.values[0])
+		}
+		return compareNumbers(c.op, n, want)
+	}
+	return c.matchStrings([]string{value}, strings.EqualFold)
+}
+
+func (c *cond) matchBool(v bool) bool {
+	if c.op == "" {
+		return v
+	}
+	want, _ := strconv.ParseBool(c.values[0])
+	return (v == want) == (c.op == "=")
+}
+
+func compareNumbers(op string, a, b float64) bool {
+	switch op {
+	case "=":
+		return a == b
+	case "!=":
+		return a != b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
+
+func compareStrings(op string, a, b string) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokWord tokenKind = iota
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokEOF
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators, longest first
+var operators = []string{">=", "<=", "!=", "!~", "==", "=", ">", "<", "~"}
+
+// tokenize splits a query into words, quoted strings, operators, parentheses and commas
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{kind: tokLParen, text: "(", pos: i})
+			i++
+
+		case r == ')':
+			tokens = append(tokens, token{kind: tokRParen, text: ")", pos: i})
+			i++
+
+		case r == ',':
+			tokens = append(tokens, token{kind: tokComma, text: ",", pos: i})
+			i++
+
+		case r == '"' || r == '\'':
+			start := i
+			i++
+			var b strings.Builder
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start+1)
+			}
+			i++
+			tokens = append(tokens, token{kind: tokString, text: b.String(), pos: start})
+
+		default:
+			if op := matchOperator(runes[i:]); op != "" {
+				tokens = append(tokens, token{kind: tokOp, text: op, pos: i})
+				i += len(op)
+				continue
+			}
+
+			start := i
+			for i < len(runes) && !isDelimiter(runes[i]) && matchOperator(runes[i:]) == "" {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q at position %d", string(r), start+1)
+			}
+			tokens = append(tokens, token{kind: tokWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+func matchOperator(runes []rune) string {
+	for _, op := range operators {
+		if len(runes) >= len(op) && string(runes[:len(op)]) == op {
+			return op
+		}
+	}
+	return ""
+}
+
+func isDelimiter(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')' || r == ',' || r == '"' || r == '\''
+}
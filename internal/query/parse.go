@@ -0,0 +1,458 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/currency"
+	"spendgrid/internal/parser"
+)
+
+// Fields that can be used in conditions
+var fieldNames = []string{
+	"date", "year", "month", "day", "amount", "abs", "currency",
+	"tag", "project", "account", "desc", "meta.<key>",
+	"rule", "completed", "planned", "income", "expense",
+}
+
+// GroupFields are the fields a query can be grouped by
+var GroupFields = []string{"tag", "project", "account", "currency", "year", "month", "quarter", "day", "desc"}
+
+// Aggregates are the supported aggregation functions
+var Aggregates = []string{"sum", "avg", "count", "min", "max"}
+
+var keywords = map[string]bool{"and": true, "or": true, "not": true, "group": true, "by": true, "in": true}
+
+// Query is a parsed query expression
+type Query struct {
+	Source     string
+	filter     node
+	GroupBy    []string
+	Aggregates []string
+}
+
+type node interface {
+	match(r *row) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n *andNode) match(r *row) bool { return n.left.match(r) && n.right.match(r) }
+func (n *orNode) match(r *row) bool  { return n.left.match(r) || n.right.match(r) }
+func (n *notNode) match(r *row) bool { return !n.inner.match(r) }
+
+// cond is a single field comparison
+type cond struct {
+	field   string
+	metaKey string
+	op      string // =, !=, >, >=, <, <=, ~, !~, in, or "" for a bare boolean
+	values  []string
+	re      *regexp.Regexp
+	amounts []amountValue
+	periods []period
+}
+
+type amountValue struct {
+	value    float64
+	currency string // empty compares the row amount as written
+}
+
+// period is a half-open date range [start, end)
+type period struct {
+	start, end time.Time
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses a query expression
+// Grammar: [condition ...] [group by field[, field]] [sum|avg|count|min|max ...]
+func Parse(input string) (*Query, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &queryParser{tokens: tokens}
+	q := &Query{Source: input}
+
+	if p.startsTerm() {
+		if q.filter, err = p.parseOr(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isWord("group") {
+		p.next()
+		if !p.isWord("by") {
+			return nil, p.errorf("expected 'by' after 'group'")
+		}
+		p.next()
+		for p.peek().kind == tokWord && !isAggregate(p.peek().text) {
+			field := strings.ToLower(p.next().text)
+			if !contains(GroupFields, field) {
+				return nil, fmt.Errorf("cannot group by %s (use %s)", field, strings.Join(GroupFields, ", "))
+			}
+			q.GroupBy = append(q.GroupBy, field)
+			if p.peek().kind == tokComma {
+				p.next()
+			}
+		}
+		if len(q.GroupBy) == 0 {
+			return nil, p.errorf("expected a field after 'group by'")
+		}
+	}
+
+	for p.peek().kind == tokWord && isAggregate(p.peek().text) {
+		fn := strings.ToLower(p.next().text)
+		// sum(amount) is accepted as a longer form of sum
+		if p.peek().kind == tokLParen {
+			p.next()
+			if arg := p.next(); arg.kind != tokWord || strings.ToLower(arg.text) != "amount" {
+				return nil, fmt.Errorf("%s only aggregates amount", fn)
+			}
+			if p.next().kind != tokRParen {
+				return nil, p.errorf("expected ')'")
+			}
+		}
+		if !contains(q.Aggregates, fn) {
+			q.Aggregates = append(q.Aggregates, fn)
+		}
+		if p.peek().kind == tokComma {
+			p.next()
+		}
+	}
+
+	if p.peek().kind != tokEOF {
+		return nil, p.errorf("unexpected %q", p.peek().text)
+	}
+
+	// Grouping without an aggregate counts and sums
+	if len(q.GroupBy) > 0 && len(q.Aggregates) == 0 {
+		q.Aggregates = []string{"count", "sum"}
+	}
+
+	return q, nil
+}
+
+// IsAggregate returns true if the query groups or aggregates rows
+func (q *Query) IsAggregate() bool {
+	return len(q.Aggregates) > 0
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *queryParser) isWord(word string) bool {
+	t := p.peek()
+	return t.kind == tokWord && strings.EqualFold(t.text, word)
+}
+
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at position %d", fmt.Sprintf(format, args...), p.peek().pos+1)
+}
+
+// startsTerm returns true if the next token can begin a condition
+func (p *queryParser) startsTerm() bool {
+	t := p.peek()
+	switch t.kind {
+	case tokLParen:
+		return true
+	case tokWord:
+		word := strings.ToLower(t.text)
+		return word == "not" || (!keywords[word] && !isAggregate(word))
+	}
+	return false
+}
+
+func (p *queryParser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.isWord("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left, right}
+	}
+	return left, nil
+}
+
+// parseAnd joins conditions with 'and'; adjacent conditions are joined implicitly
+func (p *queryParser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if p.isWord("and") {
+			p.next()
+		} else if !p.startsTerm() {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left, right}
+	}
+}
+
+func (p *queryParser) parseUnary() (node, error) {
+	if p.isWord("not") {
+		p.next()
+		inner, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{inner}, nil
+	}
+
+	if p.peek().kind == tokLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, p.errorf("expected ')'")
+		}
+		return inner, nil
+	}
+
+	return p.parseCondition()
+}
+
+func (p *queryParser) parseCondition() (node, error) {
+	t := p.next()
+	if t.kind != tokWord {
+		return nil, fmt.Errorf("expected a condition at position %d", t.pos+1)
+	}
+
+	// Shorthands: #tag, @project, &account
+	switch t.text[0] {
+	case '#':
+		return compile(&cond{field: "tag", op: "=", values: []string{t.text[1:]}})
+	case '@':
+		return compile(&cond{field: "project", op: "=", values: []string{t.text[1:]}})
+	case '&':
+		return compile(&cond{field: "account", op: "=", values: []string{t.text[1:]}})
+	}
+
+	c := &cond{field: strings.ToLower(t.text)}
+	if c.field == "description" {
+		c.field = "desc"
+	}
+	if strings.HasPrefix(c.field, "meta.") {
+		c.metaKey = t.text[len("meta."):]
+		c.field = "meta"
+	} else if !contains(fieldNames, c.field) {
+		return nil, fmt.Errorf("unknown field %q (use %s)", t.text, strings.Join(fieldNames, ", "))
+	}
+
+	switch {
+	case p.peek().kind == tokOp:
+		c.op = p.next().text
+		if c.op == "==" {
+			c.op = "="
+		}
+		v := p.next()
+		if v.kind != tokWord && v.kind != tokString {
+			return nil, fmt.Errorf("expected a value after %s %s", t.text, c.op)
+		}
+		c.values = []string{v.text}
+
+	case p.isWord("in"):
+		p.next()
+		c.op = "in"
+		values, err := p.parseValues()
+		if err != nil {
+			return nil, err
+		}
+		c.values = values
+
+	default:
+		// Bare boolean fields and meta keys
+		switch c.field {
+		case "rule", "completed", "planned", "income", "expense", "meta":
+		default:
+			return nil, p.errorf("expected an operator after %s", t.text)
+		}
+	}
+
+	return compile(c)
+}
+
+// parseValues parses a single value or a parenthesized list
+func (p *queryParser) parseValues() ([]string, error) {
+	if p.peek().kind != tokLParen {
+		v := p.next()
+		if v.kind != tokWord && v.kind != tokString {
+			return nil, p.errorf("expected a value after 'in'")
+		}
+		return []string{v.text}, nil
+	}
+
+	p.next()
+	var values []string
+	for {
+		v := p.next()
+		if v.kind != tokWord && v.kind != tokString {
+			return nil, fmt.Errorf("expected a value at position %d", v.pos+1)
+		}
+		values = append(values, v.text)
+
+		switch p.next().kind {
+		case tokComma:
+			continue
+		case tokRParen:
+			return values, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or ')' in value list")
+		}
+	}
+}
+
+// compile checks the operator of a condition and prepares its values
+func compile(c *cond) (node, error) {
+	ordered := c.op == ">" || c.op == ">=" || c.op == "<" || c.op == "<="
+	regex := c.op == "~" || c.op == "!~"
+
+	switch c.field {
+	case "date":
+		if regex {
+			return nil, fmt.Errorf("date cannot be matched with %s", c.op)
+		}
+		for _, v := range c.values {
+			per, err := parsePeriod(v)
+			if err != nil {
+				return nil, err
+			}
+			c.periods = append(c.periods, per)
+		}
+
+	case "year", "month", "day", "amount", "abs":
+		if regex {
+			return nil, fmt.Errorf("%s cannot be matched with %s", c.field, c.op)
+		}
+		for _, v := range c.values {
+			a, err := parseAmountValue(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s value %q", c.field, v)
+			}
+			if a.currency != "" && c.field != "amount" && c.field != "abs" {
+				return nil, fmt.Errorf("invalid %s value %q", c.field, v)
+			}
+			c.amounts = append(c.amounts, a)
+		}
+
+	case "rule", "completed", "planned", "income", "expense":
+		if c.op == "" {
+			break
+		}
+		if c.op != "=" && c.op != "!=" {
+			return nil, fmt.Errorf("%s can only be compared with = or !=", c.field)
+		}
+		if _, err := strconv.ParseBool(c.values[0]); err != nil {
+			return nil, fmt.Errorf("%s expects true or false", c.field)
+		}
+
+	default:
+		if ordered && c.field != "meta" {
+			return nil, fmt.Errorf("%s cannot be compared with %s", c.field, c.op)
+		}
+		if c.field == "currency" {
+			for i, v := range c.values {
+				c.values[i] = currency.Normalize(v)
+			}
+		}
+		if c.field == "tag" || c.field == "project" || c.field == "account" {
+			for i, v := range c.values {
+				c.values[i] = strings.TrimLeft(v, "#@&")
+			}
+		}
+	}
+
+	if regex {
+		re, err := regexp.Compile("(?i)" + c.values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", c.values[0], err)
+		}
+		c.re = re
+	}
+
+	return c, nil
+}
+
+// parseAmountValue parses 500, -120.5 or 500TRY
+func parseAmountValue(value string) (amountValue, error) {
+	if n, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64); err == nil {
+		return amountValue{value: n}, nil
+	}
+	amount, cur, err := parser.ParseAmount(value)
+	if err != nil {
+		return amountValue{}, err
+	}
+	return amountValue{value: amount, currency: cur}, nil
+}
+
+var quarterPattern = regexp.MustCompile(`^(\d{4})-[Qq]([1-4])$`)
+
+// parsePeriod parses 2026, 2026-Q3, 2026-07 or 2026-07-15 into a date range
+func parsePeriod(value string) (period, error) {
+	if m := quarterPattern.FindStringSubmatch(value); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		start := time.Date(year, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+		return period{start, start.AddDate(0, 3, 0)}, nil
+	}
+
+	for _, layout := range []struct {
+		format string
+		years  int
+		months int
+		days   int
+	}{
+		{"2006-01-02", 0, 0, 1},
+		{"2006-01", 0, 1, 0},
+		{"2006", 1, 0, 0},
+	} {
+		if start, err := time.Parse(layout.format, value); err == nil {
+			return period{start, start.AddDate(layout.years, layout.months, layout.days)}, nil
+		}
+	}
+
+	return period{}, fmt.Errorf("invalid date %q (use YYYY, YYYY-QN, YYYY-MM or YYYY-MM-DD)", value)
+}
+
+func isAggregate(word string) bool {
+	return contains(Aggregates, strings.ToLower(word))
+}
+
+func contains(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
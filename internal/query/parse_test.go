@@ -0,0 +1,58 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+func TestPrecedence(t *testing.T) {
+	lines := map[string]string{
+		"market": "- 1 | Market | -100 TRY | @ev",
+		"coffee": "- 2 | Coffee | -40 USD | @is",
+		"salary": "- 3 | Salary | +5000 TRY | @is",
+	}
+	names := []string{"market", "coffee", "salary"}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"currency = USD or currency = TRY and amount > 0", "coffee salary"},
+		{"(currency = USD or currency = TRY) and amount > 0", "salary"},
+		{"currency = TRY and amount < 0 or currency = USD", "market coffee"},
+		{"currency = TRY and (amount < 0 or currency = USD)", "market"},
+		{"not currency = USD and amount < 0", "market"},
+		{"not (currency = USD and amount < 0)", "market salary"},
+		{"not not @ev", "market"},
+		{"@is amount < 0 or @ev", "market coffee"},
+		{"@ev or @is amount < 0", "market coffee"},
+		{"@is (amount < 0 or currency = TRY)", "coffee salary"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			q, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, name := range names {
+				tx := parser.ParseTransaction(lines[name], 1)
+				if tx == nil || tx.IsUnparsed {
+					t.Fatalf("cannot parse %q", lines[name])
+				}
+				r := &row{entry: &ledger.Entry{Year: 2026, Month: 1, Tx: tx}, converted: make(map[string]*float64)}
+				if q.filter.match(r) {
+					got = append(got, name)
+				}
+			}
+			if strings.Join(got, " ") != tt.want {
+				t.Errorf("got %q, want %q", strings.Join(got, " "), tt.want)
+			}
+		})
+	}
+}
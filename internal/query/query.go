@@ -0,0 +1,439 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/category"
+	"spendgrid/internal/exchange"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+)
+
+// Match is a row that matched the query
+type Match struct {
+	Date        string            `json:"date" yaml:"date"`
+	File        string            `json:"file" yaml:"file"`
+	Line        int               `json:"line" yaml:"line"`
+	Description string            `json:"description" yaml:"description"`
+	Amount      float64           `json:"amount" yaml:"amount"`
+	Currency    string            `json:"currency" yaml:"currency"`
+	Tags        []string          `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Projects    []string          `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Account     string            `json:"account,omitempty" yaml:"account,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty" yaml:"meta,omitempty"`
+	Rule        bool              `json:"rule" yaml:"rule"`
+	Completed   bool              `json:"completed" yaml:"completed"`
+}
+
+// Group is one group of an aggregated query
+// Amounts are in the result currency; rows without an exchange rate are only counted
+type Group struct {
+	Key   map[string]string `json:"key" yaml:"key"`
+	Count *int              `json:"count,omitempty" yaml:"count,omitempty"`
+	Sum   *float64          `json:"sum,omitempty" yaml:"sum,omitempty"`
+	Avg   *float64          `json:"avg,omitempty" yaml:"avg,omitempty"`
+	Min   *float64          `json:"min,omitempty" yaml:"min,omitempty"`
+	Max   *float64          `json:"max,omitempty" yaml:"max,omitempty"`
+
+	rows      int
+	converted []float64
+}
+
+// Result is the outcome of a query
+type Result struct {
+	Query        string   `json:"query" yaml:"query"`
+	Currency     string   `json:"currency" yaml:"currency"`
+	GroupBy      []string `json:"group_by,omitempty" yaml:"group_by,omitempty"`
+	Aggregates   []string `json:"aggregates,omitempty" yaml:"aggregates,omitempty"`
+	Matches      []*Match `json:"matches" yaml:"matches"`
+	Groups       []*Group `json:"groups,omitempty" yaml:"groups,omitempty"`
+	MissingRates int      `json:"missing_rates" yaml:"missing_rates"`
+}
+
+// Table returns the groups of an aggregated query, otherwise the matches, for CSV output
+func (r *Result) Table() interface{} {
+	if len(r.Aggregates) == 0 {
+		return r.Matches
+	}
+
+	grid := &output.Grid{Header: append(append([]string{}, r.GroupBy...), r.Aggregates...)}
+	for _, g := range r.Groups {
+		var row []string
+		for _, field := range r.GroupBy {
+			row = append(row, g.Key[field])
+		}
+		for _, fn := range r.Aggregates {
+			row = append(row, g.value(fn))
+		}
+		grid.Rows = append(grid.Rows, row)
+	}
+	return grid
+}
+
+// row is a ledger entry being evaluated
+type row struct {
+	entry     *ledger.Entry
+	tree      *category.Tree
+	converted map[string]*float64 // currency -> amount, nil if there is no rate
+}
+
+// amountIn returns the row amount in a currency, false if there is no exchange rate
+func (r *row) amountIn(cur string) (float64, bool) {
+	if cur == "" || strings.EqualFold(cur, r.entry.Tx.Currency) {
+		return r.entry.Tx.Amount, true
+	}
+
+	amount, done := r.converted[cur]
+	if !done {
+		if value, err := exchange.ConvertTransaction(r.entry.Tx, cur, r.entry.Date()); err == nil {
+			amount = &value
+		}
+		r.converted[cur] = amount
+	}
+
+	if amount == nil {
+		return 0, false
+	}
+	return *amount, true
+}
+
+// Entries returns the ledger entries that match the conditions of a query
+// Grouping and aggregates are not allowed
+func Entries(q *Query) ([]*ledger.Entry, error) {
+	if q.IsAggregate() {
+		return nil, fmt.Errorf("group by and aggregates cannot be used here")
+	}
+	if err := ledger.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
+	entries, err := ledger.AllEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := category.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*ledger.Entry
+	for _, entry := range entries {
+		r := &row{entry: entry, tree: tree, converted: make(map[string]*float64)}
+		if q.filter == nil || q.filter.match(r) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched, nil
+}
+
+// Run evaluates a query over every month file of the ledger
+// Aggregated amounts are converted to cur
+func Run(q *Query, cur string) (*Result, error) {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
+	entries, err := ledger.AllEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := category.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Query:      q.Source,
+		Currency:   cur,
+		GroupBy:    q.GroupBy,
+		Aggregates: q.Aggregates,
+		Matches:    []*Match{},
+	}
+
+	groups := make(map[string]*Group)
+	var order []string
+
+	for _, entry := range entries {
+		r := &row{entry: entry, tree: tree, converted: make(map[string]*float64)}
+		if q.filter != nil && !q.filter.match(r) {
+			continue
+		}
+
+		tx := entry.Tx
+		result.Matches = append(result.Matches, &Match{
+			Date:        entry.Date().Format("2006-01-02"),
+			File:        entry.Path,
+			Line:        tx.LineNumber,
+			Description: tx.Description,
+			Amount:      tx.Amount,
+			Currency:    tx.Currency,
+			Tags:        tx.Tags,
+			Projects:    tx.Projects,
+			Account:     tx.Account,
+			Meta:        tx.Meta,
+			Rule:        tx.IsRule,
+			Completed:   tx.Completed,
+		})
+
+		if !q.IsAggregate() {
+			continue
+		}
+
+		amount, ok := r.amountIn(cur)
+		if !ok {
+			result.MissingRates++
+		}
+
+		for _, key := range groupKeys(r, q.GroupBy) {
+			id := strings.Join(keyValues(key, q.GroupBy), "\x00")
+			g := groups[id]
+			if g == nil {
+				g = &Group{Key: key}
+				groups[id] = g
+				order = append(order, id)
+			}
+			g.rows++
+			if ok {
+				g.converted = append(g.converted, amount)
+			}
+		}
+	}
+
+	if q.IsAggregate() {
+		sort.Strings(order)
+		// Without group by there is a single total, even when nothing matched
+		if len(q.GroupBy) == 0 && len(order) == 0 {
+			groups[""] = &Group{Key: map[string]string{}}
+			order = append(order, "")
+		}
+		for _, id := range order {
+			g := groups[id]
+			g.aggregate(q.Aggregates)
+			result.Groups = append(result.Groups, g)
+		}
+	}
+
+	return result, nil
+}
+
+// groupKeys returns the group keys of a row
+// A row with several tags or projects belongs to a group for each of them
+func groupKeys(r *row, fields []string) []map[string]string {
+	keys := []map[string]string{{}}
+	tx := r.entry.Tx
+	date := r.entry.Date()
+
+	for _, field := range fields {
+		var values []string
+		switch field {
+		case "tag":
+			for _, tag := range tx.Tags {
+				values = append(values, r.tree.Resolve(tag))
+			}
+		case "project":
+			values = tx.Projects
+		case "account":
+			values = []string{tx.Account}
+		case "currency":
+			values = []string{tx.Currency}
+		case "year":
+			values = []string{date.Format("2006")}
+		case "month":
+			values = []string{date.Format("2006-01")}
+		case "quarter":
+			values = []string{fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())-1)/3+1)}
+		case "day":
+			values = []string{date.Format("2006-01-02")}
+		case "desc":
+			values = []string{tx.Description}
+		}
+		if len(values) == 0 {
+			values = []string{""}
+		}
+
+		var expanded []map[string]string
+		for _, key := range keys {
+			for _, value := range values {
+				next := make(map[string]string, len(key)+1)
+				for k, v := range key {
+					next[k] = v
+				}
+				next[field] = value
+				expanded = append(expanded, next)
+			}
+		}
+		keys = expanded
+	}
+
+	return keys
+}
+
+func keyValues(key map[string]string, fields []string) []string {
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		values[i] = key[field]
+	}
+	return values
+}
+
+// aggregate fills the requested aggregates of a group
+func (g *Group) aggregate(fns []string) {
+	for _, fn := range fns {
+		switch fn {
+		case "count":
+			count := g.rows
+			g.Count = &count
+		case "sum":
+			sum := 0.0
+			for _, v := range g.converted {
+				sum += v
+			}
+			g.Sum = &sum
+		case "avg":
+			if len(g.converted) > 0 {
+				sum := 0.0
+				for _, v := range g.converted {
+					sum += v
+				}
+				avg := sum / float64(len(g.converted))
+				g.Avg = &avg
+			}
+		case "min", "max":
+			if len(g.converted) == 0 {
+				continue
+			}
+			value := g.converted[0]
+			for _, v := range g.converted[1:] {
+				if (fn == "min" && v < value) || (fn == "max" && v > value) {
+					value = v
+				}
+			}
+			if fn == "min" {
+				g.Min = &value
+			} else {
+				g.Max = &value
+			}
+		}
+	}
+}
+
+// value returns an aggregate of the group formatted for output, empty if it has none
+func (g *Group) value(fn string) string {
+	var v *float64
+	switch fn {
+	case "count":
+		if g.Count != nil {
+			return strconv.Itoa(*g.Count)
+		}
+		return ""
+	case "sum":
+		v = g.Sum
+	case "avg":
+		v = g.Avg
+	case "min":
+		v = g.Min
+	case "max":
+		v = g.Max
+	}
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(math.Round(*v*100)/100, 'f', 2, 64)
+}
+
+// Show prints the result of a query as a table
+func Show(result *Result) {
+	fmt.Println()
+	if len(result.Aggregates) > 0 {
+		showGroups(result)
+	} else {
+		showMatches(result)
+	}
+
+	if result.MissingRates > 0 {
+		color.Yellow(i18n.T("query.missing_rates"), result.MissingRates, result.Currency)
+	}
+	fmt.Println()
+}
+
+func showMatches(result *Result) {
+	if len(result.Matches) == 0 {
+		fmt.Println(i18n.T("query.no_matches"))
+		return
+	}
+
+	fmt.Printf("%-10s  %-25s %12s %-4s  %-25s %s\n", "Date", "Description", "Amount", "", "Tags", "File")
+	fmt.Println(strings.Repeat("-", 100))
+	for _, m := range result.Matches {
+		var labels []string
+		for _, tag := range m.Tags {
+			labels = append(labels, "#"+tag)
+		}
+		for _, proj := range m.Projects {
+			labels = append(labels, "@"+proj)
+		}
+		if m.Account != "" {
+			labels = append(labels, "&"+m.Account)
+		}
+
+		fmt.Printf("%-10s  %-25s %12.2f %-4s  %-25s %s:%d\n",
+			m.Date, output.Truncate(m.Description, 25), m.Amount, m.Currency,
+			output.Truncate(strings.Join(labels, " "), 25), m.File, m.Line)
+	}
+	fmt.Println(strings.Repeat("-", 100))
+	fmt.Printf(i18n.T("query.matches")+"\n", len(result.Matches))
+}
+
+func showGroups(result *Result) {
+	header := append(append([]string{}, result.GroupBy...), result.Aggregates...)
+	grid := result.Table().(*output.Grid)
+
+	// Column widths from the header and the values
+	widths := make([]int, len(header))
+	for i, h := range header {
+		widths[i] = len(h)
+	}
+	for _, r := range grid.Rows {
+		for i, v := range r {
+			if n := len([]rune(v)); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	printRow := func(values []string) {
+		parts := make([]string, len(values))
+		for i, v := range values {
+			if i < len(result.GroupBy) {
+				parts[i] = v + strings.Repeat(" ", widths[i]-len([]rune(v)))
+			} else {
+				parts[i] = strings.Repeat(" ", widths[i]-len([]rune(v))) + v
+			}
+		}
+		fmt.Println(strings.Join(parts, "  "))
+	}
+
+	printRow(header)
+	total := len(header) * 2
+	for _, w := range widths {
+		total += w
+	}
+	fmt.Println(strings.Repeat("-", total))
+	for _, r := range grid.Rows {
+		for i := range r[:len(result.GroupBy)] {
+			if r[i] == "" {
+				r[i] = "-"
+			}
+		}
+		printRow(r)
+	}
+	fmt.Printf(i18n.T("query.currency")+"\n", result.Currency)
+}
@@ -0,0 +1,341 @@
+package reconcile
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/storage"
+)
+
+// MetaKey is the meta key written to reconciled rows, e.g. [RECONCILED:2026-09-30]
+const MetaKey = "RECONCILED"
+
+// Checkpoint is the stored result of a statement reconciliation
+type Checkpoint struct {
+	Account       string    `yaml:"account"`
+	StatementDate string    `yaml:"statement_date"` // Format: YYYY-MM-DD
+	Balance       float64   `yaml:"balance"`
+	Currency      string    `yaml:"currency"`
+	Opening       float64   `yaml:"opening"`
+	Cleared       float64   `yaml:"cleared"`
+	Difference    float64   `yaml:"difference"`
+	Rows          int       `yaml:"rows"`
+	CreatedAt     time.Time `yaml:"created_at"`
+}
+
+// CheckpointSet holds all checkpoints
+type CheckpointSet struct {
+	Checkpoints []Checkpoint `yaml:"checkpoints"`
+}
+
+// GetCheckpointsFilePath returns the path to reconciliations.yml
+func GetCheckpointsFilePath() string {
+	return filepath.Join("_config", "reconciliations.yml")
+}
+
+// LoadCheckpoints loads all reconciliation checkpoints
+func LoadCheckpoints() (*CheckpointSet, error) {
+	data, err := storage.ReadFile(GetCheckpointsFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &CheckpointSet{Checkpoints: []Checkpoint{}}, nil
+		}
+		return nil, fmt.Errorf("failed to read reconciliations: %v", err)
+	}
+
+	var set CheckpointSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse reconciliations: %v", err)
+	}
+
+	return &set, nil
+}
+
+// SaveCheckpoints saves all reconciliation checkpoints
+func SaveCheckpoints(set *CheckpointSet) error {
+	data, err := yaml.Marshal(set)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reconciliations: %v", err)
+	}
+
+	header := "# SpendGrid Reconciliations\n# Hesap ekstresi mutabakat kayıtları\n\n"
+	data = append([]byte(header), data...)
+
+	if err := storage.WriteFile(GetCheckpointsFilePath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write reconciliations: %v", err)
+	}
+
+	return nil
+}
+
+// LastCheckpoint returns the latest checkpoint for an account and currency, or nil
+func (s *CheckpointSet) LastCheckpoint(account, currency string) *Checkpoint {
+	var last *Checkpoint
+	for i := range s.Checkpoints {
+		cp := &s.Checkpoints[i]
+		if cp.Account != account || cp.Currency != currency {
+			continue
+		}
+		if last == nil || cp.StatementDate >= last.StatementDate {
+			last = cp
+		}
+	}
+	return last
+}
+
+// candidate is an unreconciled row that can be ticked off
+type candidate struct {
+	entry  *ledger.Entry
+	ticked bool
+}
+
+// Reconcile runs the interactive statement reconciliation for an account
+// account: account name with or without the leading &
+// statementDate: YYYY-MM-DD
+// balance: statement balance with currency, e.g. 12345.67TRY
+func Reconcile(account, statementDate, balance string) error {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	account = strings.TrimPrefix(strings.TrimSpace(account), "&")
+	if account == "" {
+		return fmt.Errorf("account cannot be empty")
+	}
+
+	date, err := time.Parse("2006-01-02", statementDate)
+	if err != nil {
+		return fmt.Errorf("invalid statement date (use YYYY-MM-DD): %s", statementDate)
+	}
+
+	statementBalance, currency, err := parser.ParseAmount(balance)
+	if err != nil {
+		return fmt.Errorf("invalid balance: %v", err)
+	}
+
+	checkpoints, err := LoadCheckpoints()
+	if err != nil {
+		return err
+	}
+
+	opening := 0.0
+	if last := checkpoints.LastCheckpoint(account, currency); last != nil {
+		opening = last.Balance
+	}
+
+	entries, err := ledger.AllEntries()
+	if err != nil {
+		return err
+	}
+
+	var candidates []*candidate
+	skipped := 0
+	for _, e := range entries {
+		if e.Tx.Account != account || e.Tx.Meta[MetaKey] != "" || e.IsPlanned() {
+			continue
+		}
+		if e.Date().After(date) {
+			continue
+		}
+		if e.Tx.Currency != currency {
+			skipped++
+			continue
+		}
+		candidates = append(candidates, &candidate{entry: e})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].entry.Date().Before(candidates[j].entry.Date())
+	})
+
+	if skipped > 0 {
+		color.Yellow("⚠ %d row(s) in other currencies skipped", skipped)
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println(i18n.T("reconcile.no_rows"))
+		return nil
+	}
+
+	if !runSelector(candidates, account, statementDate, opening, statementBalance, currency) {
+		fmt.Println(i18n.T("common.cancel"))
+		return nil
+	}
+
+	cleared := clearedTotal(candidates)
+	difference := statementBalance - (opening + cleared)
+
+	// Write meta to every ticked row, grouped by file
+	replacements := make(map[string]map[int]string)
+	rows := 0
+	for _, c := range candidates {
+		if !c.ticked {
+			continue
+		}
+		tx := c.entry.Tx
+		tx.Meta[MetaKey] = statementDate
+		if replacements[c.entry.Path] == nil {
+			replacements[c.entry.Path] = make(map[int]string)
+		}
+		replacements[c.entry.Path][tx.LineNumber] = ledger.FormatRow(tx)
+		rows++
+	}
+
+	for path, lines := range replacements {
+		if err := ledger.ReplaceLines(path, lines); err != nil {
+			return err
+		}
+	}
+
+	checkpoints.Checkpoints = append(checkpoints.Checkpoints, Checkpoint{
+		Account:       account,
+		StatementDate: statementDate,
+		Balance:       statementBalance,
+		Currency:      currency,
+		Opening:       opening,
+		Cleared:       round2(cleared),
+		Difference:    round2(difference),
+		Rows:          rows,
+		CreatedAt:     time.Now(),
+	})
+	if err := SaveCheckpoints(checkpoints); err != nil {
+		return err
+	}
+
+	fmt.Printf(i18n.T("reconcile.success"), rows, account, statementDate)
+	fmt.Println()
+	return nil
+}
+
+// runSelector lets the user tick rows off, showing the running difference
+// Returns false if the user cancelled
+func runSelector(candidates []*candidate, account, statementDate string, opening, statementBalance float64, currency string) bool {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Print("\033[H\033[2J")
+		fmt.Println()
+		color.Cyan("%s &%s - %s", i18n.T("reconcile.header"), account, statementDate)
+		fmt.Println(strings.Repeat("-", 70))
+
+		for i, c := range candidates {
+			checkbox := "☐"
+			if c.ticked {
+				checkbox = "☑"
+			}
+			tx := c.entry.Tx
+			fmt.Printf("%3d. %s %s | %-30s | %12.2f %s\n",
+				i+1, checkbox, c.entry.Date().Format("2006-01-02"),
+				output.Truncate(tx.Description, 30), tx.Amount, tx.Currency)
+		}
+
+		cleared := clearedTotal(candidates)
+		difference := statementBalance - (opening + cleared)
+
+		fmt.Println(strings.Repeat("-", 70))
+		fmt.Printf("Opening:    %12.2f %s\n", opening, currency)
+		fmt.Printf("Cleared:    %12.2f %s\n", cleared, currency)
+		fmt.Printf("Statement:  %12.2f %s\n", statementBalance, currency)
+		if isZero(difference) {
+			color.Green("Difference: %12.2f %s", difference, currency)
+		} else {
+			color.Red("Difference: %12.2f %s", difference, currency)
+		}
+
+		fmt.Println()
+		fmt.Print("Toggle: numbers (e.g. 1 3 5-8) | [a]ll [n]one [f]inish [q]uit: ")
+
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+		input = strings.TrimSpace(strings.ToLower(input))
+
+		switch input {
+		case "q", "quit", "cancel":
+			return false
+		case "a", "all":
+			for _, c := range candidates {
+				c.ticked = true
+			}
+		case "n", "none":
+			for _, c := range candidates {
+				c.ticked = false
+			}
+		case "f", "finish", "done":
+			if !isZero(difference) {
+				fmt.Printf(i18n.T("reconcile.confirm_difference"), difference, currency)
+				fmt.Print(" ")
+				response, _ := reader.ReadString('\n')
+				response = strings.TrimSpace(strings.ToLower(response))
+				if response != "y" && response != "yes" && response != i18n.T("common.yes") {
+					continue
+				}
+			}
+			return true
+		default:
+			for _, idx := range parseSelection(input, len(candidates)) {
+				candidates[idx].ticked = !candidates[idx].ticked
+			}
+		}
+	}
+}
+
+// parseSelection parses "1 3 5-8" or "1,3,5-8" into zero-based indexes
+func parseSelection(input string, max int) []int {
+	var indexes []int
+	fields := strings.FieldsFunc(input, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+
+	for _, field := range fields {
+		from, to := field, field
+		if idx := strings.Index(field, "-"); idx > 0 {
+			from, to = field[:idx], field[idx+1:]
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		for n := start; n <= end; n++ {
+			if n >= 1 && n <= max {
+				indexes = append(indexes, n-1)
+			}
+		}
+	}
+
+	return indexes
+}
+
+func clearedTotal(candidates []*candidate) float64 {
+	total := 0.0
+	for _, c := range candidates {
+		if c.ticked {
+			total += c.entry.Tx.Amount
+		}
+	}
+	return total
+}
+
+func isZero(f float64) bool {
+	return math.Abs(f) < 0.005
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
@@ -0,0 +1,140 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"spendgrid/internal/category"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+// loadCategoryTree loads the category tree of a ledger, falling back to an empty tree
+// so a broken categories.yml does not block reports
+func loadCategoryTree(l *ledger.Ledger) *category.Tree {
+	tree, err := category.LoadLedger(l)
+	if err != nil {
+		return &category.Tree{}
+	}
+	return tree
+}
+
+// addToCategories adds a row to every level of its tag paths
+func addToCategories(byCategory map[string]map[string]float64, baseByCategory map[string]float64, tree *category.Tree, tx *parser.Transaction, inBase float64) {
+	for _, path := range tree.Rollup(tx.Tags) {
+		if byCategory[path] == nil {
+			byCategory[path] = make(map[string]float64)
+		}
+		byCategory[path][tx.Currency] += tx.Amount
+		baseByCategory[path] += inBase
+	}
+}
+
+// sortCategoryPaths sorts paths so that children follow their parent
+func sortCategoryPaths(paths []string) {
+	sort.Slice(paths, func(i, j int) bool {
+		a := strings.Split(paths[i], category.Separator)
+		b := strings.Split(paths[j], category.Separator)
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+}
+
+// hasChildren returns true if any path is directly below the given path
+func hasChildren(byCategory map[string]map[string]float64, path string) bool {
+	prefix := path + category.Separator
+	for other := range byCategory {
+		if strings.HasPrefix(other, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// printCategoryTree prints category subtotals as an indented tree
+// depth limits the number of levels shown, 0 shows all levels
+func printCategoryTree(byCategory map[string]map[string]float64, baseByCategory map[string]float64, base string, depth int) {
+	paths := getSortedKeys(byCategory)
+	sortCategoryPaths(paths)
+
+	for _, path := range paths {
+		level := category.Depth(path)
+		if depth > 0 && level >= depth {
+			continue
+		}
+
+		marker := "  "
+		if hasChildren(byCategory, path) {
+			if depth > 0 && level == depth-1 {
+				marker = "▸ "
+			} else {
+				marker = "▾ "
+			}
+		}
+
+		label := strings.Repeat("  ", level) + marker + "#" + category.Leaf(path)
+		fmt.Printf("%-28s %12.2f %s", label, baseByCategory[path], base)
+
+		currencies := make([]string, 0, len(byCategory[path]))
+		for curr := range byCategory[path] {
+			currencies = append(currencies, curr)
+		}
+		sort.Strings(currencies)
+
+		if len(currencies) > 1 || (len(currencies) == 1 && currencies[0] != base) {
+			var parts []string
+			for _, curr := range currencies {
+				parts = append(parts, fmt.Sprintf("%.2f %s", byCategory[path][curr], curr))
+			}
+			fmt.Printf("  (%s)", strings.Join(parts, ", "))
+		}
+		fmt.Println()
+	}
+}
+
+// writeCategoryHTML writes category subtotals as expandable nested lists
+func writeCategoryHTML(out *strings.Builder, byCategory map[string]map[string]float64, baseByCategory map[string]float64, base string) {
+	paths := getSortedKeys(byCategory)
+	sortCategoryPaths(paths)
+
+	children := make(map[string][]string)
+	for _, path := range paths {
+		parent := ""
+		if idx := strings.LastIndex(path, category.Separator); idx >= 0 {
+			parent = path[:idx]
+		}
+		children[parent] = append(children[parent], path)
+	}
+
+	var write func(path string)
+	write = func(path string) {
+		amount := baseByCategory[path]
+		class := "expense"
+		if amount >= 0 {
+			class = "income"
+		}
+		label := fmt.Sprintf("#%s <span class='%s'>%.2f %s</span>", html.EscapeString(category.Leaf(path)), class, amount, base)
+
+		if len(children[path]) == 0 {
+			out.WriteString(fmt.Sprintf("<div class='category'>%s</div>\n", label))
+			return
+		}
+
+		out.WriteString(fmt.Sprintf("<details class='category'><summary>%s</summary>\n", label))
+		for _, child := range children[path] {
+			write(child)
+		}
+		out.WriteString("</details>\n")
+	}
+
+	out.WriteString("<h2>By Category</h2>\n")
+	for _, path := range children[""] {
+		write(path)
+	}
+}
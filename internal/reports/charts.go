@@ -0,0 +1,197 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"spendgrid/internal/category"
+)
+
+// Inline SVG charts of the HTML report, drawn in Go so the file needs no script libraries
+
+const (
+	chartWidth  = 720.0
+	chartHeight = 240.0
+	chartTop    = 12.0
+	chartBottom = 28.0
+	chartLeft   = 72.0
+)
+
+// chartPalette colors donut slices, the rest of the charts use CSS classes
+var chartPalette = []string{"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f", "#edc948", "#b07aa1", "#ff9da7", "#9c755f"}
+
+// chartSeries is one named set of values, one value per label
+type chartSeries struct {
+	Name   string
+	Class  string // CSS class giving the color
+	Values []float64
+}
+
+// chartSlice is one part of a donut chart
+type chartSlice struct {
+	Label string
+	Value float64
+}
+
+// writeBarChart draws grouped bars, one group per label and one bar per series
+func writeBarChart(out *strings.Builder, labels []string, series []chartSeries) {
+	if len(labels) == 0 {
+		return
+	}
+
+	max := 0.0
+	for _, s := range series {
+		for _, v := range s.Values {
+			max = math.Max(max, v)
+		}
+	}
+	if max == 0 {
+		max = 1
+	}
+
+	plotHeight := chartHeight - chartTop - chartBottom
+	groupWidth := (chartWidth - chartLeft) / float64(len(labels))
+	barWidth := groupWidth * 0.8 / float64(len(series))
+
+	out.WriteString(fmt.Sprintf("<svg class='chart' viewBox='0 0 %.0f %.0f' role='img'>\n", chartWidth, chartHeight))
+	writeGrid(out, 0, max)
+	for g, label := range labels {
+		x := chartLeft + groupWidth*float64(g) + groupWidth*0.1
+		for _, s := range series {
+			v := math.Max(s.Values[g], 0)
+			h := plotHeight * v / max
+			out.WriteString(fmt.Sprintf("<rect class='%s' x='%.1f' y='%.1f' width='%.1f' height='%.1f'><title>%s %s: %.2f</title></rect>\n",
+				s.Class, x, chartTop+plotHeight-h, barWidth, h, html.EscapeString(label), html.EscapeString(s.Name), s.Values[g]))
+			x += barWidth
+		}
+		out.WriteString(fmt.Sprintf("<text class='axis' x='%.1f' y='%.1f' text-anchor='middle'>%s</text>\n",
+			chartLeft+groupWidth*(float64(g)+0.5), chartHeight-8, html.EscapeString(label)))
+	}
+	out.WriteString("</svg>\n")
+	writeLegend(out, series)
+}
+
+// writeLineChart draws one line per series, the value range may include negatives
+func writeLineChart(out *strings.Builder, labels []string, series []chartSeries) {
+	if len(labels) == 0 {
+		return
+	}
+
+	min, max := 0.0, 0.0
+	for _, s := range series {
+		for _, v := range s.Values {
+			min = math.Min(min, v)
+			max = math.Max(max, v)
+		}
+	}
+	if max == min {
+		max = min + 1
+	}
+
+	plotHeight := chartHeight - chartTop - chartBottom
+	step := (chartWidth - chartLeft) / float64(len(labels))
+	x := func(i int) float64 { return chartLeft + step*(float64(i)+0.5) }
+	y := func(v float64) float64 { return chartTop + plotHeight*(max-v)/(max-min) }
+
+	out.WriteString(fmt.Sprintf("<svg class='chart' viewBox='0 0 %.0f %.0f' role='img'>\n", chartWidth, chartHeight))
+	writeGrid(out, min, max)
+	for _, s := range series {
+		points := make([]string, len(s.Values))
+		for i, v := range s.Values {
+			points[i] = fmt.Sprintf("%.1f,%.1f", x(i), y(v))
+		}
+		out.WriteString(fmt.Sprintf("<polyline class='line %s' points='%s'/>\n", s.Class, strings.Join(points, " ")))
+		for i, v := range s.Values {
+			out.WriteString(fmt.Sprintf("<circle class='%s' cx='%.1f' cy='%.1f' r='3'><title>%s %s: %.2f</title></circle>\n",
+				s.Class, x(i), y(v), html.EscapeString(labels[i]), html.EscapeString(s.Name), v))
+		}
+	}
+	for i, label := range labels {
+		out.WriteString(fmt.Sprintf("<text class='axis' x='%.1f' y='%.1f' text-anchor='middle'>%s</text>\n",
+			x(i), chartHeight-8, html.EscapeString(label)))
+	}
+	out.WriteString("</svg>\n")
+	writeLegend(out, series)
+}
+
+// writeGrid draws horizontal guide lines with their values at min, the middle and max
+func writeGrid(out *strings.Builder, min, max float64) {
+	plotHeight := chartHeight - chartTop - chartBottom
+	for i := 0; i <= 2; i++ {
+		v := min + (max-min)*float64(i)/2
+		y := chartTop + plotHeight - plotHeight*float64(i)/2
+		out.WriteString(fmt.Sprintf("<line class='grid' x1='%.0f' y1='%.1f' x2='%.0f' y2='%.1f'/>\n", chartLeft, y, chartWidth, y))
+		out.WriteString(fmt.Sprintf("<text class='axis' x='%.0f' y='%.1f' text-anchor='end'>%.0f</text>\n", chartLeft-6, y+4, v))
+	}
+	if min < 0 && max > 0 {
+		y := chartTop + plotHeight*max/(max-min)
+		out.WriteString(fmt.Sprintf("<line class='zero' x1='%.0f' y1='%.1f' x2='%.0f' y2='%.1f'/>\n", chartLeft, y, chartWidth, y))
+	}
+}
+
+func writeLegend(out *strings.Builder, series []chartSeries) {
+	out.WriteString("<div class='legend'>")
+	for _, s := range series {
+		out.WriteString(fmt.Sprintf("<span><i class='%s'></i>%s</span>", s.Class, html.EscapeString(s.Name)))
+	}
+	out.WriteString("</div>\n")
+}
+
+// writeDonutChart draws slices as arcs of a circle with a circumference of 100
+func writeDonutChart(out *strings.Builder, slices []chartSlice, base string) {
+	total := 0.0
+	for _, s := range slices {
+		total += s.Value
+	}
+	if total <= 0 {
+		return
+	}
+
+	const radius = 15.915 // circumference of 100 so dash lengths are percentages
+	out.WriteString("<div class='donut'>\n")
+	out.WriteString("<svg viewBox='0 0 42 42' role='img'>\n")
+	offset := 25.0 // start at 12 o'clock
+	for i, s := range slices {
+		share := s.Value / total * 100
+		out.WriteString(fmt.Sprintf("<circle cx='21' cy='21' r='%.3f' fill='none' stroke='%s' stroke-width='6' stroke-dasharray='%.3f %.3f' stroke-dashoffset='%.3f'><title>%s: %.2f %s (%.1f%%)</title></circle>\n",
+			radius, chartPalette[i%len(chartPalette)], share, 100-share, offset, html.EscapeString(s.Label), s.Value, base, share))
+		offset -= share
+	}
+	out.WriteString(fmt.Sprintf("<text x='21' y='22' text-anchor='middle' class='donut-total'>%.0f</text>\n", total))
+	out.WriteString("</svg>\n<ul>\n")
+	for i, s := range slices {
+		out.WriteString(fmt.Sprintf("<li><i style='background:%s'></i>#%s <span>%.2f %s (%.1f%%)</span></li>\n",
+			chartPalette[i%len(chartPalette)], html.EscapeString(s.Label), s.Value, base, s.Value/total*100))
+	}
+	out.WriteString("</ul>\n</div>\n")
+}
+
+// expenseSlices returns top level expense categories, largest first
+// Categories after the first limit-1 are merged into a single "other" slice
+func expenseSlices(baseByCategory map[string]float64, limit int) []chartSlice {
+	var slices []chartSlice
+	for path, amount := range baseByCategory {
+		if strings.Contains(path, category.Separator) || amount >= 0 {
+			continue
+		}
+		slices = append(slices, chartSlice{Label: path, Value: -amount})
+	}
+	sort.Slice(slices, func(i, j int) bool {
+		if slices[i].Value != slices[j].Value {
+			return slices[i].Value > slices[j].Value
+		}
+		return slices[i].Label < slices[j].Label
+	})
+
+	if len(slices) > limit {
+		other := chartSlice{Label: "other"}
+		for _, s := range slices[limit-1:] {
+			other.Value += s.Value
+		}
+		slices = append(slices[:limit-1], other)
+	}
+	return slices
+}
@@ -2,16 +2,16 @@ package reports
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"spendgrid/internal/category"
+	"spendgrid/internal/config"
 	"spendgrid/internal/exchange"
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
 )
 
@@ -35,31 +35,46 @@ var (
 
 // MonthlyReport represents a monthly financial report
 type MonthlyReport struct {
-	Year            int
-	Month           int
-	Income          map[string]float64            // Completed transactions (by currency)
-	Expenses        map[string]float64            // Completed transactions (by currency)
-	PlannedIncome   map[string]float64            // Uncompleted rules (by currency)
-	PlannedExpenses map[string]float64            // Uncompleted rules (by currency)
-	ByCategory      map[string]map[string]float64 // category -> currency -> amount
-	ByProject       map[string]map[string]float64 // project -> currency -> amount
-	Transactions    []*parser.Transaction
-	PlannedTx       []*parser.Transaction // Uncompleted rules
+	Year                int                           `json:"year" yaml:"year"`
+	Month               int                           `json:"month" yaml:"month"`
+	Income              map[string]float64            `json:"income" yaml:"income"`                     // Completed transactions (by currency)
+	Expenses            map[string]float64            `json:"expenses" yaml:"expenses"`                 // Completed transactions (by currency)
+	PlannedIncome       map[string]float64            `json:"planned_income" yaml:"planned_income"`     // Uncompleted rules (by currency)
+	PlannedExpenses     map[string]float64            `json:"planned_expenses" yaml:"planned_expenses"` // Uncompleted rules (by currency)
+	ByCategory          map[string]map[string]float64 `json:"by_category" yaml:"by_category"`           // category path -> currency -> amount, at every level
+	BaseByCategory      map[string]float64            `json:"base_by_category" yaml:"base_by_category"` // category path -> amount in base currency
+	ByProject           map[string]map[string]float64 `json:"by_project" yaml:"by_project"`             // project -> currency -> amount
+	Transactions        []*parser.Transaction         `json:"transactions" yaml:"transactions"`
+	PlannedTx           []*parser.Transaction         `json:"planned_transactions" yaml:"planned_transactions"` // Uncompleted rules
+	BaseCurrency        string                        `json:"base_currency" yaml:"base_currency"`
+	BaseIncome          float64                       `json:"base_income" yaml:"base_income"`                     // Completed income in base currency
+	BaseExpenses        float64                       `json:"base_expenses" yaml:"base_expenses"`                 // Completed expenses in base currency
+	BasePlannedIncome   float64                       `json:"base_planned_income" yaml:"base_planned_income"`     // Planned income in base currency
+	BasePlannedExpenses float64                       `json:"base_planned_expenses" yaml:"base_planned_expenses"` // Planned expenses in base currency
+	MissingRates        []*parser.Transaction         `json:"missing_rates" yaml:"missing_rates"`                 // Rows that could not be converted
 }
 
 // YearlyReport represents a yearly financial report
 type YearlyReport struct {
-	Year          int
-	Months        []*MonthlyReport
-	TotalIncome   map[string]float64
-	TotalExpenses map[string]float64
-	NetByMonth    map[int]float64 // month -> net amount in base currency
+	Year              int                           `json:"year" yaml:"year"`
+	Months            []*MonthlyReport              `json:"months" yaml:"months"`
+	TotalIncome       map[string]float64            `json:"total_income" yaml:"total_income"`
+	TotalExpenses     map[string]float64            `json:"total_expenses" yaml:"total_expenses"`
+	NetByMonth        map[int]float64               `json:"net_by_month" yaml:"net_by_month"` // month -> net amount in base currency
+	BaseCurrency      string                        `json:"base_currency" yaml:"base_currency"`
+	BaseTotalIncome   float64                       `json:"base_total_income" yaml:"base_total_income"`
+	BaseTotalExpenses float64                       `json:"base_total_expenses" yaml:"base_total_expenses"`
+	ByCategory        map[string]map[string]float64 `json:"by_category" yaml:"by_category"`           // category path -> currency -> amount, at every level
+	BaseByCategory    map[string]float64            `json:"base_by_category" yaml:"base_by_category"` // category path -> amount in base currency
+	MissingRates      int                           `json:"missing_rates" yaml:"missing_rates"`       // Number of rows that could not be converted
 }
 
 // GenerateMonthlyReport generates a report for the current or specified month
-func GenerateMonthlyReport(month int) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+// depth limits the category levels shown, 0 shows all levels
+func GenerateMonthlyReport(month, depth int) error {
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
 	now := time.Now()
@@ -69,20 +84,52 @@ func GenerateMonthlyReport(month int) error {
 		month = int(now.Month())
 	}
 
-	// Parse month file
-	monthFile := parser.GetMonthFile(month)
-	yearDir := strconv.Itoa(year)
-	filePath := filepath.Join(yearDir, monthFile)
+	report, unparsed, err := BuildMonthlyReport(l, year, month)
+	if err != nil {
+		return err
+	}
+
+	// Print report
+	printMonthlyReport(report, unparsed, depth)
+
+	return nil
+}
+
+// LoadMonthlyReport builds the report of the current or specified month of this year
+func LoadMonthlyReport(l *ledger.Ledger, month int) (*MonthlyReport, error) {
+	if err := l.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if month == 0 {
+		month = int(now.Month())
+	}
+
+	report, _, err := BuildMonthlyReport(l, now.Year(), month)
+	return report, err
+}
 
-	content, err := os.ReadFile(filePath)
+// BuildMonthlyReport parses a month file and aggregates it into a report
+// Returns the report and the unparsed lines of the file
+func BuildMonthlyReport(l *ledger.Ledger, year, month int) (*MonthlyReport, []*parser.Transaction, error) {
+	// Parse month file
+	content, err := l.ReadFile(ledger.MonthPath(year, month))
 	if err != nil {
-		return fmt.Errorf("failed to read month file: %v", err)
+		return nil, nil, fmt.Errorf("failed to read month file: %v", err)
 	}
 
 	parsed, unparsed := parser.ParseMonthFile(string(content))
 
-	// Generate report
-	report := &MonthlyReport{
+	report := newMonthlyReport(year, month, config.LedgerBaseCurrency(l))
+	report.aggregate(parsed, loadCategoryTree(l))
+
+	return report, unparsed, nil
+}
+
+// newMonthlyReport returns an empty monthly report
+func newMonthlyReport(year, month int, base string) *MonthlyReport {
+	return &MonthlyReport{
 		Year:            year,
 		Month:           month,
 		Income:          make(map[string]float64),
@@ -90,21 +137,37 @@ func GenerateMonthlyReport(month int) error {
 		PlannedIncome:   make(map[string]float64),
 		PlannedExpenses: make(map[string]float64),
 		ByCategory:      make(map[string]map[string]float64),
+		BaseByCategory:  make(map[string]float64),
 		ByProject:       make(map[string]map[string]float64),
-		Transactions:    parsed,
+		Transactions:    make([]*parser.Transaction, 0),
 		PlannedTx:       make([]*parser.Transaction, 0),
+		BaseCurrency:    base,
+		MissingRates:    make([]*parser.Transaction, 0),
 	}
+}
+
+// aggregate adds rows to the report, uncompleted rules are counted as planned
+func (report *MonthlyReport) aggregate(parsed []*parser.Transaction, tree *category.Tree) {
+	year, month := report.Year, report.Month
+	report.Transactions = append(report.Transactions, parsed...)
 
 	// Aggregate data - separate completed vs planned
 	for _, tx := range parsed {
+		inBase, ok := convertToBase(tx, year, month, report.BaseCurrency)
+		if !ok {
+			report.MissingRates = append(report.MissingRates, tx)
+		}
+
 		// Skip uncompleted rules for main totals
 		if tx.IsRule && !tx.Completed {
 			// This is a planned transaction
 			report.PlannedTx = append(report.PlannedTx, tx)
 			if tx.IsIncome() {
 				report.PlannedIncome[tx.Currency] += tx.Amount
+				report.BasePlannedIncome += inBase
 			} else {
 				report.PlannedExpenses[tx.Currency] += -tx.Amount
+				report.BasePlannedExpenses += -inBase
 			}
 			continue
 		}
@@ -112,267 +175,150 @@ func GenerateMonthlyReport(month int) error {
 		// Completed transactions and non-rule transactions
 		if tx.IsIncome() {
 			report.Income[tx.Currency] += tx.Amount
+			report.BaseIncome += inBase
 		} else {
 			report.Expenses[tx.Currency] += -tx.Amount
+			report.BaseExpenses += -inBase
 		}
 
-		// By category
-		for _, tag := range tx.Tags {
-			if report.ByCategory[tag] == nil {
-				report.ByCategory[tag] = make(map[string]float64)
-			}
-			if tx.IsIncome() {
-				report.ByCategory[tag][tx.Currency] += tx.Amount
-			} else {
-				report.ByCategory[tag][tx.Currency] += tx.Amount
-			}
-		}
+		// By category, rolled up to every parent
+		addToCategories(report.ByCategory, report.BaseByCategory, tree, tx, inBase)
 
 		// By project
 		for _, proj := range tx.Projects {
 			if report.ByProject[proj] == nil {
 				report.ByProject[proj] = make(map[string]float64)
 			}
-			if tx.IsIncome() {
-				report.ByProject[proj][tx.Currency] += tx.Amount
-			} else {
-				report.ByProject[proj][tx.Currency] += tx.Amount
-			}
+			report.ByProject[proj][tx.Currency] += tx.Amount
 		}
 	}
-
-	// Print report
-	printMonthlyReport(report, unparsed)
-
-	return nil
 }
 
-// GenerateYearlyReport generates a report for the entire year
-func GenerateYearlyReport() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+// convertToBase converts a row to the base currency at its date, or at its @rate
+// Returns false if no exchange rate was available
+func convertToBase(tx *parser.Transaction, year, month int, base string) (float64, bool) {
+	date := time.Date(year, time.Month(month), tx.Day, 0, 0, 0, 0, time.UTC)
+	amount, err := exchange.ConvertTransaction(tx, base, date)
+	if err != nil {
+		return 0, false
 	}
+	return amount, true
+}
 
-	now := time.Now()
-	year := now.Year()
-	yearDir := strconv.Itoa(year)
-
-	report := &YearlyReport{
-		Year:          year,
-		Months:        make([]*MonthlyReport, 0),
-		TotalIncome:   make(map[string]float64),
-		TotalExpenses: make(map[string]float64),
-		NetByMonth:    make(map[int]float64),
+// GenerateYearlyReport generates a report for the entire year
+// depth limits the category levels shown, 0 shows all levels
+func GenerateYearlyReport(depth int) error {
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
 	}
 
-	// Parse all months
-	for month := 1; month <= 12; month++ {
-		monthFile := parser.GetMonthFile(month)
-		filePath := filepath.Join(yearDir, monthFile)
-
-		content, err := os.ReadFile(filePath)
-		if err != nil {
-			continue // Skip if file doesn't exist
-		}
-
-		parsed, _ := parser.ParseMonthFile(string(content))
-
-		monthly := &MonthlyReport{
-			Year:         year,
-			Month:        month,
-			Income:       make(map[string]float64),
-			Expenses:     make(map[string]float64),
-			ByCategory:   make(map[string]map[string]float64),
-			ByProject:    make(map[string]map[string]float64),
-			Transactions: parsed,
-		}
-
-		// Aggregate data
-		for _, tx := range parsed {
-			if tx.IsIncome() {
-				monthly.Income[tx.Currency] += tx.Amount
-				report.TotalIncome[tx.Currency] += tx.Amount
-			} else {
-				monthly.Expenses[tx.Currency] += -tx.Amount
-				report.TotalExpenses[tx.Currency] += -tx.Amount
-			}
-		}
-
-		report.Months = append(report.Months, monthly)
-	}
+	report := BuildYearlyReport(l, time.Now().Year())
 
 	// Print report
-	printYearlyReport(report)
+	printYearlyReport(report, depth)
 
 	return nil
 }
 
-// GenerateHTMLReport generates an HTML report
-func GenerateHTMLReport(year bool) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+// LoadYearlyReport builds the report of the current year
+func LoadYearlyReport(l *ledger.Ledger) (*YearlyReport, error) {
+	if err := l.EnsureInitialized(); err != nil {
+		return nil, err
 	}
 
-	now := time.Now()
-	dateStr := now.Format("2006_01_02")
-	filename := fmt.Sprintf("report_%s.html", dateStr)
-	filePath := filepath.Join("_share", filename)
-
-	// Generate HTML content
-	var html strings.Builder
-	html.WriteString("<!DOCTYPE html>\n")
-	html.WriteString("<html>\n<head>\n")
-	html.WriteString("<meta charset='UTF-8'>\n")
-	html.WriteString("<title>SpendGrid Report</title>\n")
-	html.WriteString("<style>\n")
-	html.WriteString("body { font-family: Arial, sans-serif; margin: 40px; }\n")
-	html.WriteString("table { border-collapse: collapse; width: 100%; margin: 20px 0; }\n")
-	html.WriteString("th, td { border: 1px solid #ddd; padding: 12px; text-align: left; }\n")
-	html.WriteString("th { background-color: #4CAF50; color: white; }\n")
-	html.WriteString("tr:nth-child(even) { background-color: #f2f2f2; }\n")
-	html.WriteString(".income { color: green; }\n")
-	html.WriteString(".expense { color: red; }\n")
-	html.WriteString(".summary { font-weight: bold; font-size: 1.2em; margin: 20px 0; }\n")
-	html.WriteString("</style>\n")
-	html.WriteString("</head>\n<body>\n")
-	html.WriteString("<h1>SpendGrid Financial Report</h1>\n")
-	html.WriteString(fmt.Sprintf("<p>Generated: %s</p>\n", now.Format("2006-01-02 15:04:05")))
-
-	if year {
-		// Yearly report HTML
-		yearly := &YearlyReport{
-			Year:          now.Year(),
-			Months:        make([]*MonthlyReport, 0),
-			TotalIncome:   make(map[string]float64),
-			TotalExpenses: make(map[string]float64),
-		}
-
-		yearDir := strconv.Itoa(now.Year())
-		for month := 1; month <= 12; month++ {
-			monthFile := parser.GetMonthFile(month)
-			filePath := filepath.Join(yearDir, monthFile)
+	return BuildYearlyReport(l, time.Now().Year()), nil
+}
 
-			content, err := os.ReadFile(filePath)
-			if err != nil {
-				continue
-			}
+// BuildYearlyReport aggregates all month files of a year into a report
+func BuildYearlyReport(l *ledger.Ledger, year int) *YearlyReport {
+	report := newYearlyReport(year, config.LedgerBaseCurrency(l))
 
-			parsed, _ := parser.ParseMonthFile(string(content))
-			monthly := &MonthlyReport{
-				Year:         now.Year(),
-				Month:        month,
-				Income:       make(map[string]float64),
-				Expenses:     make(map[string]float64),
-				Transactions: parsed,
-			}
+	tree := loadCategoryTree(l)
 
-			for _, tx := range parsed {
-				if tx.IsIncome() {
-					monthly.Income[tx.Currency] += tx.Amount
-					yearly.TotalIncome[tx.Currency] += tx.Amount
-				} else {
-					monthly.Expenses[tx.Currency] += -tx.Amount
-					yearly.TotalExpenses[tx.Currency] += -tx.Amount
-				}
-			}
-			yearly.Months = append(yearly.Months, monthly)
+	// Parse all months
+	for month := 1; month <= 12; month++ {
+		content, err := l.ReadFile(ledger.MonthPath(year, month))
+		if err != nil {
+			continue // Skip if file doesn't exist
 		}
 
-		// Monthly summary table
-		html.WriteString("<h2>Monthly Summary</h2>\n")
-		html.WriteString("<table>\n")
-		html.WriteString("<tr><th>Month</th><th>Income</th><th>Expenses</th><th>Net</th></tr>\n")
-
-		for _, m := range yearly.Months {
-			totalIncome := 0.0
-			totalExpense := 0.0
-
-			for _, amt := range m.Income {
-				totalIncome += amt
-			}
-			for _, amt := range m.Expenses {
-				totalExpense += amt
-			}
+		parsed, _ := parser.ParseMonthFile(string(content))
+		report.addMonth(month, parsed, tree)
+	}
 
-			net := totalIncome - totalExpense
-			monthName := time.Month(m.Month).String()
+	return report
+}
 
-			html.WriteString(fmt.Sprintf("<tr><td>%s</td><td class='income'>%.2f</td><td class='expense'>%.2f</td><td>%.2f</td></tr>\n",
-				monthName, totalIncome, totalExpense, net))
-		}
-		html.WriteString("</table>\n")
+// newYearlyReport returns an empty yearly report
+func newYearlyReport(year int, base string) *YearlyReport {
+	return &YearlyReport{
+		Year:           year,
+		Months:         make([]*MonthlyReport, 0),
+		TotalIncome:    make(map[string]float64),
+		TotalExpenses:  make(map[string]float64),
+		NetByMonth:     make(map[int]float64),
+		BaseCurrency:   base,
+		ByCategory:     make(map[string]map[string]float64),
+		BaseByCategory: make(map[string]float64),
+	}
+}
 
-		// Yearly totals
-		html.WriteString("<div class='summary'>\n")
-		html.WriteString("<h2>Yearly Totals</h2>\n")
+// addMonth aggregates the rows of a month into the yearly report
+func (report *YearlyReport) addMonth(month int, parsed []*parser.Transaction, tree *category.Tree) {
+	year := report.Year
+	monthly := &MonthlyReport{
+		Year:           year,
+		Month:          month,
+		Income:         make(map[string]float64),
+		Expenses:       make(map[string]float64),
+		ByCategory:     make(map[string]map[string]float64),
+		BaseByCategory: make(map[string]float64),
+		ByProject:      make(map[string]map[string]float64),
+		Transactions:   parsed,
+		BaseCurrency:   report.BaseCurrency,
+		MissingRates:   make([]*parser.Transaction, 0),
+	}
 
-		totalInc := 0.0
-		totalExp := 0.0
-		for _, amt := range yearly.TotalIncome {
-			totalInc += amt
-		}
-		for _, amt := range yearly.TotalExpenses {
-			totalExp += amt
+	// Aggregate data
+	for _, tx := range parsed {
+		inBase, ok := convertToBase(tx, year, month, report.BaseCurrency)
+		if !ok {
+			monthly.MissingRates = append(monthly.MissingRates, tx)
+			report.MissingRates++
 		}
 
-		html.WriteString(fmt.Sprintf("<p class='income'>Total Income: %.2f</p>\n", totalInc))
-		html.WriteString(fmt.Sprintf("<p class='expense'>Total Expenses: %.2f</p>\n", totalExp))
-		html.WriteString(fmt.Sprintf("<p>Net: %.2f</p>\n", totalInc-totalExp))
-		html.WriteString("</div>\n")
-	} else {
-		// Monthly report HTML
-		month := int(now.Month())
-		monthFile := parser.GetMonthFile(month)
-		yearDir := strconv.Itoa(now.Year())
-		filePath := filepath.Join(yearDir, monthFile)
-
-		content, err := os.ReadFile(filePath)
-		if err == nil {
-			parsed, _ := parser.ParseMonthFile(string(content))
-
-			html.WriteString(fmt.Sprintf("<h2>Transactions for %s %d</h2>\n", time.Month(month), now.Year()))
-			html.WriteString("<table>\n")
-			html.WriteString("<tr><th>Day</th><th>Description</th><th>Amount</th><th>Currency</th><th>Tags</th></tr>\n")
-
-			for _, tx := range parsed {
-				tags := strings.Join(tx.Tags, ", ")
-				class := "expense"
-				if tx.IsIncome() {
-					class = "income"
-				}
-				html.WriteString(fmt.Sprintf("<tr><td>%d</td><td>%s</td><td class='%s'>%.2f</td><td>%s</td><td>%s</td></tr>\n",
-					tx.Day, tx.Description, class, tx.Amount, tx.Currency, tags))
-			}
-			html.WriteString("</table>\n")
+		if tx.IsIncome() {
+			monthly.Income[tx.Currency] += tx.Amount
+			report.TotalIncome[tx.Currency] += tx.Amount
+			monthly.BaseIncome += inBase
+		} else {
+			monthly.Expenses[tx.Currency] += -tx.Amount
+			report.TotalExpenses[tx.Currency] += -tx.Amount
+			monthly.BaseExpenses += -inBase
 		}
-	}
-
-	html.WriteString("</body>\n</html>")
 
-	// Write to file
-	if err := os.WriteFile(filePath, []byte(html.String()), 0644); err != nil {
-		return fmt.Errorf("failed to write HTML report: %v", err)
+		addToCategories(monthly.ByCategory, monthly.BaseByCategory, tree, tx, inBase)
+		addToCategories(report.ByCategory, report.BaseByCategory, tree, tx, inBase)
 	}
 
-	fmt.Printf("HTML report generated: %s\n", filePath)
-	return nil
+	report.BaseTotalIncome += monthly.BaseIncome
+	report.BaseTotalExpenses += monthly.BaseExpenses
+	report.NetByMonth[month] = monthly.BaseIncome - monthly.BaseExpenses
+	report.Months = append(report.Months, monthly)
 }
 
-func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
+func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction, depth int) {
+	base := report.BaseCurrency
+
 	// Header
 	fmt.Printf("\n%s %s %d\n", i18n.T("reports.monthly_title"), time.Month(report.Month), report.Year)
 	fmt.Println(strings.Repeat("=", 70))
 
-	// Calculate totals in base currency (TRY)
-	date := time.Date(report.Year, time.Month(report.Month), 1, 0, 0, 0, 0, time.UTC)
-
 	// ========== GERÇEKLEŞEN ==========
 	fmt.Printf("\n📊 %s\n", "GERÇEKLEŞEN")
 	fmt.Println(strings.Repeat("-", 70))
 
-	totalIncome := 0.0
-	totalExpense := 0.0
-
 	// Print by currency
 	fmt.Printf("%-20s %15s %15s\n", "Currency", "Income", "Expense")
 	fmt.Println(strings.Repeat("-", 70))
@@ -382,13 +328,6 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 		inc := report.Income[curr]
 		exp := report.Expenses[curr]
 
-		// Convert to base currency
-		incInBase, _ := exchange.ConvertAmount(inc, curr, "TRY", date)
-		expInBase, _ := exchange.ConvertAmount(exp, curr, "TRY", date)
-
-		totalIncome += incInBase
-		totalExpense += expInBase
-
 		// Renkli yazdırma - Soft renkler
 		incomeStr := softGreen.Sprintf("%15.2f", inc)
 		expenseStr := softRed.Sprintf("%15.2f", exp)
@@ -397,14 +336,14 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 
 	fmt.Println(strings.Repeat("-", 70))
 
-	// TOTAL satırı
-	totalIncomeStr := softGreen.Sprintf("%15.2f", totalIncome)
-	totalExpenseStr := softRed.Sprintf("%15.2f", totalExpense)
-	whiteBold.Printf("%-20s ", "TOTAL (TRY)")
+	// TOTAL satırı (base currency)
+	totalIncomeStr := softGreen.Sprintf("%15.2f", report.BaseIncome)
+	totalExpenseStr := softRed.Sprintf("%15.2f", report.BaseExpenses)
+	whiteBold.Printf("%-20s ", fmt.Sprintf("TOTAL (%s)", base))
 	fmt.Printf("%s %s\n", totalIncomeStr, totalExpenseStr)
 
 	// NET satırı
-	net := totalIncome - totalExpense
+	net := report.BaseIncome - report.BaseExpenses
 	whiteBold.Printf("%-20s ", "NET")
 	if net >= 0 {
 		fmt.Printf("%s\n", strongGreen.Sprintf("%15.2f", net))
@@ -418,22 +357,12 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 		fmt.Printf("\n📅 %s\n", "PLANLANAN (Tamamlanmamış Rule'lar)")
 		fmt.Println(strings.Repeat("-", 70))
 
-		plannedIncomeTotal := 0.0
-		plannedExpenseTotal := 0.0
-
 		// Print planned by currency
 		allPlannedCurrencies := getAllCurrencies(report.PlannedIncome, report.PlannedExpenses)
 		for _, curr := range allPlannedCurrencies {
 			inc := report.PlannedIncome[curr]
 			exp := report.PlannedExpenses[curr]
 
-			// Convert to base currency
-			incInBase, _ := exchange.ConvertAmount(inc, curr, "TRY", date)
-			expInBase, _ := exchange.ConvertAmount(exp, curr, "TRY", date)
-
-			plannedIncomeTotal += incInBase
-			plannedExpenseTotal += expInBase
-
 			incomeStr := softGreen.Sprintf("%15.2f", inc)
 			expenseStr := softRed.Sprintf("%15.2f", exp)
 			fmt.Printf("%-20s %s %s\n", curr, incomeStr, expenseStr)
@@ -441,9 +370,9 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 
 		fmt.Println(strings.Repeat("-", 70))
 
-		plannedIncomeStr := softGreen.Sprintf("%15.2f", plannedIncomeTotal)
-		plannedExpenseStr := softRed.Sprintf("%15.2f", plannedExpenseTotal)
-		whiteBold.Printf("%-20s ", "PLANNED TOTAL")
+		plannedIncomeStr := softGreen.Sprintf("%15.2f", report.BasePlannedIncome)
+		plannedExpenseStr := softRed.Sprintf("%15.2f", report.BasePlannedExpenses)
+		whiteBold.Printf("%-20s ", fmt.Sprintf("PLANNED (%s)", base))
 		fmt.Printf("%s %s\n", plannedIncomeStr, plannedExpenseStr)
 
 		// List planned transactions
@@ -461,8 +390,8 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 		fmt.Printf("\n🔮 %s\n", "PROJEKSİYON (Gerçekleşen + Planlanan)")
 		fmt.Println(strings.Repeat("-", 70))
 
-		projectedIncome := totalIncome + plannedIncomeTotal
-		projectedExpense := totalExpense + plannedExpenseTotal
+		projectedIncome := report.BaseIncome + report.BasePlannedIncome
+		projectedExpense := report.BaseExpenses + report.BasePlannedExpenses
 		projectedNet := projectedIncome - projectedExpense
 
 		projIncStr := softGreen.Sprintf("%15.2f", projectedIncome)
@@ -482,17 +411,12 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 	if len(report.ByCategory) > 0 {
 		fmt.Printf("\n%s\n", i18n.T("reports.by_category"))
 		fmt.Println(strings.Repeat("-", 70))
-
-		categories := getSortedKeys(report.ByCategory)
-		for _, cat := range categories {
-			fmt.Printf("#%-19s", cat)
-			for curr, amt := range report.ByCategory[cat] {
-				fmt.Printf(" %10.2f %s", amt, curr)
-			}
-			fmt.Println()
-		}
+		printCategoryTree(report.ByCategory, report.BaseByCategory, base, depth)
 	}
 
+	// Rows without exchange rate
+	printMissingRates(report.MissingRates, base)
+
 	// Unparsed transactions
 	if len(unparsed) > 0 {
 		fmt.Printf("\n%s\n", i18n.T("transaction.unparsed_header"))
@@ -505,33 +429,39 @@ func printMonthlyReport(report *MonthlyReport, unparsed []*parser.Transaction) {
 	fmt.Println()
 }
 
-func printYearlyReport(report *YearlyReport) {
+// printMissingRates lists rows that are excluded from base currency totals
+func printMissingRates(missing []*parser.Transaction, base string) {
+	if len(missing) == 0 {
+		return
+	}
+
+	fmt.Printf("\n⚠️  %s (%s)\n", i18n.T("reports.missing_rates"), base)
+	fmt.Println(strings.Repeat("-", 70))
+	for _, tx := range missing {
+		fmt.Printf("  Line %d: %02d | %-30s | %.2f %s\n",
+			tx.LineNumber, tx.Day, tx.Description, tx.Amount, tx.Currency)
+	}
+}
+
+func printYearlyReport(report *YearlyReport, depth int) {
+	base := report.BaseCurrency
+
 	// Header
 	fmt.Printf("\n%s %d\n", i18n.T("reports.yearly_title"), report.Year)
 	fmt.Println(strings.Repeat("=", 70))
 
 	// Monthly breakdown
-	fmt.Printf("\n%s\n", i18n.T("reports.monthly_breakdown"))
+	fmt.Printf("\n%s (%s)\n", i18n.T("reports.monthly_breakdown"), base)
 	fmt.Println(strings.Repeat("-", 70))
 	fmt.Printf("%-10s %15s %15s %15s\n", "Month", "Income", "Expense", "Net")
 	fmt.Println(strings.Repeat("-", 70))
 
 	for _, m := range report.Months {
-		totalIncome := 0.0
-		totalExpense := 0.0
-
-		for _, amt := range m.Income {
-			totalIncome += amt
-		}
-		for _, amt := range m.Expenses {
-			totalExpense += amt
-		}
-
-		net := totalIncome - totalExpense
+		net := report.NetByMonth[m.Month]
 
 		// Soft renklerle income ve expense
-		incomeStr := softGreen.Sprintf("%15.2f", totalIncome)
-		expenseStr := softRed.Sprintf("%15.2f", totalExpense)
+		incomeStr := softGreen.Sprintf("%15.2f", m.BaseIncome)
+		expenseStr := softRed.Sprintf("%15.2f", m.BaseExpenses)
 
 		// Net için güçlü renkler
 		var netStr string
@@ -541,31 +471,27 @@ func printYearlyReport(report *YearlyReport) {
 			netStr = strongRed.Sprintf("%15.2f", net)
 		}
 
-		fmt.Printf("%-10s %s %s %s\n",
-			time.Month(m.Month).String(), incomeStr, expenseStr, netStr)
+		flag := ""
+		if len(m.MissingRates) > 0 {
+			flag = " ⚠"
+		}
+
+		fmt.Printf("%-10s %s %s %s%s\n",
+			time.Month(m.Month).String(), incomeStr, expenseStr, netStr, flag)
 	}
 
 	// Yearly totals - Background ile vurgulanmış
 	fmt.Println(strings.Repeat("-", 70))
 
-	totalInc := 0.0
-	totalExp := 0.0
-	for _, amt := range report.TotalIncome {
-		totalInc += amt
-	}
-	for _, amt := range report.TotalExpenses {
-		totalExp += amt
-	}
-
 	// TOTAL satırı - Sarı background ile vurgulu + renkli rakamlar
-	netTotal := totalInc - totalExp
+	netTotal := report.BaseTotalIncome - report.BaseTotalExpenses
 
 	// Background başlat
 	bgYellow.Printf("%-10s", "TOTAL")
 
 	// Renkli rakamlar (background'ın üzerinde)
-	fmt.Printf(" %s", softGreen.Sprintf("%15.2f", totalInc))
-	fmt.Printf(" %s", softRed.Sprintf("%15.2f", totalExp))
+	fmt.Printf(" %s", softGreen.Sprintf("%15.2f", report.BaseTotalIncome))
+	fmt.Printf(" %s", softRed.Sprintf("%15.2f", report.BaseTotalExpenses))
 
 	// Net total (güçlü renklerle)
 	if netTotal >= 0 {
@@ -592,6 +518,18 @@ func printYearlyReport(report *YearlyReport) {
 		fmt.Printf("%-15s %s %s\n", curr, incomeStr, expenseStr)
 	}
 
+	// By category
+	if len(report.ByCategory) > 0 {
+		fmt.Printf("\n%s\n", i18n.T("reports.by_category"))
+		fmt.Println(strings.Repeat("-", 70))
+		printCategoryTree(report.ByCategory, report.BaseByCategory, base, depth)
+	}
+
+	if report.MissingRates > 0 {
+		fmt.Println()
+		color.Yellow("⚠ %d row(s) had no exchange rate and are excluded from %s totals", report.MissingRates, base)
+	}
+
 	fmt.Println()
 }
 
@@ -0,0 +1,424 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+	"spendgrid/internal/parser"
+)
+
+// DefaultTransferTag marks rows that move money between household ledgers
+const DefaultTransferTag = "transfer"
+
+// transferMetaKey names the other ledger of a transfer, e.g. [TRANSFER:work]
+const transferMetaKey = "TRANSFER"
+
+// HouseholdSource is a ledger taking part in a household report
+type HouseholdSource struct {
+	Name   string
+	Ledger *ledger.Ledger
+}
+
+// HouseholdOptions controls how ledgers are combined
+type HouseholdOptions struct {
+	BaseCurrency string   // Currency of the combined totals
+	TransferTags []string // Rows with one of these tags may be transfers between the ledgers
+	MaxDays      int      // Days a transfer may take to show up in the other ledger
+}
+
+// HouseholdRow is a row together with the ledger it comes from
+type HouseholdRow struct {
+	Ledger string              `json:"ledger" yaml:"ledger"`
+	Date   string              `json:"date" yaml:"date"`
+	Tx     *parser.Transaction `json:"transaction" yaml:"transaction"`
+	date   time.Time
+	nearby bool // Read from just outside the period, only to match transfers
+}
+
+// Transfer is a pair of rows moving money from one household ledger to another
+// Both rows are left out of the combined report
+type Transfer struct {
+	From     string        `json:"from" yaml:"from"`
+	To       string        `json:"to" yaml:"to"`
+	Date     string        `json:"date" yaml:"date"`
+	Amount   float64       `json:"amount" yaml:"amount"`
+	Currency string        `json:"currency" yaml:"currency"`
+	Out      *HouseholdRow `json:"out" yaml:"out"`
+	In       *HouseholdRow `json:"in" yaml:"in"`
+}
+
+// LedgerTotals are the base currency amounts one ledger adds to the household
+type LedgerTotals struct {
+	Income       float64 `json:"income" yaml:"income"`
+	Expenses     float64 `json:"expenses" yaml:"expenses"`
+	Net          float64 `json:"net" yaml:"net"`
+	TransfersOut float64 `json:"transfers_out" yaml:"transfers_out"`
+	TransfersIn  float64 `json:"transfers_in" yaml:"transfers_in"`
+}
+
+// HouseholdMonthlyReport combines a month of several ledgers
+type HouseholdMonthlyReport struct {
+	Ledgers            []string                 `json:"ledgers" yaml:"ledgers"`
+	Report             *MonthlyReport           `json:"report" yaml:"report"`
+	ByLedger           map[string]*LedgerTotals `json:"by_ledger" yaml:"by_ledger"`
+	Rows               []*HouseholdRow          `json:"rows" yaml:"rows"` // Rows of the combined report with their ledger
+	Transfers          []*Transfer              `json:"transfers" yaml:"transfers"`
+	UnmatchedTransfers []*HouseholdRow          `json:"unmatched_transfers" yaml:"unmatched_transfers"` // Kept in the totals
+}
+
+// HouseholdYearlyReport combines a year of several ledgers
+type HouseholdYearlyReport struct {
+	Ledgers            []string                 `json:"ledgers" yaml:"ledgers"`
+	Report             *YearlyReport            `json:"report" yaml:"report"`
+	ByLedger           map[string]*LedgerTotals `json:"by_ledger" yaml:"by_ledger"`
+	Transfers          []*Transfer              `json:"transfers" yaml:"transfers"`
+	UnmatchedTransfers []*HouseholdRow          `json:"unmatched_transfers" yaml:"unmatched_transfers"` // Kept in the totals
+}
+
+// household holds the rows of all sources for a period
+type household struct {
+	opts      HouseholdOptions
+	planned   bool // Count uncompleted rules in the ledger totals, as yearly reports do
+	ledgers   []string
+	rows      []*HouseholdRow
+	transfers []*Transfer
+	unmatched []*HouseholdRow
+	removed   map[*HouseholdRow]bool
+	byLedger  map[string]*LedgerTotals
+}
+
+// loadHousehold reads the given months of every source and pairs up transfers
+// Every month within MaxDays of the period is read too, so a transfer whose
+// other half falls across the edge of the period is still matched
+// Returns the months that exist in at least one ledger
+func loadHousehold(sources []HouseholdSource, year int, months []int, opts HouseholdOptions, planned bool) (*household, []int, error) {
+	if len(sources) == 0 {
+		return nil, nil, fmt.Errorf("no ledgers to combine")
+	}
+	if len(opts.TransferTags) == 0 {
+		opts.TransferTags = []string{DefaultTransferTag}
+	}
+
+	h := &household{
+		opts:     opts,
+		planned:  planned,
+		removed:  make(map[*HouseholdRow]bool),
+		byLedger: make(map[string]*LedgerTotals),
+	}
+
+	start := time.Date(year, time.Month(months[0]), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.Month(months[len(months)-1])+1, 1, 0, 0, 0, 0, time.UTC)
+	from, to := start.AddDate(0, 0, -opts.MaxDays), end.AddDate(0, 0, opts.MaxDays)
+
+	present := make(map[int]bool)
+	for _, src := range sources {
+		if _, ok := h.byLedger[src.Name]; ok {
+			return nil, nil, fmt.Errorf("ledger '%s' is listed twice", src.Name)
+		}
+		if err := src.Ledger.EnsureInitialized(); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", src.Name, err)
+		}
+		h.ledgers = append(h.ledgers, src.Name)
+		h.byLedger[src.Name] = &LedgerTotals{}
+
+		for _, month := range months {
+			found, err := h.load(src, year, month, start, end, false)
+			if err != nil {
+				return nil, nil, err
+			}
+			if found {
+				present[month] = true
+			}
+		}
+		if opts.MaxDays > 0 {
+			for m := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); m.Before(start); m = m.AddDate(0, 1, 0) {
+				if _, err := h.load(src, m.Year(), int(m.Month()), from, start, true); err != nil {
+					return nil, nil, err
+				}
+			}
+			for m := end; m.Before(to); m = m.AddDate(0, 1, 0) {
+				if _, err := h.load(src, m.Year(), int(m.Month()), end, to, true); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(h.rows, func(i, j int) bool { return h.rows[i].date.Before(h.rows[j].date) })
+	h.matchTransfers()
+	h.addTotals()
+
+	var found []int
+	for _, month := range months {
+		if present[month] {
+			found = append(found, month)
+		}
+	}
+	return h, found, nil
+}
+
+// load adds the rows of a month of a source dated from (inclusive) to (exclusive)
+// Returns false if the source has no such month file
+func (h *household) load(src HouseholdSource, year, month int, from, to time.Time, nearby bool) (bool, error) {
+	if !src.Ledger.Exists(ledger.MonthPath(year, month)) {
+		return false, nil
+	}
+
+	parsed, _, err := src.Ledger.LoadMonth(year, month)
+	if err != nil {
+		return false, fmt.Errorf("%s: %v", src.Name, err)
+	}
+	for _, tx := range parsed {
+		date := time.Date(year, time.Month(month), tx.Day, 0, 0, 0, 0, time.UTC)
+		if date.Before(from) || !date.Before(to) {
+			continue
+		}
+		h.rows = append(h.rows, &HouseholdRow{Ledger: src.Name, Date: date.Format("2006-01-02"), Tx: tx, date: date, nearby: nearby})
+	}
+	return true, nil
+}
+
+// isTransfer returns true if a row is marked as a transfer between ledgers
+func (h *household) isTransfer(tx *parser.Transaction) bool {
+	if tx.IsRule && !tx.Completed {
+		return false
+	}
+	if _, ok := tx.Meta[transferMetaKey]; ok {
+		return true
+	}
+	for _, tag := range tx.Tags {
+		for _, t := range h.opts.TransferTags {
+			if strings.EqualFold(tag, t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accepts returns true if a transfer row allows the other ledger as its counterpart
+func accepts(row *HouseholdRow, other string) bool {
+	target := strings.TrimSpace(row.Tx.Meta[transferMetaKey])
+	return target == "" || target == other
+}
+
+// matchTransfers pairs outgoing and incoming transfer rows of different ledgers
+// with the same amount and currency, preferring the closest dates
+func (h *household) matchTransfers() {
+	var candidates []*HouseholdRow
+	for _, row := range h.rows {
+		if h.isTransfer(row.Tx) {
+			candidates = append(candidates, row)
+		}
+	}
+
+	used := make(map[*HouseholdRow]bool)
+	for _, out := range candidates {
+		if !out.Tx.IsExpense() || used[out] {
+			continue
+		}
+
+		var best *HouseholdRow
+		bestDays := 0.0
+		for _, in := range candidates {
+			if !in.Tx.IsIncome() || used[in] || in.Ledger == out.Ledger {
+				continue
+			}
+			if in.Tx.Currency != out.Tx.Currency || math.Abs(in.Tx.Amount+out.Tx.Amount) > 0.005 {
+				continue
+			}
+			if !accepts(out, in.Ledger) || !accepts(in, out.Ledger) {
+				continue
+			}
+			days := math.Abs(in.date.Sub(out.date).Hours() / 24)
+			if days > float64(h.opts.MaxDays) {
+				continue
+			}
+			if best == nil || days < bestDays {
+				best, bestDays = in, days
+			}
+		}
+
+		if best == nil {
+			continue
+		}
+		used[out], used[best] = true, true
+		// A pair entirely outside the period belongs to the neighbouring report
+		if out.nearby && best.nearby {
+			continue
+		}
+		h.removed[out], h.removed[best] = true, true
+		h.transfers = append(h.transfers, &Transfer{
+			From:     out.Ledger,
+			To:       best.Ledger,
+			Date:     out.Date,
+			Amount:   best.Tx.Amount,
+			Currency: best.Tx.Currency,
+			Out:      out,
+			In:       best,
+		})
+	}
+
+	for _, row := range candidates {
+		if !used[row] && !row.nearby {
+			h.unmatched = append(h.unmatched, row)
+		}
+	}
+}
+
+// addTotals adds every row to the totals of its ledger
+func (h *household) addTotals() {
+	for _, row := range h.rows {
+		if row.nearby || (row.Tx.IsRule && !row.Tx.Completed && !h.planned) {
+			continue
+		}
+
+		inBase, _ := convertToBase(row.Tx, row.date.Year(), int(row.date.Month()), h.opts.BaseCurrency)
+		totals := h.byLedger[row.Ledger]
+		switch {
+		case h.removed[row] && row.Tx.IsIncome():
+			totals.TransfersIn += inBase
+		case h.removed[row]:
+			totals.TransfersOut += -inBase
+		case row.Tx.IsIncome():
+			totals.Income += inBase
+		default:
+			totals.Expenses += -inBase
+		}
+		totals.Net = totals.Income - totals.Expenses
+	}
+}
+
+// kept returns the rows of a month that are not transfers between the ledgers
+func (h *household) kept(month int) []*HouseholdRow {
+	var rows []*HouseholdRow
+	for _, row := range h.rows {
+		if !row.nearby && int(row.date.Month()) == month && !h.removed[row] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// transactions returns the transactions of rows
+func transactions(rows []*HouseholdRow) []*parser.Transaction {
+	txs := make([]*parser.Transaction, 0, len(rows))
+	for _, row := range rows {
+		txs = append(txs, row.Tx)
+	}
+	return txs
+}
+
+// BuildHouseholdMonthlyReport combines a month of several ledgers into one report,
+// leaving out transfers between them, also those whose other half is in the next or previous month
+// Categories come from the current ledger
+func BuildHouseholdMonthlyReport(sources []HouseholdSource, year, month int, opts HouseholdOptions) (*HouseholdMonthlyReport, error) {
+	h, _, err := loadHousehold(sources, year, []int{month}, opts, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := h.kept(month)
+	report := newMonthlyReport(year, month, opts.BaseCurrency)
+	report.aggregate(transactions(rows), loadCategoryTree(ledger.Current()))
+
+	return &HouseholdMonthlyReport{
+		Ledgers:            h.ledgers,
+		Report:             report,
+		ByLedger:           h.byLedger,
+		Rows:               rows,
+		Transfers:          h.transfers,
+		UnmatchedTransfers: h.unmatched,
+	}, nil
+}
+
+// BuildHouseholdYearlyReport combines a year of several ledgers into one report,
+// leaving out transfers between them, also across month and year ends
+// Categories come from the current ledger
+func BuildHouseholdYearlyReport(sources []HouseholdSource, year int, opts HouseholdOptions) (*HouseholdYearlyReport, error) {
+	months := make([]int, 12)
+	for i := range months {
+		months[i] = i + 1
+	}
+
+	h, present, err := loadHousehold(sources, year, months, opts, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := newYearlyReport(year, opts.BaseCurrency)
+	tree := loadCategoryTree(ledger.Current())
+	for _, month := range present {
+		report.addMonth(month, transactions(h.kept(month)), tree)
+	}
+
+	return &HouseholdYearlyReport{
+		Ledgers:            h.ledgers,
+		Report:             report,
+		ByLedger:           h.byLedger,
+		Transfers:          h.transfers,
+		UnmatchedTransfers: h.unmatched,
+	}, nil
+}
+
+// PrintHouseholdMonthlyReport prints the combined month followed by the ledger breakdown
+func PrintHouseholdMonthlyReport(h *HouseholdMonthlyReport, depth int) {
+	fmt.Println()
+	color.Cyan("Household: %s", strings.Join(h.Ledgers, " + "))
+	printMonthlyReport(h.Report, nil, depth)
+
+	fmt.Println("Rows by ledger")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, row := range h.Rows {
+		fmt.Printf("%-12s %s  %-30s %12.2f %s\n", output.Truncate(row.Ledger, 12), row.Date, output.Truncate(row.Tx.Description, 30), row.Tx.Amount, row.Tx.Currency)
+	}
+	fmt.Println()
+
+	printHouseholdLedgers(h.Ledgers, h.ByLedger, h.Report.BaseCurrency)
+	printHouseholdTransfers(h.Transfers, h.UnmatchedTransfers)
+}
+
+// PrintHouseholdYearlyReport prints the combined year followed by the ledger breakdown
+func PrintHouseholdYearlyReport(h *HouseholdYearlyReport, depth int) {
+	fmt.Println()
+	color.Cyan("Household: %s", strings.Join(h.Ledgers, " + "))
+	printYearlyReport(h.Report, depth)
+
+	printHouseholdLedgers(h.Ledgers, h.ByLedger, h.Report.BaseCurrency)
+	printHouseholdTransfers(h.Transfers, h.UnmatchedTransfers)
+}
+
+func printHouseholdLedgers(ledgers []string, byLedger map[string]*LedgerTotals, base string) {
+	fmt.Printf("By ledger (%s)\n", base)
+	fmt.Println(strings.Repeat("-", 70))
+	fmt.Printf("%-12s %11s %11s %11s %11s %11s\n", "Ledger", "Income", "Expense", "Net", "Sent", "Received")
+	for _, name := range ledgers {
+		t := byLedger[name]
+		fmt.Printf("%-12s %11.2f %11.2f %11.2f %11.2f %11.2f\n", output.Truncate(name, 12), t.Income, t.Expenses, t.Net, t.TransfersOut, t.TransfersIn)
+	}
+	fmt.Println()
+}
+
+func printHouseholdTransfers(transfers []*Transfer, unmatched []*HouseholdRow) {
+	if len(transfers) > 0 {
+		fmt.Printf("Transfers between ledgers (left out): %d\n", len(transfers))
+		for _, t := range transfers {
+			fmt.Printf("  %s  %s → %s  %.2f %s  %s\n", t.Date, t.From, t.To, t.Amount, t.Currency, output.Truncate(t.Out.Tx.Description, 30))
+		}
+		fmt.Println()
+	}
+
+	if len(unmatched) > 0 {
+		color.Yellow("⚠️  %d transfer row(s) without a counterpart in another ledger are counted:", len(unmatched))
+		for _, row := range unmatched {
+			color.Yellow("  %s  %s  %s  %.2f %s", row.Date, row.Ledger, output.Truncate(row.Tx.Description, 30), row.Tx.Amount, row.Tx.Currency)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,454 @@
+package reports
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"spendgrid/internal/config"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+// htmlStyle is inlined so the report is a single file without external assets
+const htmlStyle = `body { font-family: Arial, sans-serif; margin: 40px; color: #222; background: #fff; }
+table { border-collapse: collapse; width: 100%; margin: 20px 0; }
+th, td { border: 1px solid #ddd; padding: 8px 12px; text-align: left; }
+th { background-color: #4CAF50; color: white; }
+tr:nth-child(even) { background-color: #f2f2f2; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.income { color: green; }
+.expense { color: red; }
+.missing { color: #b36b00; font-style: italic; }
+.summary { font-weight: bold; font-size: 1.2em; margin: 20px 0; }
+.category { margin: 4px 0 4px 20px; }
+summary { cursor: pointer; }
+.cards { display: flex; flex-wrap: wrap; gap: 12px; margin: 20px 0; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 12px 16px; min-width: 150px; }
+.card b { display: block; font-size: 1.4em; margin-top: 4px; }
+.charts { display: grid; grid-template-columns: repeat(auto-fit, minmax(420px, 1fr)); gap: 24px; }
+figure { margin: 0; border: 1px solid #ddd; border-radius: 6px; padding: 12px; }
+figcaption { font-weight: bold; margin-bottom: 8px; }
+svg.chart { width: 100%; height: auto; }
+.axis { font-size: 11px; fill: #777; }
+.grid { stroke: #e5e5e5; }
+.zero { stroke: #999; }
+polyline.line { fill: none; stroke-width: 2; }
+.c-income { fill: #59a14f; stroke: #59a14f; background: #59a14f; }
+.c-expense { fill: #e15759; stroke: #e15759; background: #e15759; }
+.c-planned { fill: #f28e2b; stroke: #f28e2b; background: #f28e2b; }
+.c-balance { fill: #4e79a7; stroke: #4e79a7; background: #4e79a7; }
+.c-projected { fill: #b07aa1; stroke: #b07aa1; background: #b07aa1; stroke-dasharray: 4 3; }
+.legend span { margin-right: 16px; font-size: 0.9em; }
+.legend i, .donut li i { display: inline-block; width: 10px; height: 10px; margin-right: 6px; border-radius: 2px; }
+.donut { display: flex; align-items: center; gap: 16px; }
+.donut svg { width: 180px; flex: none; }
+.donut ul { list-style: none; padding: 0; margin: 0; font-size: 0.9em; }
+.donut li span { color: #777; }
+.donut-total { font-size: 5px; fill: currentColor; }
+.filters { display: flex; flex-wrap: wrap; gap: 8px; align-items: center; margin: 12px 0; }
+.filters input, .filters select { padding: 6px; }
+tr.planned td { font-style: italic; }
+@media (prefers-color-scheme: dark) {
+  body { color: #ddd; background: #1e1e1e; }
+  th, td, .card, figure { border-color: #444; }
+  tr:nth-child(even) { background-color: #2a2a2a; }
+  .grid { stroke: #333; }
+}
+`
+
+// htmlScript filters the rows of the transactions table in the browser
+const htmlScript = `(function () {
+  var text = document.getElementById('filter-text');
+  var type = document.getElementById('filter-type');
+  var tag = document.getElementById('filter-tag');
+  var count = document.getElementById('filter-count');
+  if (!text) return;
+  var rows = Array.prototype.slice.call(document.querySelectorAll('#transactions tr[data-type]'));
+  var tags = {};
+  rows.forEach(function (row) {
+    row.getAttribute('data-tags').split(' ').forEach(function (t) {
+      var parts = t.split(':');
+      for (var i = 1; t && i <= parts.length; i++) tags[parts.slice(0, i).join(':')] = true;
+    });
+  });
+  Object.keys(tags).sort().forEach(function (t) {
+    var option = document.createElement('option');
+    option.value = t;
+    option.textContent = '#' + t;
+    tag.appendChild(option);
+  });
+  function hasTag(row, wanted) {
+    return row.getAttribute('data-tags').split(' ').some(function (t) {
+      return t === wanted || t.indexOf(wanted + ':') === 0;
+    });
+  }
+  function apply() {
+    var q = text.value.trim().toLowerCase();
+    var shown = 0;
+    rows.forEach(function (row) {
+      var ok = (!type.value || row.getAttribute('data-type') === type.value) &&
+        (!tag.value || hasTag(row, tag.value)) &&
+        (!q || row.textContent.toLowerCase().indexOf(q) >= 0);
+      row.style.display = ok ? '' : 'none';
+      if (ok) shown++;
+    });
+    count.textContent = shown + ' / ' + rows.length;
+  }
+  text.addEventListener('input', apply);
+  type.addEventListener('change', apply);
+  tag.addEventListener('change', apply);
+  apply();
+})();
+`
+
+// GenerateHTMLReport generates a self-contained HTML report with charts in _share
+// The yearly report covers every month file of the current year, the monthly one the current month
+func GenerateHTMLReport(year bool) error {
+	l := ledger.Current()
+	if err := l.EnsureInitialized(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filename := fmt.Sprintf("report_%s.html", now.Format("2006_01_02"))
+	filePath := filepath.Join("_share", filename)
+
+	base := config.LedgerBaseCurrency(l)
+	months := loadMonths(l, now.Year())
+	opening := openingBalance(l, now.Year(), base)
+
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n")
+	out.WriteString("<html>\n<head>\n")
+	out.WriteString("<meta charset='UTF-8'>\n")
+	out.WriteString("<meta name='viewport' content='width=device-width, initial-scale=1'>\n")
+	out.WriteString("<title>SpendGrid Report</title>\n")
+	out.WriteString("<style>\n" + htmlStyle + "</style>\n")
+	out.WriteString("</head>\n<body>\n")
+	out.WriteString("<h1>SpendGrid Financial Report</h1>\n")
+	out.WriteString(fmt.Sprintf("<p>Generated: %s</p>\n", now.Format("2006-01-02 15:04:05")))
+
+	if year {
+		total := mergeMonths(now.Year(), base, months)
+		out.WriteString(fmt.Sprintf("<h2>Year %d</h2>\n", now.Year()))
+		writeTotalsHTML(&out, total)
+		writeChartsHTML(&out, total, months, opening)
+		writeMonthTableHTML(&out, months, base)
+		writeCurrencyTableHTML(&out, total)
+		writePlannedHTML(&out, total, months)
+		if len(total.ByCategory) > 0 {
+			writeCategoryHTML(&out, total.ByCategory, total.BaseByCategory, base)
+		}
+		writeTransactionsHTML(&out, months)
+	} else if report := findMonth(months, int(now.Month())); report != nil {
+		out.WriteString(fmt.Sprintf("<h2>%s %d</h2>\n", time.Month(report.Month), report.Year))
+		writeTotalsHTML(&out, report)
+		writeChartsHTML(&out, report, months, opening)
+		writeCurrencyTableHTML(&out, report)
+		writePlannedHTML(&out, report, []*MonthlyReport{report})
+		if len(report.ByCategory) > 0 {
+			writeCategoryHTML(&out, report.ByCategory, report.BaseByCategory, base)
+		}
+		writeTransactionsHTML(&out, []*MonthlyReport{report})
+	}
+
+	out.WriteString("<script>\n" + htmlScript + "</script>\n")
+	out.WriteString("</body>\n</html>")
+
+	// Write to file
+	if err := l.WriteFile(filePath, []byte(out.String())); err != nil {
+		return fmt.Errorf("failed to write HTML report: %v", err)
+	}
+
+	fmt.Printf("HTML report generated: %s\n", filePath)
+	return nil
+}
+
+// loadMonths builds the report of every month file of a year
+func loadMonths(l *ledger.Ledger, year int) []*MonthlyReport {
+	var months []*MonthlyReport
+	for month := 1; month <= 12; month++ {
+		report, _, err := BuildMonthlyReport(l, year, month)
+		if err != nil {
+			continue // Skip if file doesn't exist
+		}
+		months = append(months, report)
+	}
+	return months
+}
+
+func findMonth(months []*MonthlyReport, month int) *MonthlyReport {
+	for _, m := range months {
+		if m.Month == month {
+			return m
+		}
+	}
+	return nil
+}
+
+// mergeMonths sums monthly reports into one report of the whole period
+// Unlike BuildYearlyReport it keeps unchecked rules apart as planned
+func mergeMonths(year int, base string, months []*MonthlyReport) *MonthlyReport {
+	total := newMonthlyReport(year, 0, base)
+	for _, m := range months {
+		for curr, v := range m.Income {
+			total.Income[curr] += v
+		}
+		for curr, v := range m.Expenses {
+			total.Expenses[curr] += v
+		}
+		for curr, v := range m.PlannedIncome {
+			total.PlannedIncome[curr] += v
+		}
+		for curr, v := range m.PlannedExpenses {
+			total.PlannedExpenses[curr] += v
+		}
+		for path, byCurrency := range m.ByCategory {
+			if total.ByCategory[path] == nil {
+				total.ByCategory[path] = make(map[string]float64)
+			}
+			for curr, v := range byCurrency {
+				total.ByCategory[path][curr] += v
+			}
+		}
+		for path, v := range m.BaseByCategory {
+			total.BaseByCategory[path] += v
+		}
+		total.Transactions = append(total.Transactions, m.Transactions...)
+		total.PlannedTx = append(total.PlannedTx, m.PlannedTx...)
+		total.MissingRates = append(total.MissingRates, m.MissingRates...)
+		total.BaseIncome += m.BaseIncome
+		total.BaseExpenses += m.BaseExpenses
+		total.BasePlannedIncome += m.BasePlannedIncome
+		total.BasePlannedExpenses += m.BasePlannedExpenses
+	}
+	return total
+}
+
+// openingBalance sums the completed rows of all years before the given one in base currency
+func openingBalance(l *ledger.Ledger, year int, base string) float64 {
+	entries, err := l.AllEntries()
+	if err != nil {
+		return 0
+	}
+
+	balance := 0.0
+	for _, e := range entries {
+		if e.Year >= year || e.IsPlanned() {
+			continue
+		}
+		if amount, ok := convertToBase(e.Tx, e.Year, e.Month, base); ok {
+			balance += amount
+		}
+	}
+	return balance
+}
+
+// writeTotalsHTML writes the base currency totals as cards
+func writeTotalsHTML(out *strings.Builder, report *MonthlyReport) {
+	base := report.BaseCurrency
+	net := report.BaseIncome - report.BaseExpenses
+	card := func(label, class string, amount float64) {
+		out.WriteString(fmt.Sprintf("<div class='card'>%s <b class='%s'>%.2f %s</b></div>\n", label, class, amount, base))
+	}
+
+	out.WriteString("<div class='cards'>\n")
+	card("Income", "income", report.BaseIncome)
+	card("Expenses", "expense", report.BaseExpenses)
+	card("Net", netClass(net), net)
+	if len(report.PlannedTx) > 0 {
+		projected := net + report.BasePlannedIncome - report.BasePlannedExpenses
+		card("Planned income", "income", report.BasePlannedIncome)
+		card("Planned expenses", "expense", report.BasePlannedExpenses)
+		card("Projected net", netClass(projected), projected)
+	}
+	out.WriteString("</div>\n")
+
+	if len(report.MissingRates) > 0 {
+		out.WriteString(fmt.Sprintf("<p class='missing'>%d row(s) had no exchange rate and are excluded from the %s totals</p>\n", len(report.MissingRates), base))
+	}
+}
+
+func netClass(net float64) string {
+	if net < 0 {
+		return "expense"
+	}
+	return "income"
+}
+
+// writeChartsHTML draws the charts, monthly charts cover every month of the year
+func writeChartsHTML(out *strings.Builder, report *MonthlyReport, months []*MonthlyReport, opening float64) {
+	base := report.BaseCurrency
+	labels := make([]string, len(months))
+	income := chartSeries{Name: "Income", Class: "c-income"}
+	expenses := chartSeries{Name: "Expenses", Class: "c-expense"}
+	planned := chartSeries{Name: "Planned expenses", Class: "c-planned"}
+	balance := chartSeries{Name: "Net worth", Class: "c-balance", Values: []float64{opening}}
+	projected := chartSeries{Name: "With planned rows", Class: "c-projected", Values: []float64{opening}}
+
+	actual, withPlanned := opening, opening
+	for i, m := range months {
+		labels[i] = time.Month(m.Month).String()[:3]
+		income.Values = append(income.Values, m.BaseIncome)
+		expenses.Values = append(expenses.Values, m.BaseExpenses)
+		planned.Values = append(planned.Values, m.BasePlannedExpenses)
+
+		actual += m.BaseIncome - m.BaseExpenses
+		withPlanned += m.BaseIncome - m.BaseExpenses + m.BasePlannedIncome - m.BasePlannedExpenses
+		balance.Values = append(balance.Values, actual)
+		projected.Values = append(projected.Values, withPlanned)
+	}
+
+	out.WriteString("<h2>Charts</h2>\n<div class='charts'>\n")
+
+	out.WriteString(fmt.Sprintf("<figure><figcaption>Income vs expenses (%s)</figcaption>\n", base))
+	writeBarChart(out, labels, []chartSeries{income, expenses})
+	out.WriteString("</figure>\n")
+
+	if slices := expenseSlices(report.BaseByCategory, len(chartPalette)); len(slices) > 0 {
+		out.WriteString(fmt.Sprintf("<figure><figcaption>Expenses by category (%s)</figcaption>\n", base))
+		writeDonutChart(out, slices, base)
+		out.WriteString("</figure>\n")
+	}
+
+	out.WriteString(fmt.Sprintf("<figure><figcaption>Net worth (%s, month end)</figcaption>\n", base))
+	writeLineChart(out, append([]string{"Start"}, labels...), []chartSeries{balance, projected})
+	out.WriteString("</figure>\n")
+
+	out.WriteString(fmt.Sprintf("<figure><figcaption>Planned vs actual expenses (%s)</figcaption>\n", base))
+	writeBarChart(out, labels, []chartSeries{expenses, planned})
+	out.WriteString("</figure>\n")
+
+	out.WriteString("</div>\n")
+}
+
+// writeMonthTableHTML writes one row per month in base currency
+func writeMonthTableHTML(out *strings.Builder, months []*MonthlyReport, base string) {
+	out.WriteString("<h2>Monthly Summary</h2>\n")
+	out.WriteString("<table>\n")
+	out.WriteString(fmt.Sprintf("<tr><th>Month</th><th>Income (%s)</th><th>Expenses (%s)</th><th>Net (%s)</th><th>Planned net (%s)</th><th>Missing Rates</th></tr>\n", base, base, base, base))
+
+	for _, m := range months {
+		net := m.BaseIncome - m.BaseExpenses
+		plannedNet := m.BasePlannedIncome - m.BasePlannedExpenses
+
+		missing := ""
+		if len(m.MissingRates) > 0 {
+			missing = fmt.Sprintf("<span class='missing'>%d row(s)</span>", len(m.MissingRates))
+		}
+
+		out.WriteString(fmt.Sprintf("<tr><td>%s</td><td class='num income'>%.2f</td><td class='num expense'>%.2f</td><td class='num %s'>%.2f</td><td class='num'>%.2f</td><td>%s</td></tr>\n",
+			time.Month(m.Month), m.BaseIncome, m.BaseExpenses, netClass(net), net, plannedNet, missing))
+	}
+	out.WriteString("</table>\n")
+}
+
+// writeCurrencyTableHTML writes subtotals in each original currency
+func writeCurrencyTableHTML(out *strings.Builder, report *MonthlyReport) {
+	out.WriteString("<h2>By Currency</h2>\n")
+	out.WriteString("<table>\n")
+	out.WriteString("<tr><th>Currency</th><th>Income</th><th>Expenses</th><th>Planned income</th><th>Planned expenses</th></tr>\n")
+
+	currencies := getAllCurrencies(report.Income, report.Expenses)
+	for _, curr := range getAllCurrencies(report.PlannedIncome, report.PlannedExpenses) {
+		if _, ok := report.Income[curr]; !ok {
+			if _, ok := report.Expenses[curr]; !ok {
+				currencies = append(currencies, curr)
+			}
+		}
+	}
+	for _, curr := range currencies {
+		out.WriteString(fmt.Sprintf("<tr><td>%s</td><td class='num income'>%.2f</td><td class='num expense'>%.2f</td><td class='num income'>%.2f</td><td class='num expense'>%.2f</td></tr>\n",
+			html.EscapeString(curr), report.Income[curr], report.Expenses[curr], report.PlannedIncome[curr], report.PlannedExpenses[curr]))
+	}
+	out.WriteString(fmt.Sprintf("<tr><th>Total (%s)</th><th>%.2f</th><th>%.2f</th><th>%.2f</th><th>%.2f</th></tr>\n",
+		html.EscapeString(report.BaseCurrency), report.BaseIncome, report.BaseExpenses, report.BasePlannedIncome, report.BasePlannedExpenses))
+	out.WriteString("</table>\n")
+}
+
+// writePlannedHTML lists unchecked rule lines of the months and the projection of report, like the terminal report
+func writePlannedHTML(out *strings.Builder, report *MonthlyReport, months []*MonthlyReport) {
+	if len(report.PlannedTx) == 0 {
+		return
+	}
+	base := report.BaseCurrency
+
+	out.WriteString("<h2>Planned (unchecked rules)</h2>\n")
+	writeRowsTableHTML(out, months, true)
+
+	projectedIncome := report.BaseIncome + report.BasePlannedIncome
+	projectedExpenses := report.BaseExpenses + report.BasePlannedExpenses
+	out.WriteString("<div class='summary'>\n")
+	out.WriteString(fmt.Sprintf("<p class='income'>Projected Income: %.2f %s</p>\n", projectedIncome, base))
+	out.WriteString(fmt.Sprintf("<p class='expense'>Projected Expenses: %.2f %s</p>\n", projectedExpenses, base))
+	out.WriteString(fmt.Sprintf("<p>Projected Net: %.2f %s</p>\n", projectedIncome-projectedExpenses, base))
+	out.WriteString("</div>\n")
+}
+
+// writeTransactionsHTML writes all rows with the client-side filters above them
+func writeTransactionsHTML(out *strings.Builder, months []*MonthlyReport) {
+	out.WriteString("<h2>Transactions</h2>\n")
+	out.WriteString("<div class='filters'>\n")
+	out.WriteString("<input id='filter-text' type='search' placeholder='Search'>\n")
+	out.WriteString("<select id='filter-type'><option value=''>All rows</option><option value='income'>Income</option><option value='expense'>Expenses</option><option value='planned'>Planned</option></select>\n")
+	out.WriteString("<select id='filter-tag'><option value=''>All tags</option></select>\n")
+	out.WriteString("<span id='filter-count'></span>\n")
+	out.WriteString("</div>\n")
+	writeRowsTableHTML(out, months, false)
+}
+
+// writeRowsTableHTML writes a table of rows, only the planned ones if plannedOnly is set
+// Rows carry their type and tags as data attributes for the filters
+func writeRowsTableHTML(out *strings.Builder, months []*MonthlyReport, plannedOnly bool) {
+	if len(months) == 0 {
+		return
+	}
+	base := months[0].BaseCurrency
+
+	if plannedOnly {
+		out.WriteString("<table>\n")
+	} else {
+		out.WriteString("<table id='transactions'>\n")
+	}
+	out.WriteString(fmt.Sprintf("<tr><th>Date</th><th>Description</th><th>Amount</th><th>Currency</th><th>Amount (%s)</th><th>Tags</th></tr>\n", base))
+	for _, m := range months {
+		missing := make(map[*parser.Transaction]bool)
+		for _, tx := range m.MissingRates {
+			missing[tx] = true
+		}
+
+		rows := m.Transactions
+		if plannedOnly {
+			rows = m.PlannedTx
+		}
+		for _, tx := range rows {
+			class := "expense"
+			if tx.IsIncome() {
+				class = "income"
+			}
+			rowType := class
+			if tx.IsRule && !tx.Completed {
+				rowType = "planned"
+			}
+
+			converted := "<span class='missing'>no rate</span>"
+			if !missing[tx] {
+				inBase, _ := convertToBase(tx, m.Year, m.Month, base)
+				converted = fmt.Sprintf("%.2f", inBase)
+			}
+
+			tags := make([]string, len(tx.Tags))
+			for i, tag := range tx.Tags {
+				tags[i] = html.EscapeString(tag)
+			}
+
+			out.WriteString(fmt.Sprintf("<tr class='%s' data-type='%s' data-tags='%s'><td>%04d-%02d-%02d</td><td>%s</td><td class='num %s'>%.2f</td><td>%s</td><td class='num %s'>%s</td><td>%s</td></tr>\n",
+				rowType, rowType, strings.Join(tags, " "), m.Year, m.Month, tx.Day, html.EscapeString(tx.Description),
+				class, tx.Amount, html.EscapeString(tx.Currency), class, converted, strings.Join(tags, ", ")))
+		}
+	}
+	out.WriteString("</table>\n")
+}
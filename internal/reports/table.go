@@ -0,0 +1,34 @@
+package reports
+
+import "spendgrid/internal/parser"
+
+// MonthSummary is a single month of a yearly report in base currency
+type MonthSummary struct {
+	Year     int     `json:"year" yaml:"year"`
+	Month    int     `json:"month" yaml:"month"`
+	Income   float64 `json:"income" yaml:"income"`
+	Expenses float64 `json:"expenses" yaml:"expenses"`
+	Net      float64 `json:"net" yaml:"net"`
+	Currency string  `json:"currency" yaml:"currency"`
+}
+
+// Table returns the completed and planned rows of the month for CSV output
+func (r *MonthlyReport) Table() interface{} {
+	return append(append([]*parser.Transaction{}, r.Transactions...), r.PlannedTx...)
+}
+
+// Table returns one summary per month for CSV output
+func (r *YearlyReport) Table() interface{} {
+	rows := make([]MonthSummary, 0, len(r.Months))
+	for _, m := range r.Months {
+		rows = append(rows, MonthSummary{
+			Year:     m.Year,
+			Month:    m.Month,
+			Income:   m.BaseIncome,
+			Expenses: m.BaseExpenses,
+			Net:      m.BaseIncome - m.BaseExpenses,
+			Currency: r.BaseCurrency,
+		})
+	}
+	return rows
+}
@@ -4,20 +4,19 @@ import (
 	"bufio"
 	"fmt"
 	"os"
-	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/eiannone/keyboard"
 	"spendgrid/internal/cache"
 	"spendgrid/internal/i18n"
-	"spendgrid/internal/parser"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/terminal"
 )
 
 // ListRules displays all rules
 func ListRules() error {
-	ruleSet, err := LoadRules()
+	ruleSet, err := LoadRules(ledger.Current())
 	if err != nil {
 		return err
 	}
@@ -70,7 +69,7 @@ func AddRuleInteractive() error {
 
 	// Get rule name
 	fmt.Println(i18n.T("rules.name_prompt"))
-	name, err := readSimpleLine()
+	name, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading name: %v", err)
 	}
@@ -84,7 +83,7 @@ func AddRuleInteractive() error {
 
 	// Get type
 	fmt.Println("Tür [income/expense] [expense]:")
-	ruleTypeInput, err := readSimpleLine()
+	ruleTypeInput, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading type: %v", err)
 	}
@@ -95,7 +94,7 @@ func AddRuleInteractive() error {
 
 	// Get amount and currency
 	fmt.Println("Tutar ve Para Birimi (örn: 25000TRY, 500 USD, -150.50 EUR):")
-	amountInput, err := readSimpleLine()
+	amountInput, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading amount: %v", err)
 	}
@@ -108,7 +107,7 @@ func AddRuleInteractive() error {
 	// Get schedule day with default
 	today := time.Now().Day()
 	fmt.Printf("Ayın günü [%d]:\n", today)
-	dayStr, err := readSimpleLine()
+	dayStr, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading day: %v", err)
 	}
@@ -123,7 +122,7 @@ func AddRuleInteractive() error {
 
 	// Ask about duration
 	fmt.Println("Tüm yıl boyunca mu? (e/h) [e]:")
-	fullYear, err := readSimpleLine()
+	fullYear, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading duration: %v", err)
 	}
@@ -135,14 +134,14 @@ func AddRuleInteractive() error {
 	if fullYear == "h" || fullYear == "hayır" || fullYear == "hayir" {
 		// Ask for start and end dates
 		fmt.Println("Başlangıç tarihi (YYYY-MM):")
-		startDate, err = readSimpleLine()
+		startDate, err = terminal.ReadLine()
 		if err != nil {
 			return fmt.Errorf("error reading start date: %v", err)
 		}
 		startDate = strings.TrimSpace(startDate)
 
 		fmt.Println("Bitiş tarihi (YYYY-MM):")
-		endDate, err = readSimpleLine()
+		endDate, err = terminal.ReadLine()
 		if err != nil {
 			return fmt.Errorf("error reading end date: %v", err)
 		}
@@ -151,7 +150,7 @@ func AddRuleInteractive() error {
 
 	// Ask about installment/credit
 	fmt.Println("Taksitli/kredili ödeme mi? (e/h) [h]:")
-	isInstallment, err := readSimpleLine()
+	isInstallment, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading installment: %v", err)
 	}
@@ -162,7 +161,7 @@ func AddRuleInteractive() error {
 
 	if isInstallment == "e" || isInstallment == "evet" {
 		fmt.Println("Toplam tutar (örn: 25000):")
-		totalStr, err := readSimpleLine()
+		totalStr, err := terminal.ReadLine()
 		if err != nil {
 			return fmt.Errorf("error reading total amount: %v", err)
 		}
@@ -172,7 +171,7 @@ func AddRuleInteractive() error {
 		}
 
 		fmt.Println("Açıklama (örn: 3 taksit - iPhone 15):")
-		metadata, err = readSimpleLine()
+		metadata, err = terminal.ReadLine()
 		if err != nil {
 			return fmt.Errorf("error reading metadata: %v", err)
 		}
@@ -182,7 +181,7 @@ func AddRuleInteractive() error {
 	// Get tags with autocomplete
 	fmt.Println("Etiketler:")
 	fmt.Println("  (Type to filter, Tab to autocomplete, 1-9 to select, Enter to confirm)")
-	tagsInput, err := readWithAutocomplete("  > ", cacheStore.GetTags(), "#")
+	tagsInput, err := terminal.ReadWithAutocomplete("  > ", cacheStore.GetTags(), "#")
 	if err != nil {
 		return fmt.Errorf("error reading tags: %v", err)
 	}
@@ -191,7 +190,7 @@ func AddRuleInteractive() error {
 	// Get project with autocomplete
 	fmt.Println("Proje:")
 	fmt.Println("  (Type to filter, Tab to autocomplete, 1-9 to select, Enter to confirm)")
-	project, err := readWithAutocomplete("  > ", cacheStore.GetProjects(), "@")
+	project, err := terminal.ReadWithAutocomplete("  > ", cacheStore.GetProjects(), "@")
 	if err != nil {
 		return fmt.Errorf("error reading project: %v", err)
 	}
@@ -216,7 +215,7 @@ func AddRuleInteractive() error {
 		Metadata:    metadata,
 	}
 
-	if err := AddRule(rule); err != nil {
+	if err := AddRule(ledger.Current(), rule); err != nil {
 		return err
 	}
 
@@ -260,219 +259,17 @@ func AddRuleInteractive() error {
 	return nil
 }
 
-// readSimpleLine reads a line of input using keyboard mode
-func readSimpleLine() (string, error) {
-	if err := keyboard.Open(); err != nil {
-		// Fallback to regular input
-		reader := bufio.NewReader(os.Stdin)
-		return reader.ReadString('\n')
-	}
-	defer keyboard.Close()
-
-	var input []rune
-	for {
-		char, key, err := keyboard.GetKey()
-		if err != nil {
-			return "", err
-		}
-
-		switch key {
-		case keyboard.KeyEnter:
-			fmt.Println()
-			return string(input), nil
-		case keyboard.KeyCtrlC, keyboard.KeyEsc:
-			return "", fmt.Errorf("cancelled")
-		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				fmt.Print("\b \b")
-			}
-		case keyboard.KeySpace:
-			input = append(input, ' ')
-			fmt.Print(" ")
-		default:
-			if char != 0 {
-				input = append(input, char)
-				fmt.Printf("%c", char)
-			}
-		}
-	}
-}
-
-// readWithAutocomplete reads input with real-time autocomplete suggestions
-func readWithAutocomplete(prompt string, items []string, prefix string) (string, error) {
-	if err := keyboard.Open(); err != nil {
-		// Fallback to regular input if keyboard fails
-		fmt.Print(prompt)
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
-		return strings.TrimSpace(input), nil
-	}
-	defer keyboard.Close()
-
-	var input []rune
-	selectedIndex := -1
-	matches := []string{}
-	suggestionsShown := false
-
-	fmt.Print(prompt)
-
-	for {
-		char, key, err := keyboard.GetKey()
-		if err != nil {
-			return "", err
-		}
-
-		switch key {
-		case keyboard.KeyEnter:
-			if suggestionsShown {
-				clearSuggestions()
-			}
-			fmt.Println()
-			result := string(input)
-			if selectedIndex >= 0 && selectedIndex < len(matches) {
-				result = prefix + matches[selectedIndex]
-			}
-			return result, nil
-
-		case keyboard.KeyCtrlC, keyboard.KeyEsc:
-			if suggestionsShown {
-				clearSuggestions()
-			}
-			fmt.Println("\nCancelled")
-			return "", fmt.Errorf("cancelled")
-
-		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				selectedIndex = -1
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyTab:
-			if len(matches) > 0 {
-				input = []rune(prefix + matches[0])
-				selectedIndex = 0
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyArrowUp:
-			if selectedIndex > 0 {
-				selectedIndex--
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyArrowDown:
-			if selectedIndex < len(matches)-1 {
-				selectedIndex++
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeySpace:
-			input = append(input, ' ')
-			selectedIndex = -1
-			searchTerm := strings.TrimPrefix(string(input), prefix)
-			matches = filterItems(items, searchTerm)
-			suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-
-		default:
-			if char != 0 {
-				if char >= '1' && char <= '9' {
-					idx := int(char - '1')
-					if idx < len(matches) {
-						input = []rune(prefix + matches[idx])
-						selectedIndex = idx
-						suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-						continue
-					}
-				}
-
-				input = append(input, char)
-				selectedIndex = -1
-
-				searchTerm := strings.TrimPrefix(string(input), prefix)
-				matches = filterItems(items, searchTerm)
-
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-		}
-	}
-}
-
-// clearSuggestions clears the suggestions line from the terminal
-func clearSuggestions() {
-	fmt.Print("\n\033[K\033[F")
-}
-
-// updateDisplay updates the terminal display with current input and suggestions
-func updateDisplay(prompt string, input []rune, matches []string, selectedIndex int, prefix string) bool {
-	fmt.Printf("\r\033[K")
-	fmt.Printf("%s%s", prompt, string(input))
-
-	if len(matches) > 0 {
-		fmt.Println()
-		fmt.Printf("\033[2K")
-		fmt.Printf("\r")
-
-		maxShow := 5
-		if len(matches) < maxShow {
-			maxShow = len(matches)
-		}
-
-		for i := 0; i < maxShow; i++ {
-			if i == selectedIndex {
-				fmt.Printf("\033[7m %d. %s%s \033[0m", i+1, prefix, matches[i])
-			} else {
-				fmt.Printf(" %d. %s%s", i+1, prefix, matches[i])
-			}
-		}
-
-		fmt.Printf("\033[F")
-		fmt.Printf("\033[%dC", len(prompt)+len(input))
-		return true
-	}
-	return false
-}
-
-// filterItems returns items that contain the search term (case-insensitive)
-func filterItems(items []string, searchTerm string) []string {
-	if searchTerm == "" {
-		return items
-	}
-
-	searchTerm = strings.ToLower(searchTerm)
-	var matches []string
-
-	for _, item := range items {
-		if strings.Contains(strings.ToLower(item), searchTerm) {
-			matches = append(matches, item)
-		}
-	}
-
-	return matches
-}
-
-// refreshCacheFromFiles scans all transaction files and populates cache
+// refreshCacheFromFiles scans the month files of the current year and populates cache
 func refreshCacheFromFiles(cacheStore *cache.Cache) error {
-	currentYear := strconv.Itoa(time.Now().Year())
+	l := ledger.Current()
+	year := time.Now().Year()
 
-	return filepath.Walk(currentYear, func(path string, info os.FileInfo, err error) error {
+	for month := 1; month <= 12; month++ {
+		parsed, _, err := l.LoadMonth(year, month)
 		if err != nil {
-			return nil
+			continue
 		}
 
-		if info.IsDir() || !strings.HasSuffix(path, ".md") {
-			return nil
-		}
-
-		content, err := os.ReadFile(path)
-		if err != nil {
-			return nil
-		}
-
-		// Parse transactions from file
-		parsed, _ := parser.ParseMonthFile(string(content))
-
 		// Extract tags and projects
 		for _, tx := range parsed {
 			for _, tag := range tx.Tags {
@@ -482,14 +279,14 @@ func refreshCacheFromFiles(cacheStore *cache.Cache) error {
 				cacheStore.AddProject(proj)
 			}
 		}
+	}
 
-		return nil
-	})
+	return nil
 }
 
 // EditRuleInteractive edits a rule interactively
 func EditRuleInteractive(id string) error {
-	rule, err := GetRule(id)
+	rule, err := GetRule(ledger.Current(), id)
 	if err != nil {
 		return err
 	}
@@ -551,7 +348,7 @@ func EditRuleInteractive(id string) error {
 		rule.Tags = parseTags(tagsInput)
 	}
 
-	if err := UpdateRule(id, *rule); err != nil {
+	if err := UpdateRule(ledger.Current(), id, *rule); err != nil {
 		return err
 	}
 
@@ -561,12 +358,12 @@ func EditRuleInteractive(id string) error {
 
 // ToggleRuleStatus toggles a rule's active status
 func ToggleRuleStatus(id string) error {
-	rule, err := GetRule(id)
+	rule, err := GetRule(ledger.Current(), id)
 	if err != nil {
 		return err
 	}
 
-	if err := ToggleRule(id); err != nil {
+	if err := ToggleRule(ledger.Current(), id); err != nil {
 		return err
 	}
 
@@ -581,7 +378,7 @@ func ToggleRuleStatus(id string) error {
 
 // RemoveRule removes a rule
 func RemoveRule(id string) error {
-	rule, err := GetRule(id)
+	rule, err := GetRule(ledger.Current(), id)
 	if err != nil {
 		return err
 	}
@@ -596,7 +393,7 @@ func RemoveRule(id string) error {
 		return nil
 	}
 
-	if err := DeleteRule(id); err != nil {
+	if err := DeleteRule(ledger.Current(), id); err != nil {
 		return err
 	}
 
@@ -608,7 +405,7 @@ func RemoveRule(id string) error {
 func SyncNow() error {
 	fmt.Println(i18n.T("rules.sync_start"))
 
-	result, err := SyncRules()
+	result, err := SyncRules(ledger.Current())
 	if err != nil {
 		return fmt.Errorf("sync failed: %v", err)
 	}
@@ -747,7 +544,7 @@ func AddRuleDirect(args []string) error {
 		Metadata:    metadata,
 	}
 
-	if err := AddRule(rule); err != nil {
+	if err := AddRule(ledger.Current(), rule); err != nil {
 		return err
 	}
 
@@ -1,33 +1,35 @@
 package rules
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"strconv"
 	"time"
 
 	"gopkg.in/yaml.v3"
+	"spendgrid/internal/ledger"
 )
 
 // Rule represents a recurring transaction rule
 type Rule struct {
-	ID              string   `yaml:"id"`
-	Name            string   `yaml:"name"`
-	Amount          float64  `yaml:"amount"`
-	RemainingAmount float64  `yaml:"remaining_amount,omitempty"` // Kalan tutar (sistem etiketleri için)
-	Currency        string   `yaml:"currency"`
-	Type            string   `yaml:"type"` // income or expense
-	Category        string   `yaml:"category"`
-	Tags            []string `yaml:"tags"`
-	Project         string   `yaml:"project,omitempty"`
-	Schedule        Schedule `yaml:"schedule"`
-	Active          bool     `yaml:"active"`
+	ID              string   `json:"id" yaml:"id"`
+	Name            string   `json:"name" yaml:"name"`
+	Amount          float64  `json:"amount" yaml:"amount"`
+	RemainingAmount float64  `json:"remaining_amount,omitempty" yaml:"remaining_amount,omitempty"` // Kalan tutar (sistem etiketleri için)
+	Currency        string   `json:"currency" yaml:"currency"`
+	Type            string   `json:"type" yaml:"type"` // income or expense
+	Category        string   `json:"category" yaml:"category"`
+	Tags            []string `json:"tags" yaml:"tags"`
+	Project         string   `json:"project,omitempty" yaml:"project,omitempty"`
+	Schedule        Schedule `json:"schedule" yaml:"schedule"`
+	Active          bool     `json:"active" yaml:"active"`
 	// New fields for installment/credit payments
-	StartDate   string  `yaml:"start_date,omitempty"` // Format: YYYY-MM
-	EndDate     string  `yaml:"end_date,omitempty"`   // Format: YYYY-MM
-	TotalAmount float64 `yaml:"total_amount,omitempty"`
-	Metadata    string  `yaml:"metadata,omitempty"` // e.g., "3 taksit - iPhone 15"
+	StartDate   string  `json:"start_date,omitempty" yaml:"start_date,omitempty"` // Format: YYYY-MM
+	EndDate     string  `json:"end_date,omitempty" yaml:"end_date,omitempty"`     // Format: YYYY-MM
+	TotalAmount float64 `json:"total_amount,omitempty" yaml:"total_amount,omitempty"`
+	Metadata    string  `json:"metadata,omitempty" yaml:"metadata,omitempty"` // e.g., "3 taksit - iPhone 15"
 }
 
 // IsSystemTag checks if the rule has any system tags (#tag# format)
@@ -63,8 +65,8 @@ func (r *Rule) ResetRemainingAmount() {
 
 // Schedule defines when the rule should be applied
 type Schedule struct {
-	Frequency string `yaml:"frequency"` // monthly, weekly, yearly
-	Day       int    `yaml:"day"`       // Day of month (1-31) or day of week (1-7)
+	Frequency string `json:"frequency" yaml:"frequency"` // monthly, weekly, yearly
+	Day       int    `json:"day" yaml:"day"`             // Day of month (1-31) or day of week (1-7)
 }
 
 // RuleSet holds all rules
@@ -78,12 +80,12 @@ func GetRulesFilePath() string {
 }
 
 // LoadRules loads all rules from rules.yml
-func LoadRules() (*RuleSet, error) {
+func LoadRules(l *ledger.Ledger) (*RuleSet, error) {
 	filePath := GetRulesFilePath()
 
-	data, err := os.ReadFile(filePath)
+	data, err := l.ReadFile(filePath)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			// Return empty rule set
 			return &RuleSet{Rules: []Rule{}}, nil
 		}
@@ -99,7 +101,7 @@ func LoadRules() (*RuleSet, error) {
 }
 
 // SaveRules saves rules to rules.yml
-func SaveRules(ruleSet *RuleSet) error {
+func SaveRules(l *ledger.Ledger, ruleSet *RuleSet) error {
 	filePath := GetRulesFilePath()
 
 	data, err := yaml.Marshal(ruleSet)
@@ -111,7 +113,7 @@ func SaveRules(ruleSet *RuleSet) error {
 	header := "# SpendGrid Rules\n# Otomatik oluşturulacak düzenli gelir/gider kuralları\n\n"
 	data = append([]byte(header), data...)
 
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
+	if err := l.WriteFile(filePath, data); err != nil {
 		return fmt.Errorf("failed to write rules file: %v", err)
 	}
 
@@ -119,8 +121,8 @@ func SaveRules(ruleSet *RuleSet) error {
 }
 
 // AddRule adds a new rule
-func AddRule(rule Rule) error {
-	ruleSet, err := LoadRules()
+func AddRule(l *ledger.Ledger, rule Rule) error {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return err
 	}
@@ -133,12 +135,12 @@ func AddRule(rule Rule) error {
 	}
 
 	ruleSet.Rules = append(ruleSet.Rules, rule)
-	return SaveRules(ruleSet)
+	return SaveRules(l, ruleSet)
 }
 
 // GetRule gets a rule by ID
-func GetRule(id string) (*Rule, error) {
-	ruleSet, err := LoadRules()
+func GetRule(l *ledger.Ledger, id string) (*Rule, error) {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return nil, err
 	}
@@ -153,8 +155,8 @@ func GetRule(id string) (*Rule, error) {
 }
 
 // UpdateRule updates an existing rule
-func UpdateRule(id string, updated Rule) error {
-	ruleSet, err := LoadRules()
+func UpdateRule(l *ledger.Ledger, id string, updated Rule) error {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return err
 	}
@@ -172,12 +174,12 @@ func UpdateRule(id string, updated Rule) error {
 		return fmt.Errorf("rule with ID '%s' not found", id)
 	}
 
-	return SaveRules(ruleSet)
+	return SaveRules(l, ruleSet)
 }
 
 // DeleteRule removes a rule by ID
-func DeleteRule(id string) error {
-	ruleSet, err := LoadRules()
+func DeleteRule(l *ledger.Ledger, id string) error {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return err
 	}
@@ -197,12 +199,12 @@ func DeleteRule(id string) error {
 	}
 
 	ruleSet.Rules = newRules
-	return SaveRules(ruleSet)
+	return SaveRules(l, ruleSet)
 }
 
 // ToggleRule toggles a rule's active status
-func ToggleRule(id string) error {
-	ruleSet, err := LoadRules()
+func ToggleRule(l *ledger.Ledger, id string) error {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return err
 	}
@@ -210,7 +212,7 @@ func ToggleRule(id string) error {
 	for i := range ruleSet.Rules {
 		if ruleSet.Rules[i].ID == id {
 			ruleSet.Rules[i].Active = !ruleSet.Rules[i].Active
-			return SaveRules(ruleSet)
+			return SaveRules(l, ruleSet)
 		}
 	}
 
@@ -218,8 +220,8 @@ func ToggleRule(id string) error {
 }
 
 // GetActiveRules returns all active rules
-func GetActiveRules() ([]Rule, error) {
-	ruleSet, err := LoadRules()
+func GetActiveRules(l *ledger.Ledger) ([]Rule, error) {
+	ruleSet, err := LoadRules(l)
 	if err != nil {
 		return nil, err
 	}
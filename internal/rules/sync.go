@@ -1,39 +1,39 @@
 package rules
 
 import (
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io/fs"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
 )
 
 // SyncResult holds the results of a sync operation
 type SyncResult struct {
-	Added   int
-	Updated int
-	Skipped int
-	Errors  []string
+	Added   int      `json:"added" yaml:"added"`
+	Updated int      `json:"updated" yaml:"updated"`
+	Skipped int      `json:"skipped" yaml:"skipped"`
+	Errors  []string `json:"errors" yaml:"errors"`
 }
 
 // SyncRules syncs rules to month files for the current and future months
-func SyncRules() (*SyncResult, error) {
+func SyncRules(l *ledger.Ledger) (*SyncResult, error) {
 	result := &SyncResult{
 		Errors: []string{},
 	}
 
 	// Check if we're in a SpendGrid directory
-	if _, err := os.Stat(".spendgrid"); os.IsNotExist(err) {
+	if !l.Exists(ledger.StateDir) {
 		// Not a SpendGrid directory, skip silently
 		return result, nil
 	}
 
 	// Load all active rules
-	rules, err := GetActiveRules()
+	rules, err := GetActiveRules(l)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load rules: %v", err)
 	}
@@ -67,7 +67,7 @@ func SyncRules() (*SyncResult, error) {
 	month := currentMonth
 	for {
 		// Sync this month
-		r, err := syncMonth(year, month, rules)
+		r, err := syncMonth(l, year, month, rules)
 		if err != nil {
 			result.Errors = append(result.Errors, fmt.Sprintf("%04d-%02d: %v", year, month, err))
 		} else {
@@ -93,30 +93,16 @@ func SyncRules() (*SyncResult, error) {
 }
 
 // syncMonth syncs rules to a specific month file
-func syncMonth(year, month int, rules []Rule) (*SyncResult, error) {
+func syncMonth(l *ledger.Ledger, year, month int, rules []Rule) (*SyncResult, error) {
 	result := &SyncResult{}
 
-	// Build file path
-	monthFile := fmt.Sprintf("%02d.md", month)
-	yearDir := strconv.Itoa(year)
-	filePath := filepath.Join(yearDir, monthFile)
+	filePath := ledger.MonthPath(year, month)
 
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		// Create year directory if needed
-		if err := os.MkdirAll(yearDir, 0755); err != nil {
-			return nil, fmt.Errorf("failed to create year directory: %v", err)
-		}
-		// Create month file with default structure
-		content := fmt.Sprintf("# %d %s\n\n## ROWS\n\n## RULES\n",
-			year, getMonthName(month))
-		if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-			return nil, fmt.Errorf("failed to create month file: %v", err)
-		}
+	// Read file content, a missing month file starts with the default structure
+	content, err := l.ReadFile(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		content, err = []byte(ledger.NewMonthFile(year, month)), nil
 	}
-
-	// Read file content
-	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read month file: %v", err)
 	}
@@ -195,8 +181,12 @@ func syncMonth(year, month int, rules []Rule) (*SyncResult, error) {
 		}
 	}
 
-	// Write back to file
-	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+	// Write back to file, unless nothing changed
+	updated := strings.Join(lines, "\n")
+	if l.Exists(filePath) && updated == string(content) {
+		return result, nil
+	}
+	if err := l.WriteFile(filePath, []byte(updated)); err != nil {
 		return nil, fmt.Errorf("failed to write month file: %v", err)
 	}
 
@@ -288,22 +278,11 @@ func extractRuleID(line string) string {
 	return ""
 }
 
-func getMonthName(month int) string {
-	months := []string{
-		"", "Ocak", "Şubat", "Mart", "Nisan", "Mayıs", "Haziran",
-		"Temmuz", "Ağustos", "Eylül", "Ekim", "Kasım", "Aralık",
-	}
-	if month >= 1 && month <= 12 {
-		return months[month]
-	}
-	return ""
-}
-
 // UpdateRemainingAmounts updates the remaining amounts for all system-tagged rules
 // by matching transactions in the given month/year
-func UpdateRemainingAmounts(year, month int) error {
+func UpdateRemainingAmounts(l *ledger.Ledger, year, month int) error {
 	// Load all active rules
-	rules, err := GetActiveRules()
+	rules, err := GetActiveRules(l)
 	if err != nil {
 		return fmt.Errorf("failed to load rules: %v", err)
 	}
@@ -321,18 +300,14 @@ func UpdateRemainingAmounts(year, month int) error {
 	}
 
 	// Load month transactions
-	monthFile := fmt.Sprintf("%02d.md", month)
-	yearDir := strconv.Itoa(year)
-	filePath := filepath.Join(yearDir, monthFile)
-
-	content, err := os.ReadFile(filePath)
+	content, err := l.ReadFile(ledger.MonthPath(year, month))
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			// File doesn't exist, reset all remaining amounts
 			for _, rule := range systemRules {
 				rule.ResetRemainingAmount()
 			}
-			return SaveRules(&RuleSet{Rules: rules})
+			return SaveRules(l, &RuleSet{Rules: rules})
 		}
 		return fmt.Errorf("failed to read month file: %v", err)
 	}
@@ -364,7 +339,7 @@ func UpdateRemainingAmounts(year, month int) error {
 	}
 
 	// Save updated rules
-	return SaveRules(&RuleSet{Rules: rules})
+	return SaveRules(l, &RuleSet{Rules: rules})
 }
 
 // hasMatchingSystemTag checks if transaction tags match any system tags
@@ -0,0 +1,236 @@
+package search
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/adrg/xdg"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/pool"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// indexVersion is bumped when the index layout changes, older indexes are rebuilt
+const indexVersion = 1
+
+const backlogFile = "_pool/backlog.md"
+
+// Document is a single searchable row
+type Document struct {
+	File        string            `json:"file"`
+	Line        int               `json:"line"`
+	Year        int               `json:"year,omitempty"`
+	Month       int               `json:"month,omitempty"`
+	Day         int               `json:"day,omitempty"`
+	Description string            `json:"description"`
+	Amount      float64           `json:"amount"`
+	Currency    string            `json:"currency"`
+	Tags        []string          `json:"tags,omitempty"`
+	Projects    []string          `json:"projects,omitempty"`
+	Account     string            `json:"account,omitempty"`
+	Meta        map[string]string `json:"meta,omitempty"`
+}
+
+// fileEntry holds the documents of a file and the state it was indexed at
+type fileEntry struct {
+	ModTime int64       `json:"mod_time"`
+	Size    int64       `json:"size"`
+	Docs    []*Document `json:"docs"`
+}
+
+// Index is the on-disk search index of a ledger
+type Index struct {
+	Version int                   `json:"version"`
+	Root    string                `json:"root"`
+	Files   map[string]*fileEntry `json:"files"`
+}
+
+// GetIndexPath returns the index path of a ledger directory
+// Each ledger gets its own file, named after a hash of its absolute path
+func GetIndexPath(root string) string {
+	sum := sha1.Sum([]byte(root))
+	return filepath.Join(xdg.DataHome, "spendgrid", "search", hex.EncodeToString(sum[:8])+".json")
+}
+
+// LoadIndex loads the index of the current ledger
+// A missing, unreadable or outdated index gives an empty one
+func LoadIndex() (*Index, error) {
+	root, err := storage.Root()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current directory: %v", err)
+	}
+
+	empty := &Index{Version: indexVersion, Root: root, Files: make(map[string]*fileEntry)}
+
+	data, err := os.ReadFile(GetIndexPath(root))
+	if err != nil {
+		return empty, nil
+	}
+
+	var idx Index
+	if err := json.Unmarshal(data, &idx); err != nil || idx.Version != indexVersion || idx.Root != root || idx.Files == nil {
+		return empty, nil
+	}
+
+	return &idx, nil
+}
+
+// Save writes the index to disk
+// The index of an encrypted ledger is only kept in memory, an older one is removed
+func (idx *Index) Save() error {
+	path := GetIndexPath(idx.Root)
+	if vault.Enabled(idx.Root) {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove index: %v", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create index directory: %v", err)
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("failed to marshal index: %v", err)
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write index: %v", err)
+	}
+
+	return nil
+}
+
+// Refresh re-indexes files whose modification time or size changed and drops deleted files
+// Returns the number of files that were (re-)indexed or dropped
+func (idx *Index) Refresh() (int, error) {
+	files, err := ledgerFiles()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	seen := make(map[string]bool)
+	for _, file := range files {
+		info, err := storage.Stat(file)
+		if err != nil {
+			continue
+		}
+		seen[file] = true
+
+		entry := idx.Files[file]
+		if entry != nil && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() {
+			continue
+		}
+
+		docs, err := indexFile(file)
+		if err != nil {
+			return changed, err
+		}
+		idx.Files[file] = &fileEntry{ModTime: info.ModTime().UnixNano(), Size: info.Size(), Docs: docs}
+		changed++
+	}
+
+	for file := range idx.Files {
+		if !seen[file] {
+			delete(idx.Files, file)
+			changed++
+		}
+	}
+
+	return changed, nil
+}
+
+// Documents returns every indexed document
+func (idx *Index) Documents() []*Document {
+	var docs []*Document
+	for _, entry := range idx.Files {
+		docs = append(docs, entry.Docs...)
+	}
+	return docs
+}
+
+// ledgerFiles returns the month files of every year directory and the backlog
+func ledgerFiles() ([]string, error) {
+	years, err := ledger.Years()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, year := range years {
+		for month := 1; month <= 12; month++ {
+			path := ledger.MonthPath(year, month)
+			if _, err := storage.Stat(path); err == nil {
+				files = append(files, path)
+			}
+		}
+	}
+
+	if _, err := storage.Stat(backlogFile); err == nil {
+		files = append(files, backlogFile)
+	}
+
+	return files, nil
+}
+
+// indexFile parses the documents of a month file or the backlog
+func indexFile(file string) ([]*Document, error) {
+	if file == backlogFile {
+		items, err := pool.LoadItems(ledger.Current())
+		if err != nil {
+			return nil, err
+		}
+
+		docs := make([]*Document, 0, len(items))
+		for _, item := range items {
+			docs = append(docs, &Document{
+				File:        file,
+				Line:        item.LineNumber,
+				Year:        item.Year,
+				Month:       item.Month,
+				Description: item.Description,
+				Amount:      item.Amount,
+				Currency:    item.Currency,
+				Tags:        item.Tags,
+			})
+		}
+		return docs, nil
+	}
+
+	var year, month int
+	if _, err := fmt.Sscanf(filepath.ToSlash(file), "%d/%d.md", &year, &month); err != nil {
+		return nil, fmt.Errorf("unexpected month file %s", file)
+	}
+
+	content, err := storage.Peek(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", file, err)
+	}
+
+	parsed, _ := parser.ParseMonthFile(string(content))
+	docs := make([]*Document, 0, len(parsed))
+	for _, tx := range parsed {
+		docs = append(docs, &Document{
+			File:        file,
+			Line:        tx.LineNumber,
+			Year:        year,
+			Month:       month,
+			Day:         tx.Day,
+			Description: tx.Description,
+			Amount:      tx.Amount,
+			Currency:    tx.Currency,
+			Tags:        tx.Tags,
+			Projects:    tx.Projects,
+			Account:     tx.Account,
+			Meta:        tx.Meta,
+		})
+	}
+	return docs, nil
+}
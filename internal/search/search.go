@@ -0,0 +1,305 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/category"
+	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/output"
+)
+
+// Hit is a document that matched a search
+type Hit struct {
+	File        string   `json:"file" yaml:"file"`
+	Line        int      `json:"line" yaml:"line"`
+	Date        string   `json:"date" yaml:"date"` // YYYY-MM-DD, YYYY-MM for dated pool items, empty for undated ones
+	Description string   `json:"description" yaml:"description"`
+	Amount      float64  `json:"amount" yaml:"amount"`
+	Currency    string   `json:"currency" yaml:"currency"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Projects    []string `json:"projects,omitempty" yaml:"projects,omitempty"`
+	Account     string   `json:"account,omitempty" yaml:"account,omitempty"`
+	Score       float64  `json:"score" yaml:"score"`
+}
+
+// Location returns file:line of the hit
+func (h *Hit) Location() string {
+	return fmt.Sprintf("%s:%d", h.File, h.Line)
+}
+
+// Search refreshes the index of the current ledger and returns the best hits for text
+// Every word of text must match the description, a tag, a project, the account or a meta
+// key/value; #word, @word and &word only match tags, projects and the account
+// rebuild discards the index first; limit <= 0 returns all hits
+func Search(text string, limit int, rebuild bool) ([]*Hit, error) {
+	if err := ledger.EnsureInitialized(); err != nil {
+		return nil, err
+	}
+
+	terms := strings.Fields(text)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("search text cannot be empty")
+	}
+
+	idx, err := LoadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if rebuild {
+		idx.Files = make(map[string]*fileEntry)
+	}
+
+	changed, err := idx.Refresh()
+	if err != nil {
+		return nil, err
+	}
+	if changed > 0 {
+		// The index is only a cache, a failed save just means a rebuild next time
+		_ = idx.Save()
+	}
+
+	// Tags also match through their full category path, so #yemek finds #market
+	tree, err := category.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []*Hit{}
+	for _, doc := range idx.Documents() {
+		if score, ok := scoreDocument(doc, terms, tree); ok {
+			hits = append(hits, newHit(doc, score))
+		}
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Date != hits[j].Date {
+			return hits[i].Date > hits[j].Date
+		}
+		if hits[i].File != hits[j].File {
+			return hits[i].File < hits[j].File
+		}
+		return hits[i].Line < hits[j].Line
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+func newHit(doc *Document, score float64) *Hit {
+	date := ""
+	switch {
+	case doc.Day > 0:
+		date = fmt.Sprintf("%04d-%02d-%02d", doc.Year, doc.Month, doc.Day)
+	case doc.Month > 0:
+		date = fmt.Sprintf("%04d-%02d", doc.Year, doc.Month)
+	}
+
+	return &Hit{
+		File:        doc.File,
+		Line:        doc.Line,
+		Date:        date,
+		Description: doc.Description,
+		Amount:      doc.Amount,
+		Currency:    doc.Currency,
+		Tags:        doc.Tags,
+		Projects:    doc.Projects,
+		Account:     doc.Account,
+		Score:       float64(int(score*100+0.5)) / 100,
+	}
+}
+
+// Field weights, a description match ranks above a meta match
+const (
+	weightDescription = 1.0
+	weightLabel       = 0.9
+	weightMeta        = 0.7
+)
+
+// scoreDocument returns the average best score of the terms, false if a term does not match
+func scoreDocument(doc *Document, terms []string, tree *category.Tree) (float64, bool) {
+	tags := make([]string, 0, len(doc.Tags))
+	for _, tag := range doc.Tags {
+		tags = append(tags, tree.Resolve(tag))
+	}
+
+	total := 0.0
+	for _, term := range terms {
+		best := 0.0
+		consider := func(text string, weight float64) {
+			if s := matchScore(term, text) * weight; s > best {
+				best = s
+			}
+		}
+
+		switch term[0] {
+		case '#':
+			term = term[1:]
+			for _, tag := range tags {
+				consider(tag, weightLabel)
+			}
+		case '@':
+			term = term[1:]
+			for _, proj := range doc.Projects {
+				consider(proj, weightLabel)
+			}
+		case '&':
+			term = term[1:]
+			consider(doc.Account, weightLabel)
+		default:
+			consider(doc.Description, weightDescription)
+			for _, tag := range tags {
+				consider(tag, weightLabel)
+			}
+			for _, proj := range doc.Projects {
+				consider(proj, weightLabel)
+			}
+			consider(doc.Account, weightLabel)
+			for key, value := range doc.Meta {
+				consider(key, weightMeta)
+				consider(value, weightMeta)
+			}
+		}
+
+		if best == 0 {
+			return 0, false
+		}
+		total += best
+	}
+
+	return total / float64(len(terms)), true
+}
+
+// matchScore scores a term against a text between 0 (no match) and 1 (whole word)
+// Prefixes and substrings score lower, words within a small edit distance lower still
+func matchScore(term, text string) float64 {
+	term, text = fold(term), fold(text)
+	if term == "" || text == "" {
+		return 0
+	}
+
+	best := 0.0
+	for _, word := range strings.FieldsFunc(text, isSeparator) {
+		var s float64
+		switch {
+		case word == term:
+			s = 1
+		case strings.HasPrefix(word, term):
+			s = 0.9
+		case strings.Contains(word, term):
+			s = 0.8
+		default:
+			if d := distance(term, word); d <= maxDistance(term) {
+				s = 0.6 - 0.1*float64(d-1)
+			}
+		}
+		if s > best {
+			best = s
+		}
+	}
+
+	if best == 0 && strings.Contains(text, term) {
+		best = 0.7
+	}
+	return best
+}
+
+// maxDistance is the number of typos tolerated in a term
+func maxDistance(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// distance returns the Levenshtein distance of two strings
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// foldReplacer drops Turkish diacritics so "odeme" finds "ödeme"
+var foldReplacer = strings.NewReplacer(
+	"ç", "c", "ğ", "g", "ı", "i", "İ", "i", "ö", "o", "ş", "s", "ü", "u",
+	"Ç", "c", "Ğ", "g", "Ö", "o", "Ş", "s", "Ü", "u",
+)
+
+func fold(s string) string {
+	return strings.ToLower(foldReplacer.Replace(s))
+}
+
+func isSeparator(r rune) bool {
+	return r == ' ' || r == ':' || r == '-' || r == '_' || r == '/' || r == '.' || r == ','
+}
+
+// ShowResults prints search hits with their file:line
+func ShowResults(text string, hits []*Hit) {
+	fmt.Println()
+	if len(hits) == 0 {
+		fmt.Printf(i18n.T("search.no_results")+"\n", text)
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("%-20s %-10s  %-28s %12s %-4s  %s\n", "File", "Date", "Description", "Amount", "", "Tags")
+	fmt.Println(strings.Repeat("-", 94))
+	for _, h := range hits {
+		var labels []string
+		for _, tag := range h.Tags {
+			labels = append(labels, "#"+tag)
+		}
+		for _, proj := range h.Projects {
+			labels = append(labels, "@"+proj)
+		}
+		if h.Account != "" {
+			labels = append(labels, "&"+h.Account)
+		}
+
+		color.New(color.FgCyan).Printf("%-20s ", h.Location())
+		fmt.Printf("%-10s  %-28s %12.2f %-4s  %s\n",
+			h.Date, output.Truncate(h.Description, 28), h.Amount, h.Currency, strings.Join(labels, " "))
+	}
+	fmt.Println(strings.Repeat("-", 94))
+	fmt.Printf(i18n.T("search.results")+"\n", len(hits))
+	fmt.Println()
+}
@@ -3,72 +3,122 @@ package status
 import (
 	"fmt"
 	"os"
-	"path/filepath"
-	"strconv"
+	"sort"
 	"time"
 
+	"spendgrid/internal/config"
+	"spendgrid/internal/exchange"
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
 	"spendgrid/internal/rules"
 )
 
-// ShowStatus displays the current status of the spendgrid database
-func ShowStatus() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+// Summary is the status of the current month
+type Summary struct {
+	Year            int                `json:"year" yaml:"year"`
+	Month           int                `json:"month" yaml:"month"`
+	BaseCurrency    string             `json:"base_currency" yaml:"base_currency"`
+	Transactions    int                `json:"transactions" yaml:"transactions"`
+	IncomeCount     int                `json:"income_count" yaml:"income_count"`
+	ExpenseCount    int                `json:"expense_count" yaml:"expense_count"`
+	Income          float64            `json:"income" yaml:"income"`
+	Expenses        float64            `json:"expenses" yaml:"expenses"`
+	Net             float64            `json:"net" yaml:"net"`
+	NetByCurrency   map[string]float64 `json:"net_by_currency" yaml:"net_by_currency"`
+	MissingRates    int                `json:"missing_rates" yaml:"missing_rates"`
+	Planned         int                `json:"planned" yaml:"planned"`
+	PlannedIncome   float64            `json:"planned_income" yaml:"planned_income"`
+	PlannedExpenses float64            `json:"planned_expenses" yaml:"planned_expenses"`
+	Tags            int                `json:"tags" yaml:"tags"`
+	Projects        int                `json:"projects" yaml:"projects"`
+	ActiveRules     int                `json:"active_rules" yaml:"active_rules"`
+	UnparsedLines   int                `json:"unparsed_lines" yaml:"unparsed_lines"`
+	RatesCached     bool               `json:"rates_cached" yaml:"rates_cached"`
+}
+
+// BuildStatus collects the status of the current month
+func BuildStatus(l *ledger.Ledger) (*Summary, error) {
+	if err := l.EnsureInitialized(); err != nil {
+		return nil, err
 	}
 
 	now := time.Now()
-	year := strconv.Itoa(now.Year())
 	currentMonth := int(now.Month())
 
+	summary := &Summary{
+		Year:          now.Year(),
+		Month:         currentMonth,
+		BaseCurrency:  config.LedgerBaseCurrency(l),
+		NetByCurrency: make(map[string]float64),
+	}
+
 	// Get active rules count
-	activeRules, err := rules.GetActiveRules()
-	rulesCount := 0
-	if err == nil {
-		rulesCount = len(activeRules)
+	if activeRules, err := rules.GetActiveRules(l); err == nil {
+		summary.ActiveRules = len(activeRules)
 	}
 
 	// Count transactions this month
-	monthFile := parser.GetMonthFile(currentMonth)
-	filePath := filepath.Join(year, monthFile)
-
-	var txCount, incomeCount, expenseCount int
-	var plannedCount int
-	var totalIncome, totalExpense float64
-	var plannedIncome, plannedExpense float64
-
-	content, err := os.ReadFile(filePath)
+	parsed, unparsed, err := l.LoadMonth(now.Year(), currentMonth)
 	if err == nil {
-		parsed, _ := parser.ParseMonthFile(string(content))
-
 		for _, tx := range parsed {
+			// Convert to base currency at the transaction date (or its @rate)
+			date := time.Date(now.Year(), now.Month(), tx.Day, 0, 0, 0, 0, time.UTC)
+			inBase, err := exchange.ConvertTransaction(tx, summary.BaseCurrency, date)
+			if err != nil {
+				summary.MissingRates++
+				inBase = 0
+			}
+
 			// Count uncompleted rules separately
 			if tx.IsRule && !tx.Completed {
-				plannedCount++
+				summary.Planned++
 				if tx.IsIncome() {
-					plannedIncome += tx.Amount
+					summary.PlannedIncome += inBase
 				} else {
-					plannedExpense += -tx.Amount
+					summary.PlannedExpenses += -inBase
 				}
 				continue
 			}
 
 			// Count completed transactions and non-rule transactions
-			txCount++
+			summary.Transactions++
+			summary.NetByCurrency[tx.Currency] += tx.Amount
 			if tx.IsIncome() {
-				incomeCount++
-				totalIncome += tx.Amount
+				summary.IncomeCount++
+				summary.Income += inBase
 			} else {
-				expenseCount++
-				totalExpense += -tx.Amount
+				summary.ExpenseCount++
+				summary.Expenses += -inBase
 			}
 		}
 	}
+	summary.Net = summary.Income - summary.Expenses
 
 	// Count categories and projects
-	tagsCount := countUniqueTags(year, currentMonth)
-	projectsCount := countUniqueProjects(year, currentMonth)
+	summary.Tags = countUniqueTags(parsed)
+	summary.Projects = countUniqueProjects(parsed)
+	summary.UnparsedLines = len(unparsed)
+
+	// Check exchange rates
+	_, err = os.Stat(exchange.GetCachePath())
+	summary.RatesCached = err == nil
+
+	return summary, nil
+}
+
+// ShowStatus displays the current status of the spendgrid database
+func ShowStatus() error {
+	return showStatus(ledger.Current())
+}
+
+func showStatus(l *ledger.Ledger) error {
+	summary, err := BuildStatus(l)
+	if err != nil {
+		return err
+	}
+
+	baseCurrency := summary.BaseCurrency
 
 	// Print status
 	fmt.Println()
@@ -76,45 +126,56 @@ func ShowStatus() error {
 	fmt.Println("========================================")
 	fmt.Println()
 
-	fmt.Printf("📅 Current Period: %s %d\n", time.Month(currentMonth), now.Year())
+	fmt.Printf("📅 Current Period: %s %d\n", time.Month(summary.Month), summary.Year)
 	fmt.Println()
 
 	fmt.Println("📊 Completed Transactions:")
-	fmt.Printf("   Total: %d (Income: %d, Expense: %d)\n", txCount, incomeCount, expenseCount)
-	fmt.Printf("   Total Income:  %.2f\n", totalIncome)
-	fmt.Printf("   Total Expense: %.2f\n", totalExpense)
-	fmt.Printf("   Net:           %.2f\n", totalIncome-totalExpense)
+	fmt.Printf("   Total: %d (Income: %d, Expense: %d)\n", summary.Transactions, summary.IncomeCount, summary.ExpenseCount)
+	fmt.Printf("   Total Income:  %.2f %s\n", summary.Income, baseCurrency)
+	fmt.Printf("   Total Expense: %.2f %s\n", summary.Expenses, baseCurrency)
+	fmt.Printf("   Net:           %.2f %s\n", summary.Net, baseCurrency)
+	if len(summary.NetByCurrency) > 1 {
+		currencies := make([]string, 0, len(summary.NetByCurrency))
+		for curr := range summary.NetByCurrency {
+			currencies = append(currencies, curr)
+		}
+		sort.Strings(currencies)
+		for _, curr := range currencies {
+			fmt.Printf("     %-4s net:    %.2f\n", curr, summary.NetByCurrency[curr])
+		}
+	}
+	if summary.MissingRates > 0 {
+		fmt.Printf("   ⚠️  %d row(s) without exchange rate excluded\n", summary.MissingRates)
+	}
 	fmt.Println()
 
-	if plannedCount > 0 {
+	if summary.Planned > 0 {
 		fmt.Println("📅 Planned (Uncompleted Rules):")
-		fmt.Printf("   Total: %d\n", plannedCount)
-		fmt.Printf("   Expected Income:  %.2f\n", plannedIncome)
-		fmt.Printf("   Expected Expense: %.2f\n", plannedExpense)
-		fmt.Printf("   Expected Net:     %.2f\n", plannedIncome-plannedExpense)
+		fmt.Printf("   Total: %d\n", summary.Planned)
+		fmt.Printf("   Expected Income:  %.2f %s\n", summary.PlannedIncome, baseCurrency)
+		fmt.Printf("   Expected Expense: %.2f %s\n", summary.PlannedExpenses, baseCurrency)
+		fmt.Printf("   Expected Net:     %.2f %s\n", summary.PlannedIncome-summary.PlannedExpenses, baseCurrency)
 		fmt.Println()
 	}
 
 	fmt.Println("🏷️ Categories:")
-	fmt.Printf("   Active Tags: %d\n", tagsCount)
-	fmt.Printf("   Active Projects: %d\n", projectsCount)
+	fmt.Printf("   Active Tags: %d\n", summary.Tags)
+	fmt.Printf("   Active Projects: %d\n", summary.Projects)
 	fmt.Println()
 
 	fmt.Println("⚙️ Rules:")
-	fmt.Printf("   Active Rules: %d\n", rulesCount)
+	fmt.Printf("   Active Rules: %d\n", summary.ActiveRules)
 	fmt.Println()
 
 	// Check for unparsed lines
-	unparsedCount := countUnparsedLines(year, currentMonth)
-	if unparsedCount > 0 {
-		fmt.Printf("⚠️  Warning: %d unparsed lines in current month\n", unparsedCount)
+	if summary.UnparsedLines > 0 {
+		fmt.Printf("⚠️  Warning: %d unparsed lines in current month\n", summary.UnparsedLines)
 		fmt.Println("   Run 'spendgrid validate' for details")
 		fmt.Println()
 	}
 
 	// Check exchange rates
-	cachePath := filepath.Join(os.Getenv("HOME"), ".local", "share", "spendgrid", "data", "exchange_rates.json")
-	if _, err := os.Stat(cachePath); os.IsNotExist(err) {
+	if !summary.RatesCached {
 		fmt.Println("💱 Exchange rates: Not cached")
 		fmt.Println("   Run 'spendgrid exchange refresh' to update")
 	} else {
@@ -128,17 +189,7 @@ func ShowStatus() error {
 	return nil
 }
 
-func countUniqueTags(year string, month int) int {
-	monthFile := parser.GetMonthFile(month)
-	filePath := filepath.Join(year, monthFile)
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return 0
-	}
-
-	parsed, _ := parser.ParseMonthFile(string(content))
-
+func countUniqueTags(parsed []*parser.Transaction) int {
 	tagSet := make(map[string]bool)
 	for _, tx := range parsed {
 		for _, tag := range tx.Tags {
@@ -149,17 +200,7 @@ func countUniqueTags(year string, month int) int {
 	return len(tagSet)
 }
 
-func countUniqueProjects(year string, month int) int {
-	monthFile := parser.GetMonthFile(month)
-	filePath := filepath.Join(year, monthFile)
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return 0
-	}
-
-	parsed, _ := parser.ParseMonthFile(string(content))
-
+func countUniqueProjects(parsed []*parser.Transaction) int {
 	projectSet := make(map[string]bool)
 	for _, tx := range parsed {
 		for _, proj := range tx.Projects {
@@ -170,37 +211,7 @@ func countUniqueProjects(year string, month int) int {
 	return len(projectSet)
 }
 
-func countUnparsedLines(year string, month int) int {
-	monthFile := parser.GetMonthFile(month)
-	filePath := filepath.Join(year, monthFile)
-
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return 0
-	}
-
-	_, unparsed := parser.ParseMonthFile(string(content))
-	return len(unparsed)
-}
-
 // ShowStatusForPath displays the status for a specific directory path
 func ShowStatusForPath(dirPath string) error {
-	// Save current directory
-	originalDir, err := os.Getwd()
-	if err != nil {
-		return fmt.Errorf("failed to get current directory: %v", err)
-	}
-
-	// Change to target directory
-	if err := os.Chdir(dirPath); err != nil {
-		return fmt.Errorf("failed to change directory: %v", err)
-	}
-
-	// Show status
-	err = ShowStatus()
-
-	// Change back to original directory
-	os.Chdir(originalDir)
-
-	return err
+	return showStatus(ledger.Open(dirPath))
 }
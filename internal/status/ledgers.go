@@ -0,0 +1,123 @@
+package status
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/config"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/reports"
+)
+
+// LedgerStatus is the summary of one registered ledger
+type LedgerStatus struct {
+	Name            string  `json:"name" yaml:"name"`
+	Path            string  `json:"path" yaml:"path"`
+	Default         bool    `json:"default" yaml:"default"`
+	BaseCurrency    string  `json:"base_currency" yaml:"base_currency"`
+	Income          float64 `json:"income" yaml:"income"`     // Completed income this month
+	Expenses        float64 `json:"expenses" yaml:"expenses"` // Completed expenses this month
+	Net             float64 `json:"net" yaml:"net"`
+	PlannedExpenses float64 `json:"planned_expenses" yaml:"planned_expenses"`
+	YearIncome      float64 `json:"year_income" yaml:"year_income"`
+	YearExpenses    float64 `json:"year_expenses" yaml:"year_expenses"`
+	YearNet         float64 `json:"year_net" yaml:"year_net"`
+	MissingRates    int     `json:"missing_rates" yaml:"missing_rates"`
+	Error           string  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// BuildLedgerStatuses summarizes the current month and year of every registered ledger
+// A ledger that cannot be read is reported with its error instead of failing the rest
+func BuildLedgerStatuses(ledgers []config.NamedLedger) ([]*LedgerStatus, error) {
+	statuses := make([]*LedgerStatus, 0, len(ledgers))
+	for _, named := range ledgers {
+		st := &LedgerStatus{Name: named.Name, Path: named.Path, Default: named.Default}
+		statuses = append(statuses, st)
+
+		l := ledger.Open(named.Path)
+		summary, err := BuildStatus(l)
+		if err != nil {
+			st.Error = err.Error()
+			continue
+		}
+
+		yearly := reports.BuildYearlyReport(l, summary.Year)
+
+		st.BaseCurrency = summary.BaseCurrency
+		st.Income = summary.Income
+		st.Expenses = summary.Expenses
+		st.Net = summary.Net
+		st.PlannedExpenses = summary.PlannedExpenses
+		st.YearIncome = yearly.BaseTotalIncome
+		st.YearExpenses = yearly.BaseTotalExpenses
+		st.YearNet = yearly.BaseTotalIncome - yearly.BaseTotalExpenses
+		st.MissingRates = yearly.MissingRates
+	}
+
+	return statuses, nil
+}
+
+// ShowLedgerStatuses prints the ledgers side by side with totals per base currency
+func ShowLedgerStatuses(statuses []*LedgerStatus) {
+	now := time.Now()
+
+	fmt.Println()
+	color.Cyan("Ledgers - %s %d", now.Month(), now.Year())
+	fmt.Println(strings.Repeat("=", 100))
+	fmt.Printf("%-14s %-5s %13s %13s %13s %13s %13s %13s\n",
+		"Ledger", "Base", "Income", "Expense", "Net", "Year income", "Year expense", "Year net")
+	fmt.Println(strings.Repeat("-", 100))
+
+	totals := make(map[string]*LedgerStatus)
+	for _, st := range statuses {
+		name := st.Name
+		if st.Default {
+			name += " *"
+		}
+
+		if st.Error != "" {
+			color.Red("%-14s %s", name, st.Error)
+			continue
+		}
+
+		fmt.Printf("%-14s %-5s %13.2f %13.2f %13.2f %13.2f %13.2f %13.2f\n",
+			name, st.BaseCurrency, st.Income, st.Expenses, st.Net, st.YearIncome, st.YearExpenses, st.YearNet)
+		if st.MissingRates > 0 {
+			color.Yellow("%-14s ⚠️  %d row(s) without exchange rate excluded", "", st.MissingRates)
+		}
+
+		total, ok := totals[st.BaseCurrency]
+		if !ok {
+			total = &LedgerStatus{BaseCurrency: st.BaseCurrency}
+			totals[st.BaseCurrency] = total
+		}
+		total.Income += st.Income
+		total.Expenses += st.Expenses
+		total.Net += st.Net
+		total.YearIncome += st.YearIncome
+		total.YearExpenses += st.YearExpenses
+		total.YearNet += st.YearNet
+	}
+
+	// Ledgers with different base currencies are not added together
+	if len(totals) > 0 {
+		currencies := make([]string, 0, len(totals))
+		for curr := range totals {
+			currencies = append(currencies, curr)
+		}
+		sort.Strings(currencies)
+
+		fmt.Println(strings.Repeat("-", 100))
+		for _, curr := range currencies {
+			t := totals[curr]
+			color.New(color.Bold).Printf("%-14s %-5s %13.2f %13.2f %13.2f %13.2f %13.2f %13.2f\n",
+				"TOTAL", curr, t.Income, t.Expenses, t.Net, t.YearIncome, t.YearExpenses, t.YearNet)
+		}
+	}
+	fmt.Println(strings.Repeat("=", 100))
+	fmt.Println("* default ledger")
+	fmt.Println()
+}
@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"spendgrid/internal/vault"
+)
+
+// EncryptedExt is appended to the name of a file stored encrypted, e.g. 2026/10.md.enc
+const EncryptedExt = ".enc"
+
+// location is where a file is stored
+type location struct {
+	path string // path on disk
+	root string // ledger root, set if the file is encrypted
+	name string // slash-separated name inside the ledger, bound to the ciphertext
+}
+
+// locate returns where a file is stored
+// In an encrypted ledger, Markdown files, _config files and journal entries are kept as <name>.enc
+func locate(path string) location {
+	loc := location{path: path}
+	if !strings.HasSuffix(path, ".md") && !strings.HasSuffix(path, ".json") && !strings.HasSuffix(path, ".yml") {
+		return loc
+	}
+
+	root := vault.Root(path)
+	if root == "" || !vault.Enabled(root) {
+		return loc
+	}
+	name, ok := ledgerName(root, path)
+	if !ok || !encryptable(name) {
+		return loc
+	}
+	// _config files of a ledger encrypted by an older version stay plain until
+	// spendgrid encrypt is run again
+	if strings.HasPrefix(name, "_config/") && isPlainLeftover(path) {
+		return loc
+	}
+
+	loc.path = path + EncryptedExt
+	loc.root = root
+	loc.name = name
+	return loc
+}
+
+// ledgerName returns the slash-separated name of a file inside the ledger in root
+func ledgerName(root, path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// encryptable returns true for the files an encrypted ledger keeps encrypted
+func encryptable(name string) bool {
+	if strings.HasSuffix(name, ".md") {
+		return true
+	}
+	if dir, file := path.Split(name); dir == "_config/" && strings.HasSuffix(file, ".yml") {
+		return true
+	}
+	return strings.HasPrefix(name, stateDir+"/journal/") && strings.HasSuffix(name, ".json")
+}
+
+// isPlainLeftover returns true if a file exists only as a plain copy
+func isPlainLeftover(path string) bool {
+	if _, err := os.Stat(path + EncryptedExt); err == nil {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// decode returns the content of a file as read from disk
+func (loc location) decode(data []byte) ([]byte, error) {
+	if loc.root == "" {
+		return data, nil
+	}
+	key, err := vault.Unlock(loc.root)
+	if err != nil {
+		return nil, err
+	}
+	return vault.Open(key, loc.name, data)
+}
+
+// encode returns the content of a file as written to disk
+func (loc location) encode(data []byte) ([]byte, error) {
+	if loc.root == "" {
+		return data, nil
+	}
+	key, err := vault.Unlock(loc.root)
+	if err != nil {
+		return nil, err
+	}
+	return vault.Seal(key, loc.name, data)
+}
+
+// Peek reads a file like ReadFile, without remembering its content for a later write
+func Peek(path string) ([]byte, error) {
+	loc := locate(Path(path))
+	data, err := os.ReadFile(loc.path)
+	if err != nil {
+		return nil, err
+	}
+	return loc.decode(data)
+}
+
+// StoredPath returns the path a file is kept at on disk, with EncryptedExt in an encrypted ledger
+func StoredPath(path string) string {
+	return locate(Path(path)).path
+}
+
+// Stat returns the file info of a file, of its encrypted copy in an encrypted ledger
+func Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(locate(Path(path)).path)
+}
+
+// Remove removes a file, or its encrypted copy in an encrypted ledger
+func Remove(path string) error {
+	path = Path(path)
+	loc := locate(path)
+	if tracking() {
+		data, err := os.ReadFile(loc.path)
+		touchStored(path, loc, data, err == nil)
+	}
+	mu.Lock()
+	delete(stamps, filepath.Clean(path))
+	mu.Unlock()
+	return os.Remove(loc.path)
+}
+
+// ReadDir lists a directory, encrypted files are listed under their plain name
+func ReadDir(dir string) ([]fs.DirEntry, error) {
+	dir = Path(dir)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	root := vault.Root(filepath.Join(dir, "x"))
+	if root == "" || !vault.Enabled(root) {
+		return entries, nil
+	}
+	for i, e := range entries {
+		plain := strings.TrimSuffix(e.Name(), EncryptedExt)
+		if e.IsDir() || plain == e.Name() {
+			continue
+		}
+		if name, ok := ledgerName(root, filepath.Join(dir, plain)); ok && encryptable(name) {
+			entries[i] = renamedEntry{DirEntry: e, name: plain}
+		}
+	}
+	return entries, nil
+}
+
+// renamedEntry is a directory entry listed under another name
+type renamedEntry struct {
+	fs.DirEntry
+	name string
+}
+
+func (e renamedEntry) Name() string {
+	return e.name
+}
+
+// EncryptLedger encrypts the ledger in the root directory with a passphrase
+// Every file is written encrypted before the plain copies are removed, so an
+// interrupted run leaves the plain ledger as it was
+// On a ledger that is already encrypted, the files still kept plain are encrypted
+// with its key, e.g. _config files of a ledger encrypted by an older version
+// Returns the number of files encrypted
+func EncryptLedger(passphrase string) (int, error) {
+	if err := Lock(); err != nil {
+		return 0, err
+	}
+	defer Unlock()
+
+	root, err := Root()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current directory: %v", err)
+	}
+
+	names, err := ledgerFiles(root, false)
+	if err != nil {
+		return 0, err
+	}
+
+	if vault.Enabled(root) {
+		if len(names) == 0 {
+			return 0, fmt.Errorf("ledger is already encrypted")
+		}
+		key, err := vault.Unlock(root)
+		if err != nil {
+			return 0, err
+		}
+		if err := sealFiles(root, names, key); err != nil {
+			return 0, err
+		}
+		forgetStamps()
+		return removePlain(root, names)
+	}
+
+	settings, key, err := vault.NewSettings(passphrase)
+	if err != nil {
+		return 0, err
+	}
+	if err := sealFiles(root, names, key); err != nil {
+		return 0, err
+	}
+
+	if err := settings.Save(root); err != nil {
+		return 0, err
+	}
+	vault.Remember(root, key)
+	forgetStamps()
+
+	return removePlain(root, names)
+}
+
+// sealFiles writes an encrypted copy of each plain file next to it
+func sealFiles(root string, names []string, key []byte) error {
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		sealed, err := vault.Seal(key, name, data)
+		if err != nil {
+			return err
+		}
+		if err := replace(path+EncryptedExt, sealed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removePlain removes the plain copies of encrypted files, returns the number of files encrypted
+func removePlain(root string, names []string) (int, error) {
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			return len(names), fmt.Errorf("encrypted, but failed to remove plain %s: %v", name, err)
+		}
+	}
+	return len(names), nil
+}
+
+// DecryptLedger turns the encrypted ledger in the root directory back into plain files
+// Returns the number of files decrypted
+func DecryptLedger() (int, error) {
+	if err := Lock(); err != nil {
+		return 0, err
+	}
+	defer Unlock()
+
+	root, err := Root()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current directory: %v", err)
+	}
+	if !vault.Enabled(root) {
+		return 0, fmt.Errorf("ledger is not encrypted")
+	}
+
+	names, err := ledgerFiles(root, true)
+	if err != nil {
+		return 0, err
+	}
+	key, err := vault.Unlock(root)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		data, err := os.ReadFile(path + EncryptedExt)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		plain, err := vault.Open(key, name, data)
+		if err != nil {
+			return 0, err
+		}
+		if err := replace(path, plain); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := vault.Disable(root); err != nil {
+		return 0, err
+	}
+	forgetStamps()
+
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(root, filepath.FromSlash(name)) + EncryptedExt); err != nil {
+			return len(names), fmt.Errorf("decrypted, but failed to remove %s%s: %v", name, EncryptedExt, err)
+		}
+	}
+	return len(names), nil
+}
+
+// ledgerFiles returns the names of the files to convert in the ledger in root,
+// the plain ones or, if encrypted is set, the ones stored with EncryptedExt
+func ledgerFiles(root string, encrypted bool) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Temporary files of a write in progress
+		if strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		name, ok := ledgerName(root, path)
+		if !ok {
+			return nil
+		}
+		if encrypted {
+			if !strings.HasSuffix(name, EncryptedExt) {
+				return nil
+			}
+			name = strings.TrimSuffix(name, EncryptedExt)
+		}
+		if encryptable(name) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger files: %v", err)
+	}
+	return names, nil
+}
+
+// replace writes a file atomically, without the checks of WriteFiles
+func replace(path string, data []byte) error {
+	tmp, err := writeTemp(path, data, 0644)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// forgetStamps drops the remembered content of every file after a ledger was converted
+func forgetStamps() {
+	mu.Lock()
+	stamps = make(map[string][sha256.Size]byte)
+	mu.Unlock()
+}
+
+// WriteStored replaces a file with data as it is kept on disk, without encrypting it
+// Used to put back files from a backup
+func WriteStored(path string, data []byte) error {
+	path = Path(path)
+	mu.Lock()
+	delete(stamps, filepath.Clean(path))
+	mu.Unlock()
+	return replace(path, data)
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stateDir is the ledger state directory, the lock file lives inside it
+const stateDir = ".spendgrid"
+
+// lockTimeout is how long to wait for another process to release the ledger
+const lockTimeout = 10 * time.Second
+
+var (
+	lockMu    sync.Mutex
+	lockDepth int
+	lockFile  *os.File
+)
+
+// Lock takes the advisory lock of the ledger in the root directory
+// Calls nest: the lock is released by the last matching Unlock
+// Outside a ledger, or in a ledger without a state directory, nothing is locked
+func Lock() error {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	if lockDepth > 0 {
+		lockDepth++
+		return nil
+	}
+
+	if info, err := os.Stat(Path(stateDir)); err != nil || !info.IsDir() {
+		lockDepth++
+		return nil
+	}
+
+	f, err := os.OpenFile(Path(filepath.Join(stateDir, "lock")), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open ledger lock: %v", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLock(f)
+		if err != nil {
+			f.Close()
+			return fmt.Errorf("failed to lock ledger: %v", err)
+		}
+		if locked {
+			break
+		}
+		if time.Now().After(deadline) {
+			f.Close()
+			return fmt.Errorf("ledger is locked by another spendgrid process")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	lockFile = f
+	lockDepth++
+	return nil
+}
+
+// Unlock releases the lock taken by Lock
+func Unlock() {
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	if lockDepth == 0 {
+		return
+	}
+	lockDepth--
+	if lockDepth == 0 && lockFile != nil {
+		unlock(lockFile)
+		lockFile.Close()
+		lockFile = nil
+	}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// rootDir is the ledger directory relative paths are resolved against, empty for the current directory
+var rootDir string
+
+// SetRoot makes relative paths resolve against dir instead of the current directory
+func SetRoot(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %v", dir, err)
+	}
+	rootDir = abs
+	return nil
+}
+
+// Root returns the absolute path of the directory relative paths are resolved against
+func Root() (string, error) {
+	if rootDir != "" {
+		return rootDir, nil
+	}
+	return os.Getwd()
+}
+
+// Path resolves a relative path against the root set with SetRoot
+func Path(path string) string {
+	if rootDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(rootDir, path)
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ErrChanged is returned when a file changed on disk since it was read
+var ErrChanged = errors.New("changed on disk since it was read, run the command again")
+
+// File is the new content of a file written by WriteFiles
+type File struct {
+	Path    string
+	Data    []byte
+	Deleted bool // remove the file instead of writing it
+}
+
+var (
+	mu     sync.Mutex
+	stamps = make(map[string][sha256.Size]byte) // content hash of files as last read or written
+)
+
+// ReadFile reads a file and remembers its content, so a later write can tell
+// whether another process changed it in between
+// Files of an encrypted ledger are decrypted
+func ReadFile(path string) ([]byte, error) {
+	path = Path(path)
+	loc := locate(path)
+	data, err := os.ReadFile(loc.path)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	stamps[filepath.Clean(path)] = sha256.Sum256(data)
+	mu.Unlock()
+	return loc.decode(data)
+}
+
+// WriteFile replaces a file atomically while holding the ledger lock
+// It fails with ErrChanged if the file was read with ReadFile and changed on disk since
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFiles([]File{{Path: path, Data: data}}, perm)
+}
+
+// WriteFiles replaces several files while holding the ledger lock
+// All files are checked and written to temporary files first, then renamed into
+// place; if a rename fails, the files already replaced are put back
+// Files of an encrypted ledger are encrypted
+func WriteFiles(files []File, perm os.FileMode) error {
+	if err := Lock(); err != nil {
+		return err
+	}
+	defer Unlock()
+
+	files = append([]File(nil), files...)
+	for i := range files {
+		files[i].Path = Path(files[i].Path)
+	}
+
+	locs := make([]location, len(files))
+	stored := make([][]byte, len(files))
+	previous := make([][]byte, len(files))
+	existed := make([]bool, len(files))
+	for i, f := range files {
+		locs[i] = locate(f.Path)
+		if err := checkUnchanged(f.Path, locs[i].path); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(locs[i].path)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read %s: %v", f.Path, err)
+		}
+		previous[i], existed[i] = data, err == nil
+		touchStored(f.Path, locs[i], data, existed[i])
+
+		if !f.Deleted {
+			if stored[i], err = locs[i].encode(f.Data); err != nil {
+				return err
+			}
+		}
+	}
+
+	temps := make([]string, len(files))
+	cleanup := func() {
+		for _, tmp := range temps {
+			if tmp != "" {
+				os.Remove(tmp)
+			}
+		}
+	}
+
+	for i, f := range files {
+		if f.Deleted {
+			continue
+		}
+		tmp, err := writeTemp(locs[i].path, stored[i], perm)
+		if err != nil {
+			cleanup()
+			return err
+		}
+		temps[i] = tmp
+	}
+
+	for i, f := range files {
+		var err error
+		if f.Deleted {
+			if err = os.Remove(locs[i].path); os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.Rename(temps[i], locs[i].path)
+			temps[i] = ""
+		}
+		if err != nil {
+			for j := range files[:i] {
+				restore(locs[j].path, previous[j], existed[j], perm)
+			}
+			cleanup()
+			return fmt.Errorf("failed to write %s: %v", f.Path, err)
+		}
+		syncDir(filepath.Dir(locs[i].path))
+	}
+
+	mu.Lock()
+	for i, f := range files {
+		if f.Deleted {
+			delete(stamps, filepath.Clean(f.Path))
+		} else {
+			stamps[filepath.Clean(f.Path)] = sha256.Sum256(stored[i])
+		}
+	}
+	mu.Unlock()
+	return nil
+}
+
+// checkUnchanged returns ErrChanged if a file read earlier no longer has the content that was read
+// stored is where the file is kept on disk
+func checkUnchanged(path, stored string) error {
+	mu.Lock()
+	stamp, ok := stamps[filepath.Clean(path)]
+	mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(stored)
+	if err != nil || sha256.Sum256(data) != stamp {
+		return fmt.Errorf("%s %w", path, ErrChanged)
+	}
+	return nil
+}
+
+// writeTemp writes data to a synced temporary file next to path
+func writeTemp(path string, data []byte, perm os.FileMode) (string, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %v", dir, err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), perm)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write %s: %v", path, err)
+	}
+
+	return f.Name(), nil
+}
+
+// restore puts back the previous content of a file after a failed write
+func restore(path string, data []byte, existed bool, perm os.FileMode) {
+	if !existed {
+		os.Remove(path)
+		return
+	}
+	if tmp, err := writeTemp(path, data, perm); err == nil {
+		os.Rename(tmp, path)
+	}
+}
+
+// syncDir flushes a directory so a rename survives a crash
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// Original is the content a file had before it was first written while tracked
+type Original struct {
+	Data    []byte // plain content, decrypted in an encrypted ledger
+	Existed bool
+}
+
+// Tracker collects the files written or removed while it is active
+type Tracker struct {
+	files map[string]Original
+}
+
+var (
+	trackMu  sync.Mutex
+	trackers = make(map[*Tracker]bool)
+)
+
+// Track starts collecting the files written or removed from now on
+func Track() *Tracker {
+	t := &Tracker{files: make(map[string]Original)}
+	trackMu.Lock()
+	trackers[t] = true
+	trackMu.Unlock()
+	return t
+}
+
+// Stop ends tracking and returns the original content of the touched files by path
+// Paths are as given to the write, resolved against the root
+func (t *Tracker) Stop() map[string]Original {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	delete(trackers, t)
+	return t.files
+}
+
+// tracking returns true if a Tracker is active
+func tracking() bool {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	return len(trackers) > 0
+}
+
+// touch hands the content of a file before a write to the active trackers
+// Only the first write of a file is kept
+func touch(path string, data []byte, existed bool) {
+	trackMu.Lock()
+	defer trackMu.Unlock()
+	path = filepath.Clean(path)
+	for t := range trackers {
+		if _, ok := t.files[path]; !ok {
+			t.files[path] = Original{Data: data, Existed: existed}
+		}
+	}
+}
+
+// touchStored hands the content of a file as stored on disk to the trackers before it is changed
+func touchStored(path string, loc location, stored []byte, existed bool) {
+	if !tracking() {
+		return
+	}
+	if !existed {
+		touch(path, nil, false)
+		return
+	}
+	if plain, err := loc.decode(stored); err == nil {
+		touch(path, plain, true)
+	}
+}
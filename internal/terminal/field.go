@@ -0,0 +1,100 @@
+package terminal
+
+import (
+	"strings"
+
+	"github.com/eiannone/keyboard"
+)
+
+// Field is a single line text input for full-screen views
+type Field struct {
+	text   []rune
+	cursor int
+}
+
+// NewField returns a field holding text with the cursor at its end
+func NewField(text string) *Field {
+	runes := []rune(text)
+	return &Field{text: runes, cursor: len(runes)}
+}
+
+// Value returns the text of the field
+func (f *Field) Value() string {
+	return string(f.text)
+}
+
+// HandleKey edits the field, returns false for keys it does not use (e.g. Enter and Esc)
+func (f *Field) HandleKey(char rune, key keyboard.Key) bool {
+	switch key {
+	case keyboard.KeyArrowLeft, keyboard.KeyCtrlB:
+		if f.cursor > 0 {
+			f.cursor--
+		}
+	case keyboard.KeyArrowRight, keyboard.KeyCtrlF:
+		if f.cursor < len(f.text) {
+			f.cursor++
+		}
+	case keyboard.KeyHome, keyboard.KeyCtrlA:
+		f.cursor = 0
+	case keyboard.KeyEnd, keyboard.KeyCtrlE:
+		f.cursor = len(f.text)
+	case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+		if f.cursor > 0 {
+			f.text = append(f.text[:f.cursor-1], f.text[f.cursor:]...)
+			f.cursor--
+		}
+	case keyboard.KeyDelete, keyboard.KeyCtrlD:
+		if f.cursor < len(f.text) {
+			f.text = append(f.text[:f.cursor], f.text[f.cursor+1:]...)
+		}
+	case keyboard.KeyCtrlU:
+		f.text = f.text[f.cursor:]
+		f.cursor = 0
+	case keyboard.KeyCtrlK:
+		f.text = f.text[:f.cursor]
+	case keyboard.KeySpace:
+		f.insert(' ')
+	default:
+		if char == 0 {
+			return false
+		}
+		f.insert(char)
+	}
+	return true
+}
+
+func (f *Field) insert(r rune) {
+	f.text = append(f.text[:f.cursor], append([]rune{r}, f.text[f.cursor:]...)...)
+	f.cursor++
+}
+
+// Render returns the field in width columns with the cursor shown in reverse video
+// Long text scrolls so that the cursor stays visible
+func (f *Field) Render(width int) string {
+	if width < 2 {
+		return ""
+	}
+	start := 0
+	if f.cursor >= width {
+		start = f.cursor - width + 1
+	}
+	end := start + width
+	if end > len(f.text)+1 {
+		end = len(f.text) + 1
+	}
+
+	var b strings.Builder
+	for i := start; i < end; i++ {
+		r := ' '
+		if i < len(f.text) {
+			r = f.text[i]
+		}
+		if i == f.cursor {
+			b.WriteString(Styled(string(r), Reverse))
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	b.WriteString(strings.Repeat(" ", width-(end-start)))
+	return b.String()
+}
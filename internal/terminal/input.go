@@ -0,0 +1,223 @@
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/eiannone/keyboard"
+)
+
+// ReadWithAutocomplete reads input with real-time autocomplete suggestions
+// prefix: "#" for tags, "@" for projects
+func ReadWithAutocomplete(prompt string, items []string, prefix string) (string, error) {
+	// Save terminal state and open keyboard
+	if err := keyboard.Open(); err != nil {
+		// Fallback to regular input if keyboard fails
+		fmt.Print(prompt)
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		return strings.TrimSpace(input), nil
+	}
+	defer keyboard.Close()
+
+	var input []rune
+	selectedIndex := -1
+	matches := []string{}
+	suggestionsShown := false
+
+	fmt.Print(prompt)
+
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyboard.KeyEnter:
+			// Clear suggestions if shown
+			if suggestionsShown {
+				clearSuggestions()
+			}
+			fmt.Println()
+			result := string(input)
+			if selectedIndex >= 0 && selectedIndex < len(matches) {
+				result = prefix + matches[selectedIndex]
+			}
+			return result, nil
+
+		case keyboard.KeyCtrlC, keyboard.KeyEsc:
+			if suggestionsShown {
+				clearSuggestions()
+			}
+			fmt.Println("\nCancelled")
+			return "", fmt.Errorf("cancelled")
+
+		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+				selectedIndex = -1
+				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+			}
+
+		case keyboard.KeyTab:
+			// Autocomplete with first match
+			if len(matches) > 0 {
+				input = []rune(prefix + matches[0])
+				selectedIndex = 0
+				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+			}
+
+		case keyboard.KeyArrowUp:
+			if selectedIndex > 0 {
+				selectedIndex--
+				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+			}
+
+		case keyboard.KeyArrowDown:
+			if selectedIndex < len(matches)-1 {
+				selectedIndex++
+				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+			}
+
+		case keyboard.KeySpace:
+			// Handle space character
+			input = append(input, ' ')
+			selectedIndex = -1
+			searchTerm := strings.TrimPrefix(string(input), prefix)
+			matches = FilterItems(items, searchTerm)
+			suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+
+		default:
+			// Regular character input
+			if char != 0 {
+				// Handle number keys 1-9 for quick selection
+				if char >= '1' && char <= '9' {
+					idx := int(char - '1')
+					if idx < len(matches) {
+						input = []rune(prefix + matches[idx])
+						selectedIndex = idx
+						suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+						continue
+					}
+				}
+
+				input = append(input, char)
+				selectedIndex = -1
+
+				// Filter matches based on input (without prefix)
+				searchTerm := strings.TrimPrefix(string(input), prefix)
+				matches = FilterItems(items, searchTerm)
+
+				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
+			}
+		}
+	}
+}
+
+// clearSuggestions clears the suggestions line from the terminal
+func clearSuggestions() {
+	// Move cursor down one line, clear it, move back up
+	fmt.Print("\n\033[K\033[F")
+}
+
+// updateDisplay updates the terminal display with current input and suggestions
+// Returns true if suggestions were shown
+func updateDisplay(prompt string, input []rune, matches []string, selectedIndex int, prefix string) bool {
+	// Clear current line and move cursor to beginning
+	fmt.Printf("\r\033[K")
+
+	// Print prompt and current input
+	fmt.Printf("%s%s", prompt, string(input))
+
+	// Show suggestions below
+	if len(matches) > 0 {
+		// Move to next line
+		fmt.Println()
+		// Clear the entire line
+		fmt.Printf("\033[2K")
+		// Move to beginning of line
+		fmt.Printf("\r")
+
+		// Show up to 5 matches
+		maxShow := 5
+		if len(matches) < maxShow {
+			maxShow = len(matches)
+		}
+
+		for i := 0; i < maxShow; i++ {
+			if i == selectedIndex {
+				// Inverted colors for selected item
+				fmt.Printf("\033[7m %d. %s%s \033[0m", i+1, prefix, matches[i])
+			} else {
+				fmt.Printf(" %d. %s%s", i+1, prefix, matches[i])
+			}
+		}
+
+		// Move cursor back up to input line
+		fmt.Printf("\033[F")
+		// Move cursor to end of input (after the prompt)
+		fmt.Printf("\033[%dC", len(prompt)+len(input))
+		return true
+	}
+	return false
+}
+
+// FilterItems returns items that contain the search term (case-insensitive)
+func FilterItems(items []string, searchTerm string) []string {
+	if searchTerm == "" {
+		return items
+	}
+
+	searchTerm = strings.ToLower(searchTerm)
+	var matches []string
+
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item), searchTerm) {
+			matches = append(matches, item)
+		}
+	}
+
+	return matches
+}
+
+// ReadLine reads a line of input using keyboard mode
+func ReadLine() (string, error) {
+	if err := keyboard.Open(); err != nil {
+		// Fallback to regular input
+		reader := bufio.NewReader(os.Stdin)
+		return reader.ReadString('\n')
+	}
+	defer keyboard.Close()
+
+	var input []rune
+	for {
+		char, key, err := keyboard.GetKey()
+		if err != nil {
+			return "", err
+		}
+
+		switch key {
+		case keyboard.KeyEnter:
+			fmt.Println()
+			return string(input), nil
+		case keyboard.KeyCtrlC, keyboard.KeyEsc:
+			return "", fmt.Errorf("cancelled")
+		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
+			if len(input) > 0 {
+				input = input[:len(input)-1]
+				fmt.Print("\b \b")
+			}
+		case keyboard.KeySpace:
+			input = append(input, ' ')
+			fmt.Print(" ")
+		default:
+			if char != 0 {
+				input = append(input, char)
+				fmt.Printf("%c", char)
+			}
+		}
+	}
+}
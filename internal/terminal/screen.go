@@ -0,0 +1,108 @@
+package terminal
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ANSI text attributes and colors for Styled
+const (
+	Bold    = "1"
+	Dim     = "2"
+	Reverse = "7"
+	Red     = "31"
+	Green   = "32"
+	Yellow  = "33"
+	Cyan    = "36"
+)
+
+// IsTerminal reports whether standard input and output are a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// Size returns the width and height of the terminal, 80x24 if it cannot be read
+func Size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// EnterFullScreen switches to the alternate screen and hides the cursor
+func EnterFullScreen() {
+	fmt.Print("\033[?1049h\033[?25l")
+}
+
+// LeaveFullScreen shows the cursor and returns to the normal screen
+func LeaveFullScreen() {
+	fmt.Print("\033[0m\033[?25h\033[?1049l")
+}
+
+// Styled wraps text in ANSI attributes, e.g. Styled("total", Bold, Green)
+func Styled(text string, attrs ...string) string {
+	if len(attrs) == 0 || text == "" {
+		return text
+	}
+	return "\033[" + strings.Join(attrs, ";") + "m" + text + "\033[0m"
+}
+
+// Fit pads or cuts plain text to exactly width columns, cut text ends with …
+func Fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	n := utf8.RuneCountInString(text)
+	if n <= width {
+		return text + strings.Repeat(" ", width-n)
+	}
+	runes := []rune(text)
+	return string(runes[:width-1]) + "…"
+}
+
+// FitRight is Fit with the text aligned to the right
+func FitRight(text string, width int) string {
+	n := utf8.RuneCountInString(text)
+	if n >= width {
+		return Fit(text, width)
+	}
+	return strings.Repeat(" ", width-n) + text
+}
+
+// Frame is the content of the whole screen, drawn at once to avoid flicker
+type Frame struct {
+	Width  int
+	Height int
+	lines  []string
+}
+
+// NewFrame returns an empty frame of the given size
+func NewFrame(width, height int) *Frame {
+	return &Frame{Width: width, Height: height, lines: make([]string, height)}
+}
+
+// Set sets line y, it is already fitted to the width and may hold ANSI attributes
+func (f *Frame) Set(y int, line string) {
+	if y >= 0 && y < f.Height {
+		f.lines[y] = line
+	}
+}
+
+// Draw writes the frame over the screen
+func (f *Frame) Draw() {
+	var b strings.Builder
+	b.WriteString("\033[H")
+	for y, line := range f.lines {
+		b.WriteString(line)
+		b.WriteString("\033[0m\033[K")
+		if y < len(f.lines)-1 {
+			b.WriteString("\r\n")
+		}
+	}
+	fmt.Print(b.String())
+}
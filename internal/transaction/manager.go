@@ -10,18 +10,22 @@ import (
 	"strings"
 	"time"
 
-	"github.com/eiannone/keyboard"
-
+	"spendgrid/internal/budget"
 	"spendgrid/internal/cache"
+	"spendgrid/internal/category"
+	"spendgrid/internal/config"
 	"spendgrid/internal/currency"
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/terminal"
 )
 
 // AddTransaction adds a new transaction interactively with real-time autocomplete
 func AddTransaction() error {
 	// Check if we're in a spendgrid directory
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -45,7 +49,7 @@ func AddTransaction() error {
 	// Ask for day (default to today)
 	today := time.Now().Day()
 	fmt.Printf("%s [%d]: ", i18n.T("transaction.day_prompt"), today)
-	dayStr, err := readSimpleLine()
+	dayStr, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading day: %v", err)
 	}
@@ -61,7 +65,7 @@ func AddTransaction() error {
 
 	// Ask for description
 	fmt.Println(i18n.T("transaction.description_prompt"))
-	desc, err := readSimpleLine()
+	desc, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading description: %v", err)
 	}
@@ -69,7 +73,7 @@ func AddTransaction() error {
 
 	// Ask for amount and currency
 	fmt.Println(i18n.T("transaction.amount_prompt"))
-	amountInput, err := readSimpleLine()
+	amountInput, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading amount: %v", err)
 	}
@@ -86,7 +90,7 @@ func AddTransaction() error {
 	// Ask for tags with real-time autocomplete
 	fmt.Println(i18n.T("transaction.tags_prompt") + " ")
 	fmt.Println("  (Type to filter, Tab to autocomplete, 1-9 to select, Enter to confirm)")
-	tagsInput, err := readWithAutocomplete("  > ", cacheStore.GetTags(), "#")
+	tagsInput, err := terminal.ReadWithAutocomplete("  > ", cacheStore.GetTags(), "#")
 	if err != nil {
 		return fmt.Errorf("error reading tags: %v", err)
 	}
@@ -95,7 +99,7 @@ func AddTransaction() error {
 	// Ask for projects with real-time autocomplete
 	fmt.Println(i18n.T("transaction.projects_prompt") + " ")
 	fmt.Println("  (Type to filter, Tab to autocomplete, 1-9 to select, Enter to confirm)")
-	projInput, err := readWithAutocomplete("  > ", cacheStore.GetProjects(), "@")
+	projInput, err := terminal.ReadWithAutocomplete("  > ", cacheStore.GetProjects(), "@")
 	if err != nil {
 		return fmt.Errorf("error reading projects: %v", err)
 	}
@@ -103,7 +107,7 @@ func AddTransaction() error {
 
 	// Ask for note (optional)
 	fmt.Println(i18n.T("transaction.note_prompt"))
-	note, err := readSimpleLine()
+	note, err := terminal.ReadLine()
 	if err != nil {
 		return fmt.Errorf("error reading note: %v", err)
 	}
@@ -124,251 +128,45 @@ func AddTransaction() error {
 		tx.Meta["NOTE"] = note
 	}
 
+	if err := checkTagsAndProjects(ledger.Current(), tags, projects); err != nil {
+		return err
+	}
+
 	// Add to file
 	if err := addTransactionToFile(filePath, tx); err != nil {
 		return err
 	}
 
 	// Auto-save tags and projects
-	if err := autoSaveTagsAndProjects(tags, projects); err != nil {
+	if err := autoSaveTagsAndProjects(ledger.Current(), tags, projects); err != nil {
 		// Non-fatal, just warn
 		fmt.Fprintf(os.Stderr, "Warning: could not auto-save tags: %v\n", err)
 	}
 
 	fmt.Println(i18n.T("transaction.add_success"))
+	budget.WarnIfOver(tx, time.Now().Year(), int(time.Now().Month()))
 	return nil
 }
 
-// readSimpleLine reads a line of input using keyboard mode (for consistency)
-func readSimpleLine() (string, error) {
-	if err := keyboard.Open(); err != nil {
-		// Fallback to regular input
-		reader := bufio.NewReader(os.Stdin)
-		return reader.ReadString('\n')
-	}
-	defer keyboard.Close()
-
-	var input []rune
-	for {
-		char, key, err := keyboard.GetKey()
-		if err != nil {
-			return "", err
-		}
-
-		switch key {
-		case keyboard.KeyEnter:
-			fmt.Println()
-			return string(input), nil
-		case keyboard.KeyCtrlC, keyboard.KeyEsc:
-			return "", fmt.Errorf("cancelled")
-		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				fmt.Print("\b \b")
-			}
-		case keyboard.KeySpace:
-			input = append(input, ' ')
-			fmt.Print(" ")
-		default:
-			if char != 0 {
-				input = append(input, char)
-				fmt.Printf("%c", char)
-			}
-		}
-	}
-}
-
-// readWithAutocomplete reads input with real-time autocomplete suggestions
-// prefix: "#" for tags, "@" for projects
-func readWithAutocomplete(prompt string, items []string, prefix string) (string, error) {
-	// Save terminal state and open keyboard
-	if err := keyboard.Open(); err != nil {
-		// Fallback to regular input if keyboard fails
-		fmt.Print(prompt)
-		reader := bufio.NewReader(os.Stdin)
-		input, _ := reader.ReadString('\n')
-		return strings.TrimSpace(input), nil
-	}
-	defer keyboard.Close()
-
-	var input []rune
-	selectedIndex := -1
-	matches := []string{}
-	suggestionsShown := false
-
-	fmt.Print(prompt)
-
-	for {
-		char, key, err := keyboard.GetKey()
-		if err != nil {
-			return "", err
-		}
-
-		switch key {
-		case keyboard.KeyEnter:
-			// Clear suggestions if shown
-			if suggestionsShown {
-				clearSuggestions()
-			}
-			fmt.Println()
-			result := string(input)
-			if selectedIndex >= 0 && selectedIndex < len(matches) {
-				result = prefix + matches[selectedIndex]
-			}
-			return result, nil
-
-		case keyboard.KeyCtrlC, keyboard.KeyEsc:
-			if suggestionsShown {
-				clearSuggestions()
-			}
-			fmt.Println("\nCancelled")
-			return "", fmt.Errorf("cancelled")
-
-		case keyboard.KeyBackspace, keyboard.KeyBackspace2:
-			if len(input) > 0 {
-				input = input[:len(input)-1]
-				selectedIndex = -1
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyTab:
-			// Autocomplete with first match
-			if len(matches) > 0 {
-				input = []rune(prefix + matches[0])
-				selectedIndex = 0
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyArrowUp:
-			if selectedIndex > 0 {
-				selectedIndex--
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeyArrowDown:
-			if selectedIndex < len(matches)-1 {
-				selectedIndex++
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-
-		case keyboard.KeySpace:
-			// Handle space character
-			input = append(input, ' ')
-			selectedIndex = -1
-			searchTerm := strings.TrimPrefix(string(input), prefix)
-			matches = filterItems(items, searchTerm)
-			suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-
-		default:
-			// Regular character input
-			if char != 0 {
-				// Handle number keys 1-9 for quick selection
-				if char >= '1' && char <= '9' {
-					idx := int(char - '1')
-					if idx < len(matches) {
-						input = []rune(prefix + matches[idx])
-						selectedIndex = idx
-						suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-						continue
-					}
-				}
-
-				input = append(input, char)
-				selectedIndex = -1
-
-				// Filter matches based on input (without prefix)
-				searchTerm := strings.TrimPrefix(string(input), prefix)
-				matches = filterItems(items, searchTerm)
-
-				suggestionsShown = updateDisplay(prompt, input, matches, selectedIndex, prefix)
-			}
-		}
-	}
-}
-
-// clearSuggestions clears the suggestions line from the terminal
-func clearSuggestions() {
-	// Move cursor down one line, clear it, move back up
-	fmt.Print("\n\033[K\033[F")
-}
-
-// updateDisplay updates the terminal display with current input and suggestions
-// Returns true if suggestions were shown
-func updateDisplay(prompt string, input []rune, matches []string, selectedIndex int, prefix string) bool {
-	// Clear current line and move cursor to beginning
-	fmt.Printf("\r\033[K")
-
-	// Print prompt and current input
-	fmt.Printf("%s%s", prompt, string(input))
-
-	// Show suggestions below
-	if len(matches) > 0 {
-		// Move to next line
-		fmt.Println()
-		// Clear the entire line
-		fmt.Printf("\033[2K")
-		// Move to beginning of line
-		fmt.Printf("\r")
-
-		// Show up to 5 matches
-		maxShow := 5
-		if len(matches) < maxShow {
-			maxShow = len(matches)
-		}
-
-		for i := 0; i < maxShow; i++ {
-			if i == selectedIndex {
-				// Inverted colors for selected item
-				fmt.Printf("\033[7m %d. %s%s \033[0m", i+1, prefix, matches[i])
-			} else {
-				fmt.Printf(" %d. %s%s", i+1, prefix, matches[i])
-			}
-		}
-
-		// Move cursor back up to input line
-		fmt.Printf("\033[F")
-		// Move cursor to end of input (after the prompt)
-		fmt.Printf("\033[%dC", len(prompt)+len(input))
-		return true
-	}
-	return false
-}
-
-// filterItems returns items that contain the search term (case-insensitive)
-func filterItems(items []string, searchTerm string) []string {
-	if searchTerm == "" {
-		return items
-	}
-
-	searchTerm = strings.ToLower(searchTerm)
-	var matches []string
-
-	for _, item := range items {
-		if strings.Contains(strings.ToLower(item), searchTerm) {
-			matches = append(matches, item)
-		}
-	}
-
-	return matches
-}
-
 // refreshCacheFromFiles scans all transaction files and populates cache
 func refreshCacheFromFiles(cacheStore *cache.Cache) error {
 	// Get current year
 	currentYear := strconv.Itoa(time.Now().Year())
 
 	// Walk through year directory
-	return filepath.Walk(currentYear, func(path string, info os.FileInfo, err error) error {
+	return filepath.Walk(storage.Path(currentYear), func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return nil // Skip errors, continue walking
 		}
 
+		// Month files of an encrypted ledger are read through their plain name
+		path = strings.TrimSuffix(path, storage.EncryptedExt)
 		if info.IsDir() || !strings.HasSuffix(path, ".md") {
 			return nil
 		}
 
 		// Read file content
-		content, err := os.ReadFile(path)
+		content, err := storage.ReadFile(path)
 		if err != nil {
 			return nil // Skip unreadable files
 		}
@@ -392,7 +190,7 @@ func refreshCacheFromFiles(cacheStore *cache.Cache) error {
 
 // AddDirectTransaction adds a transaction from a direct input string
 func AddDirectTransaction(input string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -425,6 +223,7 @@ func AddDirectTransaction(input string) error {
 		Currency:    curr,
 		Tags:        tags,
 		Projects:    []string{},
+		Account:     parseAccount(strings.TrimSpace(parts[3])),
 		Meta:        make(map[string]string),
 	}
 
@@ -432,47 +231,78 @@ func AddDirectTransaction(input string) error {
 	currentYear := strconv.Itoa(time.Now().Year())
 	filePath := filepath.Join(currentYear, monthFile)
 
+	if err := checkTagsAndProjects(ledger.Current(), tags, []string{}); err != nil {
+		return err
+	}
+
 	if err := addTransactionToFile(filePath, tx); err != nil {
 		return err
 	}
 
-	if err := autoSaveTagsAndProjects(tags, []string{}); err != nil {
+	if err := autoSaveTagsAndProjects(ledger.Current(), tags, []string{}); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: could not auto-save tags: %v\n", err)
 	}
 
 	fmt.Println(i18n.T("transaction.add_success"))
+	budget.WarnIfOver(tx, time.Now().Year(), int(time.Now().Month()))
 	return nil
 }
 
-// ListTransactions lists all transactions for current or specified month
-func ListTransactions(month string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+// MonthListing holds the rows of a month file
+type MonthListing struct {
+	Year         int                   `json:"year" yaml:"year"`
+	Month        int                   `json:"month" yaml:"month"`
+	Transactions []*parser.Transaction `json:"transactions" yaml:"transactions"`
+	Unparsed     []*parser.Transaction `json:"unparsed" yaml:"unparsed"`
+}
+
+// Table returns the rows of the listing for CSV output, unparsed lines last
+func (l *MonthListing) Table() interface{} {
+	return append(append([]*parser.Transaction{}, l.Transactions...), l.Unparsed...)
+}
+
+// LoadMonthListing parses the current or specified month (01-12) of this year
+func LoadMonthListing(month string) (*MonthListing, error) {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
+		return nil, fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
-	var monthFile string
-	if month == "" {
-		monthFile = parser.GetCurrentMonthFile()
-	} else {
-		monthInt, err := strconv.Atoi(month)
-		if err != nil || monthInt < 1 || monthInt > 12 {
-			return fmt.Errorf("invalid month: %s", month)
+	monthInt := int(time.Now().Month())
+	if month != "" {
+		m, err := strconv.Atoi(month)
+		if err != nil || m < 1 || m > 12 {
+			return nil, fmt.Errorf("invalid month: %s", month)
 		}
-		monthFile = parser.GetMonthFile(monthInt)
+		monthInt = m
 	}
 
-	currentYear := strconv.Itoa(time.Now().Year())
-	filePath := filepath.Join(currentYear, monthFile)
+	year := time.Now().Year()
+	filePath := filepath.Join(strconv.Itoa(year), parser.GetMonthFile(monthInt))
 
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read month file: %v", err)
+		return nil, fmt.Errorf("failed to read month file: %v", err)
 	}
 
 	parsed, unparsed := parser.ParseMonthFile(string(content))
+	return &MonthListing{
+		Year:         year,
+		Month:        monthInt,
+		Transactions: parsed,
+		Unparsed:     unparsed,
+	}, nil
+}
+
+// ListTransactions lists all transactions for current or specified month
+func ListTransactions(month string) error {
+	listing, err := LoadMonthListing(month)
+	if err != nil {
+		return err
+	}
+	parsed, unparsed := listing.Transactions, listing.Unparsed
 
 	// Print header
-	fmt.Printf("\n%s %s\n", currentYear, monthFile)
+	fmt.Printf("\n%d %s\n", listing.Year, parser.GetMonthFile(listing.Month))
 	fmt.Println(strings.Repeat("=", 60))
 
 	// Print parsed transactions
@@ -506,7 +336,7 @@ func ListTransactions(month string) error {
 
 // EditTransaction edits a transaction by line number
 func EditTransaction(lineNum string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -519,7 +349,7 @@ func EditTransaction(lineNum string) error {
 	currentYear := strconv.Itoa(time.Now().Year())
 	filePath := filepath.Join(currentYear, monthFile)
 
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read month file: %v", err)
 	}
@@ -606,7 +436,7 @@ func EditTransaction(lineNum string) error {
 	lines[actualLine] = parser.FormatTransaction(existing)
 
 	// Write back
-	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+	if err := storage.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
 		return fmt.Errorf("failed to save: %v", err)
 	}
 
@@ -616,7 +446,7 @@ func EditTransaction(lineNum string) error {
 
 // RemoveTransaction removes a transaction by line number
 func RemoveTransaction(lineNum string) error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
 		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
 	}
 
@@ -629,7 +459,7 @@ func RemoveTransaction(lineNum string) error {
 	currentYear := strconv.Itoa(time.Now().Year())
 	filePath := filepath.Join(currentYear, monthFile)
 
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read month file: %v", err)
 	}
@@ -663,7 +493,7 @@ func RemoveTransaction(lineNum string) error {
 		return fmt.Errorf("transaction not found at line %d", line)
 	}
 
-	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+	if err := storage.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
 		return fmt.Errorf("failed to save: %v", err)
 	}
 
@@ -778,6 +608,15 @@ func parseProjects(input string) []string {
 	return projects
 }
 
+func parseAccount(input string) string {
+	for _, word := range strings.Fields(input) {
+		if strings.HasPrefix(word, "&") && len(word) > 1 {
+			return strings.TrimPrefix(word, "&")
+		}
+	}
+	return ""
+}
+
 func formatTagsAndProjects(tags, projects []string) string {
 	var parts []string
 	for _, t := range tags {
@@ -797,12 +636,27 @@ func truncate(s string, maxLen int) string {
 }
 
 func addTransactionToFile(filePath string, tx *parser.Transaction) error {
-	content, err := os.ReadFile(filePath)
+	content, err := storage.ReadFile(filePath)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %v", err)
 	}
 
-	lines := strings.Split(string(content), "\n")
+	updated, _, err := insertRow(string(content), parser.FormatTransaction(tx))
+	if err != nil {
+		return err
+	}
+
+	if err := storage.WriteFile(filePath, []byte(updated), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %v", err)
+	}
+
+	return nil
+}
+
+// insertRow adds a line at the end of the ROWS section of a month file
+// Returns the new content and the 1-based number of the added line
+func insertRow(content, row string) (string, int, error) {
+	lines := strings.Split(content, "\n")
 
 	// Find the ROWS section and add transaction
 	inRows := false
@@ -823,26 +677,49 @@ func addTransactionToFile(filePath string, tx *parser.Transaction) error {
 	}
 
 	if insertIndex < 0 {
-		return fmt.Errorf("could not find ROWS section")
+		return "", 0, fmt.Errorf("could not find ROWS section")
 	}
 
-	formatted := parser.FormatTransaction(tx)
-
 	// Insert the new transaction
-	lines = append(lines[:insertIndex], append([]string{formatted}, lines[insertIndex:]...)...)
+	lines = append(lines[:insertIndex], append([]string{row}, lines[insertIndex:]...)...)
 
-	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
-		return fmt.Errorf("failed to write file: %v", err)
+	return strings.Join(lines, "\n"), insertIndex + 1, nil
+}
+
+// checkTagsAndProjects rejects tags and projects missing from categories.yml and projects.yml
+// in strict mode; otherwise it only warns about new names that look like typos
+func checkTagsAndProjects(l *ledger.Ledger, tags, projects []string) error {
+	strict := config.LedgerStrict(l)
+	checker, err := category.NewLedgerChecker(l)
+	if err != nil {
+		if strict {
+			return err
+		}
+		return nil
+	}
+	unknown := checker.Check(tags, projects)
+
+	if strict && len(unknown) > 0 {
+		names := make([]string, 0, len(unknown))
+		for _, u := range unknown {
+			names = append(names, u.String())
+		}
+		return fmt.Errorf("%s", i18n.Tfmt("tags.strict", strings.Join(names, ", ")))
+	}
+
+	for _, u := range unknown {
+		if u.Suggestion != "" {
+			fmt.Fprintln(os.Stderr, i18n.Tfmt("tags.new_"+u.Kind, u))
+		}
 	}
 
 	return nil
 }
 
-func autoSaveTagsAndProjects(tags, projects []string) error {
-	// Save tags to categories.yml
+func autoSaveTagsAndProjects(l *ledger.Ledger, tags, projects []string) error {
+	// Save tags to the category tree in categories.yml
 	if len(tags) > 0 {
-		categoriesPath := filepath.Join("_config", "categories.yml")
-		if err := appendToYamlList(categoriesPath, "categories", tags); err != nil {
+		if err := category.AddLedgerTags(l, tags); err != nil {
 			return fmt.Errorf("failed to save tags: %v", err)
 		}
 	}
@@ -850,7 +727,7 @@ func autoSaveTagsAndProjects(tags, projects []string) error {
 	// Save projects to projects.yml
 	if len(projects) > 0 {
 		projectsPath := filepath.Join("_config", "projects.yml")
-		if err := appendToYamlList(projectsPath, "projects", projects); err != nil {
+		if err := appendToYamlList(l, projectsPath, "projects", projects); err != nil {
 			return fmt.Errorf("failed to save projects: %v", err)
 		}
 	}
@@ -871,8 +748,8 @@ func autoSaveTagsAndProjects(tags, projects []string) error {
 	return cacheStore.SaveCache()
 }
 
-func appendToYamlList(filePath, key string, items []string) error {
-	content, err := os.ReadFile(filePath)
+func appendToYamlList(l *ledger.Ledger, filePath, key string, items []string) error {
+	content, err := l.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
@@ -900,7 +777,7 @@ func appendToYamlList(filePath, key string, items []string) error {
 	}
 
 	if added {
-		return os.WriteFile(filePath, []byte(strings.Join(lines, "\n")), 0644)
+		return l.WriteFile(filePath, []byte(strings.Join(lines, "\n")))
 	}
 
 	return nil
@@ -0,0 +1,74 @@
+package transaction
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"spendgrid/internal/budget"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/storage"
+)
+
+// AddQuickTransaction adds a transaction from natural language input
+// Example: "-100TL market alışverişi #mutfak @ev"
+func AddQuickTransaction(input string) error {
+	if _, err := storage.Stat(".spendgrid"); err != nil {
+		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	}
+
+	tx, err := parser.QuickInputParser(input)
+	if err != nil {
+		return fmt.Errorf("error parsing input: %v", err)
+	}
+
+	monthFile := parser.GetCurrentMonthFile()
+	currentYear := strconv.Itoa(time.Now().Year())
+	filePath := filepath.Join(currentYear, monthFile)
+
+	if err := checkTagsAndProjects(ledger.Current(), tx.Tags, tx.Projects); err != nil {
+		return err
+	}
+
+	if err := addTransactionToFile(filePath, tx); err != nil {
+		return err
+	}
+
+	if err := autoSaveTagsAndProjects(ledger.Current(), tx.Tags, tx.Projects); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not auto-save tags: %v\n", err)
+	}
+
+	// Build display string
+	var parts []string
+	parts = append(parts, fmt.Sprintf("Added: %s", tx.Description))
+	parts = append(parts, fmt.Sprintf("%.2f %s", tx.Amount, tx.Currency))
+	if len(tx.Tags) > 0 {
+		parts = append(parts, fmt.Sprintf("tags: %s", strings.Join(tx.Tags, ", ")))
+	}
+	if len(tx.Projects) > 0 {
+		parts = append(parts, fmt.Sprintf("project: %s", strings.Join(tx.Projects, ", ")))
+	}
+	fmt.Println(strings.Join(parts, " | "))
+
+	budget.WarnIfOver(tx, time.Now().Year(), int(time.Now().Month()))
+	return nil
+}
+
+// LooksLikeQuickInput reports whether a single argument is a transaction rather than a mistyped command
+// A transaction contains spaces or starts with a number or currency symbol, optionally signed
+func LooksLikeQuickInput(input string) bool {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return false
+	}
+	if strings.Contains(input, " ") {
+		return true
+	}
+	// "-100TL" is an amount, "-h" is a flag
+	firstChar := string([]rune(strings.TrimLeft(input, "+-") + " ")[0])
+	return strings.ContainsAny(firstChar, "0123456789$€₺")
+}
@@ -0,0 +1,235 @@
+package transaction
+
+import (
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+// ErrNotFound is returned for a row ID that names no row of the ledger
+var ErrNotFound = errors.New("transaction not found")
+
+// Row is a transaction of a month file with an ID to find it again in any period
+// The ID is made of the month and the content of the row, so changing a row changes its ID
+type Row struct {
+	ID                  string `json:"id" yaml:"id"`
+	Date                string `json:"date" yaml:"date"`
+	Year                int    `json:"year" yaml:"year"`
+	Month               int    `json:"month" yaml:"month"`
+	File                string `json:"file" yaml:"file"`
+	*parser.Transaction `yaml:",inline"`
+}
+
+// rowID returns the ID of the n-th row (1-based) of a month with the given content
+// Identical rows of a month are told apart by n
+func rowID(year, month int, formatted string, n int) string {
+	sum := sha1.Sum([]byte(formatted))
+	id := fmt.Sprintf("%04d-%02d-%x", year, month, sum[:4])
+	if n > 1 {
+		id += fmt.Sprintf("-%d", n)
+	}
+	return id
+}
+
+// MonthRows returns the parsed rows of a month with their IDs, in file order
+// A missing month file has no rows
+func MonthRows(l *ledger.Ledger, year, month int) ([]*Row, error) {
+	entries, err := l.MonthEntries(year, month)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]*Row, 0, len(entries))
+	seen := make(map[string]int)
+	for _, e := range entries {
+		formatted := parser.FormatTransaction(e.Tx)
+		seen[formatted]++
+		rows = append(rows, &Row{
+			ID:          rowID(year, month, formatted, seen[formatted]),
+			Date:        e.Date().Format("2006-01-02"),
+			Year:        year,
+			Month:       month,
+			File:        filepath.ToSlash(e.Path),
+			Transaction: e.Tx,
+		})
+	}
+	return rows, nil
+}
+
+// FindRow returns the row with the given ID
+func FindRow(l *ledger.Ledger, id string) (*Row, error) {
+	t, err := time.Parse("2006-01", id[:min(len(id), 7)])
+	if err != nil || len(id) < 9 || id[7] != '-' {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	rows, err := MonthRows(l, t.Year(), int(t.Month()))
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.ID == id {
+			return row, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+}
+
+// AddRow adds a transaction to the ROWS section of a month, creating the month file if needed
+func AddRow(l *ledger.Ledger, year, month int, tx *parser.Transaction) (*Row, error) {
+	if err := checkRow(l, year, month, tx); err != nil {
+		return nil, err
+	}
+
+	row, err := insertInto(l, year, month, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	saveTags(l, tx)
+	return row, nil
+}
+
+// UpdateRow replaces the row with the given ID by tx
+// A row whose date moved to another month is moved to that month file; rule rows stay in their month
+func UpdateRow(l *ledger.Ledger, id string, year, month int, tx *parser.Transaction) (*Row, error) {
+	row, err := FindRow(l, id)
+	if err != nil {
+		return nil, err
+	}
+	tx.IsRule = row.IsRule
+	if !tx.IsRule {
+		tx.Completed = false
+	}
+
+	if err := checkRow(l, year, month, tx); err != nil {
+		return nil, err
+	}
+
+	path := ledger.MonthPath(row.Year, row.Month)
+	if year == row.Year && month == row.Month {
+		if err := l.ReplaceLines(path, map[int]string{row.LineNumber: ledger.FormatRow(tx)}); err != nil {
+			return nil, err
+		}
+		saveTags(l, tx)
+		return rowAt(l, year, month, row.LineNumber)
+	}
+
+	if row.IsRule {
+		return nil, fmt.Errorf("rule rows cannot be moved to another month")
+	}
+
+	// Add first, so a failed add leaves the old row in place
+	moved, err := insertInto(l, year, month, tx)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeLine(l, path, row.LineNumber); err != nil {
+		return nil, err
+	}
+
+	saveTags(l, tx)
+	return moved, nil
+}
+
+// DeleteRow removes the row with the given ID and returns it
+func DeleteRow(l *ledger.Ledger, id string) (*Row, error) {
+	row, err := FindRow(l, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeLine(l, ledger.MonthPath(row.Year, row.Month), row.LineNumber); err != nil {
+		return nil, err
+	}
+	return row, nil
+}
+
+// checkRow validates the day, description and tags of a row for a month
+func checkRow(l *ledger.Ledger, year, month int, tx *parser.Transaction) error {
+	if month < 1 || month > 12 {
+		return fmt.Errorf("invalid month: %d", month)
+	}
+	days := time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if tx.Day < 1 || tx.Day > days {
+		return fmt.Errorf("invalid day: %d", tx.Day)
+	}
+	if strings.TrimSpace(tx.Description) == "" {
+		return fmt.Errorf("description cannot be empty")
+	}
+	if strings.ContainsAny(tx.Description, "|\n") {
+		return fmt.Errorf("description cannot contain '|' or line breaks")
+	}
+	return checkTagsAndProjects(l, tx.Tags, tx.Projects)
+}
+
+// insertInto writes tx at the end of the ROWS section of a month file and returns the new row
+func insertInto(l *ledger.Ledger, year, month int, tx *parser.Transaction) (*Row, error) {
+	path := ledger.MonthPath(year, month)
+
+	// A missing month file starts with the default structure
+	content, err := l.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		content, err = []byte(ledger.NewMonthFile(year, month)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read month file: %v", err)
+	}
+
+	updated, line, err := insertRow(string(content), parser.FormatTransaction(tx))
+	if err != nil {
+		return nil, err
+	}
+	if err := l.WriteFile(path, []byte(updated)); err != nil {
+		return nil, fmt.Errorf("failed to write month file: %v", err)
+	}
+
+	return rowAt(l, year, month, line)
+}
+
+// rowAt returns the row on a line of a month file
+func rowAt(l *ledger.Ledger, year, month, line int) (*Row, error) {
+	rows, err := MonthRows(l, year, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.LineNumber == line {
+			return row, nil
+		}
+	}
+	return nil, fmt.Errorf("line %d of %s is not a transaction", line, ledger.MonthPath(year, month))
+}
+
+// removeLine removes a line (1-based) from a file of the ledger
+func removeLine(l *ledger.Ledger, path string, line int) error {
+	content, err := l.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return fmt.Errorf("line %d out of range in %s", line, path)
+	}
+	lines = append(lines[:line-1], lines[line:]...)
+
+	if err := l.WriteFile(path, []byte(strings.Join(lines, "\n"))); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// saveTags remembers the tags and projects of a row, failing only warns
+func saveTags(l *ledger.Ledger, tx *parser.Transaction) {
+	if err := autoSaveTagsAndProjects(l, tx.Tags, tx.Projects); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not auto-save tags: %v\n", err)
+	}
+}
@@ -0,0 +1,90 @@
+package transaction
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"spendgrid/internal/category"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+)
+
+// memLedger returns an in-memory ledger, the autocomplete cache goes to a temporary directory
+func memLedger(t *testing.T, files map[string]string) *ledger.Ledger {
+	t.Setenv("XDG_DATA_HOME", filepath.Join(t.TempDir(), "data"))
+	xdg.Reload()
+	t.Cleanup(xdg.Reload)
+
+	data := map[string][]byte{ledger.StateDir + "/version": []byte("1")}
+	for name, content := range files {
+		data[name] = []byte(content)
+	}
+	return ledger.New(ledger.MemFS(data))
+}
+
+func TestRowsInMemory(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings string
+		tags     []string
+		projects []string
+		wantErr  bool
+	}{
+		{name: "new tag path", settings: "strict: false\n", tags: []string{"yemek:market"}, projects: []string{"ev"}},
+		{name: "known tag in strict mode", settings: "strict: true\n", tags: []string{"yemek"}, projects: []string{"is"}},
+		{name: "unknown tag in strict mode", settings: "strict: true\n", tags: []string{"markt"}, projects: []string{"is"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := memLedger(t, map[string]string{
+				"_config/settings.yml":   tt.settings,
+				"_config/categories.yml": "categories:\n  - yemek\n",
+				"_config/projects.yml":   "# SpendGrid Projects\n- is\n",
+			})
+
+			tx := &parser.Transaction{Day: 5, Description: "Market", Amount: -100, Currency: "TRY", Tags: tt.tags, Projects: tt.projects}
+			row, err := AddRow(l, 2026, 1, tx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				if l.Exists(ledger.MonthPath(2026, 1)) {
+					t.Error("a rejected row created the month file")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Tags and projects are saved to the ledger's own config
+			tree, err := category.LoadLedger(l)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tree.Has(tree.Resolve(tt.tags[0])) {
+				t.Errorf("tag %s not saved in categories.yml", tt.tags[0])
+			}
+			projects, err := category.LoadLedgerProjects(l)
+			if err != nil || !strings.Contains(strings.Join(projects, " "), tt.projects[0]) {
+				t.Errorf("project %s not saved in projects.yml: %v, %v", tt.projects[0], projects, err)
+			}
+
+			edited := *row.Transaction
+			edited.Description = "Groceries"
+			updated, err := UpdateRow(l, row.ID, 2026, 2, &edited)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updated.Month != 2 || updated.Description != "Groceries" {
+				t.Errorf("UpdateRow: got %+v", updated)
+			}
+			if rows, _ := MonthRows(l, 2026, 1); len(rows) != 0 {
+				t.Errorf("the moved row is still in January: %+v", rows)
+			}
+		})
+	}
+}
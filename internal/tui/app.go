@@ -0,0 +1,508 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/eiannone/keyboard"
+
+	"spendgrid/internal/budget"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/parser"
+	"spendgrid/internal/pool"
+	"spendgrid/internal/reports"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/terminal"
+	"spendgrid/internal/transaction"
+)
+
+// panel is the list that takes the arrow keys
+type panel int
+
+const (
+	gridPanel panel = iota
+	poolPanel
+)
+
+// App is the state of the full-screen terminal app
+type App struct {
+	l           *ledger.Ledger
+	year, month int
+
+	// Data of the month on screen, loaded again after every change
+	rows    []*transaction.Row
+	report  *reports.MonthlyReport // nil without a month file
+	budgets []*budget.Status
+	items   []*pool.Item
+
+	focus      panel
+	cursor     int // selected row of the grid
+	offset     int // first row of the grid on screen
+	poolCursor int
+	poolOffset int
+	showHelp   bool
+
+	// A prompt at the bottom takes the keys while it is open
+	prompt   string
+	field    *terminal.Field
+	onSubmit func(value string) error
+	onYes    func() error // set for a yes/no question instead of a field
+
+	message string
+	isError bool
+	quit    bool
+
+	width, height int
+}
+
+// Run shows the app on the terminal until the user quits
+func Run(l *ledger.Ledger) error {
+	if !terminal.IsTerminal() {
+		return fmt.Errorf("tui needs an interactive terminal")
+	}
+
+	keys, err := keyboard.GetKeys(10)
+	if err != nil {
+		return fmt.Errorf("failed to open keyboard: %v", err)
+	}
+	defer keyboard.Close()
+
+	terminal.EnterFullScreen()
+	defer terminal.LeaveFullScreen()
+
+	now := time.Now()
+	a := &App{l: l, year: now.Year(), month: int(now.Month())}
+	a.load()
+	a.draw()
+
+	// The terminal size is polled, a resize signal is not available on every platform
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+
+	for !a.quit {
+		select {
+		case event, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if event.Err != nil {
+				return event.Err
+			}
+			a.handleKey(event.Rune, event.Key)
+			a.draw()
+		case <-ticker.C:
+			if width, height := terminal.Size(); width != a.width || height != a.height {
+				a.draw()
+			}
+		}
+	}
+	return nil
+}
+
+// load reads the month on screen, the pool and the budgets
+func (a *App) load() {
+	var errs []string
+
+	rows, err := transaction.MonthRows(a.l, a.year, a.month)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	a.rows = rows
+
+	a.report = nil
+	if a.l.Exists(ledger.MonthPath(a.year, a.month)) {
+		if a.report, _, err = reports.BuildMonthlyReport(a.l, a.year, a.month); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if a.budgets, err = budget.GetStatuses(a.year, a.month); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	if a.items, err = pool.LoadItems(a.l); err != nil {
+		errs = append(errs, err.Error())
+	}
+
+	a.cursor = clamp(a.cursor, len(a.rows))
+	a.poolCursor = clamp(a.poolCursor, len(a.items))
+	if len(errs) > 0 {
+		a.setError(fmt.Errorf("%s", strings.Join(errs, "; ")))
+	}
+}
+
+// clamp keeps a cursor inside a list of n items
+func clamp(cursor, n int) int {
+	if cursor >= n {
+		cursor = n - 1
+	}
+	if cursor < 0 {
+		cursor = 0
+	}
+	return cursor
+}
+
+func (a *App) setMessage(format string, args ...interface{}) {
+	a.message, a.isError = fmt.Sprintf(format, args...), false
+}
+
+func (a *App) setError(err error) {
+	a.message, a.isError = err.Error(), true
+}
+
+// change runs fn with the ledger locked and journals it like a command,
+// so 'spendgrid undo' reverts it, then loads the month again
+func (a *App) change(summary string, fn func() error) error {
+	err := func() error {
+		if err := storage.Lock(); err != nil {
+			return err
+		}
+		defer storage.Unlock()
+
+		recorder, journalErr := journal.Begin("tui")
+		changeErr := fn()
+		if recorder != nil {
+			_, journalErr = recorder.Finish("tui: " + summary)
+		}
+		if changeErr == nil && journalErr != nil {
+			return fmt.Errorf("journal failed: %v", journalErr)
+		}
+		return changeErr
+	}()
+
+	a.load()
+	return err
+}
+
+// moveMonth shows the month delta months away from the one on screen
+func (a *App) moveMonth(delta int) {
+	index := a.year*12 + a.month - 1 + delta
+	a.year, a.month = index/12, index%12+1
+	a.cursor, a.offset = 0, 0
+	a.message = ""
+	a.load()
+}
+
+// ask opens a prompt with a text field, submit is called with its value on Enter
+func (a *App) ask(prompt, value string, submit func(value string) error) {
+	a.prompt, a.field, a.onSubmit, a.onYes = prompt, terminal.NewField(value), submit, nil
+	a.message = ""
+}
+
+// confirm opens a yes/no question
+func (a *App) confirm(question string, yes func() error) {
+	a.prompt, a.field, a.onSubmit, a.onYes = question+" (y/n)", nil, nil, yes
+	a.message = ""
+}
+
+func (a *App) closePrompt() {
+	a.prompt, a.field, a.onSubmit, a.onYes = "", nil, nil, nil
+}
+
+// handleKey handles a key press
+func (a *App) handleKey(char rune, key keyboard.Key) {
+	if a.prompt != "" {
+		a.handlePromptKey(char, key)
+		return
+	}
+	if a.showHelp {
+		a.showHelp = false
+		return
+	}
+
+	switch {
+	case key == keyboard.KeyCtrlC || key == keyboard.KeyEsc || char == 'q':
+		a.quit = true
+	case char == '?':
+		a.showHelp = true
+
+	case key == keyboard.KeyArrowLeft || char == 'h':
+		a.moveMonth(-1)
+	case key == keyboard.KeyArrowRight || char == 'l':
+		a.moveMonth(1)
+	case key == keyboard.KeyPgup || char == '[':
+		a.moveMonth(-12)
+	case key == keyboard.KeyPgdn || char == ']':
+		a.moveMonth(12)
+	case char == 't':
+		now := time.Now()
+		a.moveMonth(now.Year()*12 + int(now.Month()) - (a.year*12 + a.month))
+
+	case key == keyboard.KeyArrowUp || char == 'k':
+		a.moveCursor(-1)
+	case key == keyboard.KeyArrowDown || char == 'j':
+		a.moveCursor(1)
+	case key == keyboard.KeyHome || char == 'g':
+		a.moveCursor(-1 << 30)
+	case key == keyboard.KeyEnd || char == 'G':
+		a.moveCursor(1 << 30)
+	case key == keyboard.KeyTab:
+		if a.focus == gridPanel {
+			a.focus = poolPanel
+		} else {
+			a.focus = gridPanel
+		}
+
+	case char == 'r':
+		a.load()
+		a.setMessage("Reloaded")
+	case char == 'u':
+		a.undo()
+
+	case a.focus == gridPanel:
+		a.handleGridKey(char, key)
+	default:
+		a.handlePoolKey(char, key)
+	}
+}
+
+func (a *App) handlePromptKey(char rune, key keyboard.Key) {
+	if a.onYes != nil {
+		yes := a.onYes
+		a.closePrompt()
+		if char == 'y' || char == 'Y' {
+			if err := yes(); err != nil {
+				a.setError(err)
+			}
+		} else {
+			a.setMessage("Cancelled")
+		}
+		return
+	}
+
+	switch key {
+	case keyboard.KeyEsc, keyboard.KeyCtrlC:
+		a.closePrompt()
+		a.setMessage("Cancelled")
+	case keyboard.KeyEnter:
+		// A failed submit keeps the prompt open to fix the input
+		if err := a.onSubmit(a.field.Value()); err != nil {
+			a.setError(err)
+			return
+		}
+		a.closePrompt()
+	default:
+		a.field.HandleKey(char, key)
+	}
+}
+
+func (a *App) moveCursor(delta int) {
+	if a.focus == gridPanel {
+		a.cursor = clamp(a.cursor+delta, len(a.rows))
+	} else {
+		a.poolCursor = clamp(a.poolCursor+delta, len(a.items))
+	}
+}
+
+// selectRow moves the grid cursor to the row with the given ID
+func (a *App) selectRow(id string) {
+	for i, row := range a.rows {
+		if row.ID == id {
+			a.cursor = i
+			return
+		}
+	}
+}
+
+func (a *App) undo() {
+	entry, err := journal.Undo()
+	a.load()
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.setMessage("Undone: %s", entry.Summary)
+}
+
+func (a *App) handleGridKey(char rune, key keyboard.Key) {
+	switch {
+	case char == 'a':
+		a.ask("Add", "", a.addRow)
+	case len(a.rows) == 0:
+		return
+	case key == keyboard.KeyEnter || char == 'e':
+		row := a.rows[a.cursor]
+		a.ask("Edit", editableLine(row), func(value string) error { return a.editRow(row, value) })
+	case key == keyboard.KeySpace || char == 'x':
+		a.toggleRow(a.rows[a.cursor])
+	case char == 'd':
+		row := a.rows[a.cursor]
+		a.confirm(fmt.Sprintf("Remove %q?", row.Description), func() error {
+			err := a.change("remove "+row.Description, func() error {
+				_, err := transaction.DeleteRow(a.l, row.ID)
+				return err
+			})
+			if err == nil {
+				a.setMessage("Removed %s", row.Description)
+			}
+			return err
+		})
+	}
+}
+
+// editableLine returns a row as it is written in the month file, without the list dash and checkbox
+func editableLine(row *transaction.Row) string {
+	line := row.Raw
+	if !row.IsUnparsed {
+		line = parser.FormatTransaction(row.Transaction)
+	}
+	line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+	for _, checkbox := range []string{"[ ]", "[x]"} {
+		line = strings.TrimSpace(strings.TrimPrefix(line, checkbox))
+	}
+	return line
+}
+
+// addRow adds a row written as quick input, dated today or on the same day of the month on screen
+func (a *App) addRow(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("enter a row, e.g. -100TL market #mutfak")
+	}
+	tx, err := parser.QuickInputParser(value)
+	if err != nil {
+		return fmt.Errorf("error parsing input: %v", err)
+	}
+	if tx.Amount == 0 {
+		return fmt.Errorf("no amount found in %q", value)
+	}
+	if days := time.Date(a.year, time.Month(a.month)+1, 0, 0, 0, 0, 0, time.UTC).Day(); tx.Day > days {
+		tx.Day = days
+	}
+
+	var row *transaction.Row
+	err = a.change("add "+tx.Description, func() error {
+		row, err = transaction.AddRow(a.l, a.year, a.month, tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	a.selectRow(row.ID)
+	a.focus = gridPanel
+	a.setMessage("Added %s", tx.Description)
+	return nil
+}
+
+// editRow replaces a row by the line written in the prompt
+func (a *App) editRow(row *transaction.Row, value string) error {
+	tx := parser.ParseTransaction("- "+value, row.LineNumber)
+	if tx == nil || tx.IsUnparsed {
+		return fmt.Errorf("cannot parse the row, use DAY | DESCRIPTION | AMOUNT CURRENCY | TAGS")
+	}
+	tx.Completed = row.Completed
+
+	var updated *transaction.Row
+	err := a.change("edit "+tx.Description, func() error {
+		var err error
+		updated, err = transaction.UpdateRow(a.l, row.ID, row.Year, row.Month, tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	a.selectRow(updated.ID)
+	a.setMessage("Saved %s", tx.Description)
+	return nil
+}
+
+// toggleRow completes a rule row or opens it again
+func (a *App) toggleRow(row *transaction.Row) {
+	if !row.IsRule {
+		a.setError(fmt.Errorf("only rule rows can be completed"))
+		return
+	}
+
+	tx := *row.Transaction
+	tx.Completed = !row.Completed
+	summary := "complete " + tx.Description
+	if !tx.Completed {
+		summary = "uncomplete " + tx.Description
+	}
+
+	var updated *transaction.Row
+	err := a.change(summary, func() error {
+		var err error
+		updated, err = transaction.UpdateRow(a.l, row.ID, row.Year, row.Month, &tx)
+		return err
+	})
+	if err != nil {
+		a.setError(err)
+		return
+	}
+	a.selectRow(updated.ID)
+	if tx.Completed {
+		a.setMessage("Completed %s", tx.Description)
+	} else {
+		a.setMessage("Reopened %s", tx.Description)
+	}
+}
+
+func (a *App) handlePoolKey(char rune, key keyboard.Key) {
+	switch {
+	case char == 'a':
+		a.ask("Add to pool", "", a.addPoolItem)
+	case len(a.items) == 0:
+		return
+	case key == keyboard.KeyEnter || char == 'm':
+		a.movePoolItem()
+	case char == 'd':
+		n, item := a.poolCursor+1, a.items[a.poolCursor]
+		a.confirm(fmt.Sprintf("Remove %q from the pool?", item.Description), func() error {
+			err := a.change(fmt.Sprintf("pool remove %d", n), func() error {
+				_, err := pool.RemoveItem(a.l, n)
+				return err
+			})
+			if err == nil {
+				a.setMessage("Removed %s from the pool", item.Description)
+			}
+			return err
+		})
+	}
+}
+
+// addPoolItem adds an item written as quick input to the end of the pool
+func (a *App) addPoolItem(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("enter an item, e.g. 2500TL new chair #ev")
+	}
+	tx, err := parser.QuickInputParser(value)
+	if err != nil {
+		return fmt.Errorf("error parsing input: %v", err)
+	}
+
+	item := &pool.Item{Description: tx.Description, Amount: tx.Amount, Currency: tx.Currency, Tags: tx.Tags}
+	if err := a.change("pool add "+item.Description, func() error {
+		return pool.AddItem(a.l, item)
+	}); err != nil {
+		return err
+	}
+	a.poolCursor = len(a.items) - 1
+	a.setMessage("Added %s to the pool", item.Description)
+	return nil
+}
+
+// movePoolItem moves the selected pool item into the month on screen
+func (a *App) movePoolItem() {
+	n, item := a.poolCursor+1, a.items[a.poolCursor]
+	var tx *parser.Transaction
+	err := a.change(fmt.Sprintf("pool move %d %04d-%02d", n, a.year, a.month), func() error {
+		var err error
+		tx, err = pool.MoveItem(a.l, n, a.year, a.month, 0)
+		return err
+	})
+	if err != nil {
+		a.setError(err)
+		return
+	}
+
+	// The moved row is the last one with its content
+	formatted := parser.FormatTransaction(tx)
+	for i, row := range a.rows {
+		if parser.FormatTransaction(row.Transaction) == formatted {
+			a.cursor = i
+		}
+	}
+	a.setMessage("Moved %s to %04d-%02d", item.Description, a.year, a.month)
+}
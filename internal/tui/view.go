@@ -0,0 +1,379 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"spendgrid/internal/config"
+	"spendgrid/internal/ledger"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/terminal"
+	"spendgrid/internal/transaction"
+)
+
+// sidebarWidth is the width of the budget sidebar, it is left out on narrow terminals
+const sidebarWidth = 32
+
+// maxPoolLines is the most pool items shown below the grid
+const maxPoolLines = 5
+
+var helpLines = []string{
+	"Keys",
+	"",
+	"←/→  h/l       previous/next month",
+	"PgUp/PgDn [ ]  previous/next year",
+	"t              this month",
+	"↑/↓  k/j       move, Home/End to the first/last",
+	"Tab            switch between the month and the pool",
+	"",
+	"Month",
+	"a              add a row as quick input: -100TL market #mutfak",
+	"Enter e        edit the row as written in the month file",
+	"Space x        complete a rule row or open it again",
+	"d              remove the row",
+	"",
+	"Pool",
+	"a              add an item as quick input",
+	"Enter m        move the item into the month on screen",
+	"d              remove the item",
+	"",
+	"u              undo the last change",
+	"r              reload the files",
+	"q Esc          quit",
+	"",
+	"Press any key to close this help",
+}
+
+// draw draws the whole screen
+func (a *App) draw() {
+	a.width, a.height = terminal.Size()
+	frame := terminal.NewFrame(a.width, a.height)
+	if a.width < 50 || a.height < 10 {
+		frame.Set(0, terminal.Fit("The terminal is too small", a.width))
+		frame.Draw()
+		return
+	}
+
+	frame.Set(0, a.header())
+
+	side := 0
+	if a.width >= 90 {
+		side = sidebarWidth
+	}
+	mainWidth := a.width
+	if side > 0 {
+		mainWidth -= side + 1
+	}
+
+	bodyHeight := a.height - 3
+	poolHeight := len(a.items)
+	if poolHeight > maxPoolLines {
+		poolHeight = maxPoolLines
+	}
+	if poolHeight == 0 {
+		poolHeight = 1
+	}
+	poolHeight++ // title line
+
+	left := append(a.gridLines(mainWidth, bodyHeight-poolHeight), a.poolLines(mainWidth, poolHeight)...)
+	var right []string
+	if side > 0 {
+		right = a.sidebarLines(side, bodyHeight)
+	}
+	if a.showHelp {
+		left = helpBox(mainWidth, bodyHeight)
+	}
+
+	for i := 0; i < bodyHeight; i++ {
+		line := left[i]
+		if side > 0 {
+			line += terminal.Styled("│", terminal.Dim) + right[i]
+		}
+		frame.Set(1+i, line)
+	}
+
+	frame.Set(a.height-2, a.statusLine())
+	frame.Set(a.height-1, terminal.Styled(terminal.Fit(a.keysLine(), a.width), terminal.Dim))
+	frame.Draw()
+}
+
+func (a *App) header() string {
+	dir, _ := storage.Root()
+	title := fmt.Sprintf(" SpendGrid   ‹ %d %s ›", a.year, ledger.MonthName(a.month))
+	right := dir + " "
+	gap := a.width - len([]rune(title)) - len([]rune(right))
+	if gap < 1 {
+		return terminal.Styled(terminal.Fit(title, a.width), terminal.Reverse, terminal.Bold)
+	}
+	return terminal.Styled(title+strings.Repeat(" ", gap)+right, terminal.Reverse, terminal.Bold)
+}
+
+// scroll returns the first line to show so that the cursor is among the visible lines
+func scroll(offset, cursor, visible int) int {
+	if cursor < offset {
+		offset = cursor
+	}
+	if cursor >= offset+visible {
+		offset = cursor - visible + 1
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return offset
+}
+
+func money(amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%.2f %s", amount, currency))
+}
+
+// gridLines returns the month grid, a column heading and the rows
+func (a *App) gridLines(width, height int) []string {
+	lines := make([]string, 0, height)
+
+	tagsWidth := width / 4
+	if tagsWidth > 30 {
+		tagsWidth = 30
+	}
+	const amountWidth = 16
+	descWidth := width - 1 - 4 - 4 - 1 - amountWidth - 1 - tagsWidth
+	if descWidth < 10 {
+		descWidth += tagsWidth
+		tagsWidth = 0
+	}
+
+	heading := " " + terminal.Fit("", 4) + terminal.Fit("Day", 4) + terminal.Fit("Description", descWidth) + " " +
+		terminal.FitRight("Amount", amountWidth) + " " + terminal.Fit("Tags", tagsWidth)
+	lines = append(lines, terminal.Styled(terminal.Fit(heading, width), terminal.Bold))
+
+	visible := height - 1
+	switch {
+	case !a.l.Exists(ledger.MonthPath(a.year, a.month)):
+		lines = append(lines, terminal.Styled(terminal.Fit(fmt.Sprintf(" No month file for %04d-%02d, press a to add a row", a.year, a.month), width), terminal.Dim))
+	case len(a.rows) == 0:
+		lines = append(lines, terminal.Styled(terminal.Fit(" No rows, press a to add one", width), terminal.Dim))
+	}
+
+	a.offset = scroll(a.offset, a.cursor, visible)
+	for i := a.offset; i < len(a.rows) && i < a.offset+visible; i++ {
+		lines = append(lines, a.rowLine(a.rows[i], i == a.cursor, width, descWidth, amountWidth, tagsWidth))
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:height]
+}
+
+func (a *App) rowLine(row *transaction.Row, selected bool, width, descWidth, amountWidth, tagsWidth int) string {
+	marker := " "
+	if selected {
+		marker = "›"
+	}
+
+	if row.IsUnparsed {
+		line := terminal.Fit(marker+"    "+strings.TrimSpace(row.Raw), width)
+		if selected && a.focus == gridPanel {
+			return terminal.Styled(line, terminal.Reverse, terminal.Yellow)
+		}
+		return terminal.Styled(line, terminal.Yellow)
+	}
+
+	checkbox := "    "
+	if row.IsRule {
+		checkbox = "[ ] "
+		if row.Completed {
+			checkbox = "[x] "
+		}
+	}
+
+	var labels []string
+	for _, tag := range row.Tags {
+		labels = append(labels, "#"+tag)
+	}
+	for _, project := range row.Projects {
+		labels = append(labels, "@"+project)
+	}
+	if row.Account != "" {
+		labels = append(labels, "&"+row.Account)
+	}
+
+	start := marker + checkbox + terminal.Fit(fmt.Sprintf("%02d", row.Day), 4) + terminal.Fit(row.Description, descWidth) + " "
+	amount := terminal.FitRight(money(row.Amount, row.Currency), amountWidth)
+	end := " " + terminal.Fit(strings.Join(labels, " "), tagsWidth)
+	rest := width - len([]rune(start+amount+end))
+	if rest > 0 {
+		end += strings.Repeat(" ", rest)
+	}
+
+	if selected && a.focus == gridPanel {
+		return terminal.Styled(start+amount+end, terminal.Reverse)
+	}
+	if row.IsRule && row.Completed {
+		return terminal.Styled(start+amount+end, terminal.Dim)
+	}
+
+	amountColor := terminal.Green
+	if row.Amount < 0 {
+		amountColor = terminal.Red
+	}
+	if row.IsRule {
+		start = terminal.Styled(start, terminal.Yellow)
+	}
+	return start + terminal.Styled(amount, amountColor) + terminal.Styled(end, terminal.Cyan)
+}
+
+// poolLines returns the pool panel, a title and the items
+func (a *App) poolLines(width, height int) []string {
+	title := fmt.Sprintf("─ Pool (%d) ", len(a.items))
+	title += strings.Repeat("─", max(0, width-len([]rune(title))))
+	attrs := []string{terminal.Dim}
+	if a.focus == poolPanel {
+		attrs = []string{terminal.Bold, terminal.Cyan}
+	}
+	lines := []string{terminal.Styled(terminal.Fit(title, width), attrs...)}
+
+	visible := height - 1
+	if len(a.items) == 0 {
+		lines = append(lines, terminal.Styled(terminal.Fit(" The pool is empty, Tab and a to add an item", width), terminal.Dim))
+	}
+
+	a.poolOffset = scroll(a.poolOffset, a.poolCursor, visible)
+	for i := a.poolOffset; i < len(a.items) && i < a.poolOffset+visible; i++ {
+		item := a.items[i]
+		marker := " "
+		if i == a.poolCursor {
+			marker = "›"
+		}
+
+		expected := ""
+		if item.IsDated() {
+			expected = fmt.Sprintf("%04d-%02d", item.Year, item.Month)
+		}
+		amount := ""
+		if item.Amount != 0 {
+			amount = money(item.Amount, item.Currency)
+		}
+		tags := make([]string, 0, len(item.Tags))
+		for _, tag := range item.Tags {
+			tags = append(tags, "#"+tag)
+		}
+
+		descWidth := width - 1 - 4 - 16 - 9 - 20
+		line := marker + terminal.Fit(fmt.Sprintf("%d.", i+1), 4) + terminal.Fit(item.Description, max(descWidth, 10)) +
+			terminal.FitRight(amount, 16) + " " + terminal.Fit(expected, 8) + " " + strings.Join(tags, " ")
+		line = terminal.Fit(line, width)
+		if i == a.poolCursor && a.focus == poolPanel {
+			line = terminal.Styled(line, terminal.Reverse)
+		}
+		lines = append(lines, line)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:height]
+}
+
+// sidebarLines returns the totals of the month and the state of the budgets
+func (a *App) sidebarLines(width, height int) []string {
+	var lines []string
+	add := func(text string, attrs ...string) {
+		lines = append(lines, terminal.Styled(terminal.Fit(" "+text, width), attrs...))
+	}
+	amountLine := func(label string, amount float64, attrs ...string) {
+		add(terminal.Fit(label, 12)+terminal.FitRight(fmt.Sprintf("%.2f", amount), width-14), attrs...)
+	}
+
+	base := config.GetBaseCurrency()
+	add(fmt.Sprintf("Month (%s)", base), terminal.Bold)
+	if a.report == nil {
+		add("No month file", terminal.Dim)
+	} else {
+		r := a.report
+		net := r.BaseIncome - r.BaseExpenses
+		netColor := terminal.Green
+		if net < 0 {
+			netColor = terminal.Red
+		}
+		amountLine("Income", r.BaseIncome, terminal.Green)
+		amountLine("Expenses", r.BaseExpenses, terminal.Red)
+		amountLine("Net", net, terminal.Bold, netColor)
+		amountLine("Planned in", r.BasePlannedIncome, terminal.Dim)
+		amountLine("Planned out", r.BasePlannedExpenses, terminal.Dim)
+		amountLine("After plan", net+r.BasePlannedIncome-r.BasePlannedExpenses)
+		if len(r.MissingRates) > 0 {
+			add(fmt.Sprintf("Rows without a rate: %d", len(r.MissingRates)), terminal.Yellow)
+		}
+	}
+
+	add("")
+	add("Budgets", terminal.Bold)
+	if len(a.budgets) == 0 {
+		add("No budgets", terminal.Dim)
+	}
+	for _, st := range a.budgets {
+		attrs := []string{terminal.Green}
+		switch {
+		case st.IsOver():
+			attrs = []string{terminal.Red}
+		case st.Percent >= 80:
+			attrs = []string{terminal.Yellow}
+		}
+		add(terminal.Fit("#"+st.Budget.Tag, 14) + terminal.FitRight(fmt.Sprintf("%.0f/%.0f", st.Spent, st.Available), width-16))
+		add(bar(st.Percent, width-9)+fmt.Sprintf(" %4.0f%%", st.Percent), attrs...)
+	}
+
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:height]
+}
+
+// bar returns a progress bar of the given width
+func bar(percent float64, width int) string {
+	filled := int(percent / 100 * float64(width))
+	filled = max(0, min(filled, width))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func helpBox(width, height int) []string {
+	lines := make([]string, 0, height)
+	for _, text := range helpLines {
+		attrs := []string{}
+		if text == "Keys" || text == "Month" || text == "Pool" {
+			attrs = append(attrs, terminal.Bold)
+		}
+		lines = append(lines, terminal.Styled(terminal.Fit("  "+text, width), attrs...))
+	}
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return lines[:height]
+}
+
+func (a *App) statusLine() string {
+	switch {
+	case a.field != nil:
+		label := " " + a.prompt + ": "
+		return terminal.Styled(label, terminal.Bold) + a.field.Render(a.width-len([]rune(label)))
+	case a.prompt != "":
+		return terminal.Styled(terminal.Fit(" "+a.prompt, a.width), terminal.Bold, terminal.Yellow)
+	case a.isError:
+		return terminal.Styled(terminal.Fit(" "+a.message, a.width), terminal.Red)
+	default:
+		return terminal.Styled(terminal.Fit(" "+a.message, a.width), terminal.Green)
+	}
+}
+
+func (a *App) keysLine() string {
+	switch {
+	case a.field != nil:
+		return " Enter save  Esc cancel  ←/→ move  Ctrl+U clear"
+	case a.prompt != "":
+		return " y yes  any other key no"
+	case a.focus == poolPanel:
+		return " Enter move to month  a add  d remove  Tab month  ←/→ month  u undo  ? help  q quit"
+	default:
+		return " ←/→ month  [/] year  a add  Enter edit  Space complete  d remove  u undo  Tab pool  ? help  q quit"
+	}
+}
@@ -2,76 +2,89 @@ package validator
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strconv"
 	"time"
 
+	"spendgrid/internal/category"
 	"spendgrid/internal/i18n"
+	"spendgrid/internal/ledger"
 	"spendgrid/internal/parser"
 )
 
 // ValidationResult holds validation results
 type ValidationResult struct {
-	TotalFiles    int
-	TotalLines    int
-	ParsedCount   int
-	UnparsedCount int
-	UnparsedLines []UnparsedLine
-	Errors        []string
+	TotalFiles    int            `json:"total_files" yaml:"total_files"`
+	TotalLines    int            `json:"total_lines" yaml:"total_lines"`
+	ParsedCount   int            `json:"parsed_count" yaml:"parsed_count"`
+	UnparsedCount int            `json:"unparsed_count" yaml:"unparsed_count"`
+	UnparsedLines []UnparsedLine `json:"unparsed_lines" yaml:"unparsed_lines"`
+	Errors        []string       `json:"errors" yaml:"errors"`
+	Warnings      []string       `json:"warnings" yaml:"warnings"`
 }
 
 // UnparsedLine represents an unparsed line with context
 type UnparsedLine struct {
-	File    string
-	LineNum int
-	Content string
+	File    string `json:"file" yaml:"file"`
+	LineNum int    `json:"line" yaml:"line"`
+	Content string `json:"content" yaml:"content"`
 }
 
-// ValidateAll validates all files in the database
+// ValidateAll validates all files in the database and prints the results
 func ValidateAll() error {
-	if _, err := os.Stat(".spendgrid"); err != nil {
-		return fmt.Errorf("not a spendgrid directory. Run 'spendgrid init' first")
+	result, err := Validate(ledger.Current())
+	if err != nil {
+		return err
+	}
+
+	printValidationResults(result)
+	return nil
+}
+
+// Validate validates the month files of the current year and the config files
+func Validate(l *ledger.Ledger) (*ValidationResult, error) {
+	if err := l.EnsureInitialized(); err != nil {
+		return nil, err
 	}
 
 	result := &ValidationResult{
 		UnparsedLines: []UnparsedLine{},
 		Errors:        []string{},
+		Warnings:      []string{},
+	}
+
+	// Unknown tags and projects are reported as warnings
+	checker, err := category.NewLedgerChecker(l)
+	if err != nil {
+		result.Errors = append(result.Errors, err.Error())
 	}
 
 	// Validate current year
 	now := time.Now()
-	year := strconv.Itoa(now.Year())
-	yearDir := year
+	yearDir := strconv.Itoa(now.Year())
 
 	// Check if year directory exists
-	if _, err := os.Stat(yearDir); os.IsNotExist(err) {
-		return fmt.Errorf("year directory not found: %s", yearDir)
+	if !l.Exists(yearDir) {
+		return nil, fmt.Errorf("year directory not found: %s", yearDir)
 	}
 
 	// Validate each month file
 	for month := 1; month <= 12; month++ {
-		monthFile := parser.GetMonthFile(month)
-		filePath := filepath.Join(yearDir, monthFile)
-
-		if err := validateFile(filePath, result); err != nil {
+		if err := validateFile(l, ledger.MonthPath(now.Year(), month), result, checker); err != nil {
 			// File might not exist, that's ok
 			continue
 		}
 	}
 
 	// Validate config files
-	validateConfigFile("_config/settings.yml", result)
-	validateConfigFile("_config/rules.yml", result)
+	validateConfigFile(l, "_config/settings.yml", result)
+	validateConfigFile(l, "_config/rules.yml", result)
 
-	// Print results
-	printValidationResults(result)
-
-	return nil
+	return result, nil
 }
 
-func validateFile(filePath string, result *ValidationResult) error {
-	content, err := os.ReadFile(filePath)
+func validateFile(l *ledger.Ledger, filePath string, result *ValidationResult, checker *category.Checker) error {
+	content, err := l.ReadFile(filePath)
 	if err != nil {
 		return err
 	}
@@ -91,11 +104,20 @@ func validateFile(filePath string, result *ValidationResult) error {
 		})
 	}
 
+	if checker != nil {
+		for _, tx := range parsed {
+			for _, u := range checker.Check(tx.Tags, tx.Projects) {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("%s:%d | %s", filepath.Base(filePath), tx.LineNumber, i18n.Tfmt("tags.unknown_"+u.Kind, u)))
+			}
+		}
+	}
+
 	return nil
 }
 
-func validateConfigFile(filePath string, result *ValidationResult) {
-	if _, err := os.Stat(filePath); err != nil {
+func validateConfigFile(l *ledger.Ledger, filePath string, result *ValidationResult) {
+	if !l.Exists(filePath) {
 		result.Errors = append(result.Errors, fmt.Sprintf("Config file missing: %s", filePath))
 	}
 }
@@ -128,6 +150,15 @@ func printValidationResults(result *ValidationResult) {
 		}
 	}
 
+	if len(result.Warnings) > 0 {
+		fmt.Println()
+		fmt.Println(i18n.T("validation.warnings_header"))
+		fmt.Println("----------------------------------------")
+		for _, warning := range result.Warnings {
+			fmt.Printf("- %s\n", warning)
+		}
+	}
+
 	if result.UnparsedCount == 0 && len(result.Errors) == 0 {
 		fmt.Println()
 		fmt.Println(i18n.T("validation.all_ok"))
@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	"golang.org/x/term"
+)
+
+// PassphraseEnv is the environment variable read before asking for a passphrase
+const PassphraseEnv = "SPENDGRID_PASSPHRASE"
+
+// Timeout is how long an entered passphrase is remembered, 0 asks every time
+var Timeout = 15 * time.Minute
+
+var (
+	mu   sync.Mutex
+	keys = make(map[string][]byte) // by ledger root, for the rest of the process
+)
+
+// Unlock returns the key of the encrypted ledger in root
+// It comes from this process, the key cache, PassphraseEnv or the terminal, in that order
+func Unlock(root string) ([]byte, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if key, ok := keys[root]; ok {
+		return key, nil
+	}
+
+	settings, err := LoadSettings(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if key := cachedKey(root); key != nil && settings.Opens(key) {
+		keys[root] = key
+		return key, nil
+	}
+
+	passphrase := os.Getenv(PassphraseEnv)
+	if passphrase == "" {
+		if passphrase, err = Prompt(fmt.Sprintf("Passphrase for %s: ", root)); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := settings.Key(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	keys[root] = key
+	if err := cacheKey(root, key); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return key, nil
+}
+
+// Remember keeps the key of a ledger that was just encrypted
+func Remember(root string, key []byte) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	keys[root] = key
+	if err := cacheKey(root, key); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+// Forget drops the key of a ledger from this process and the key cache
+func Forget(root string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(keys, root)
+	os.Remove(cachePath(root))
+}
+
+// Prompt reads a passphrase from the terminal without echoing it
+func Prompt(label string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("ledger is encrypted: set %s or run in a terminal", PassphraseEnv)
+	}
+
+	fmt.Fprint(os.Stderr, label)
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read passphrase: %v", err)
+	}
+	return string(data), nil
+}
+
+// cachePath returns the key cache file of a ledger
+// Keys live in the runtime directory, which is private to the user and cleared on logout
+func cachePath(root string) string {
+	sum := sha1.Sum([]byte(root))
+	return filepath.Join(keysDir(), hex.EncodeToString(sum[:8]))
+}
+
+func keysDir() string {
+	return filepath.Join(xdg.RuntimeDir, "spendgrid", "keys")
+}
+
+// checkRuntimeDir returns an error unless the runtime directory is private to the user
+// Without XDG_RUNTIME_DIR it may fall back to a shared temporary directory
+func checkRuntimeDir() error {
+	if err := checkPrivate(xdg.RuntimeDir); err != nil {
+		return fmt.Errorf("passphrase is not remembered, runtime directory is not private: %v", err)
+	}
+	return nil
+}
+
+// cachedKey returns the cached key of a ledger, or nil if there is none or it expired
+func cachedKey(root string) []byte {
+	if checkRuntimeDir() != nil || checkPrivate(keysDir()) != nil || checkPrivate(cachePath(root)) != nil {
+		return nil
+	}
+	data, err := os.ReadFile(cachePath(root))
+	if err != nil {
+		return nil
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) != 2 {
+		return nil
+	}
+	expires, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		os.Remove(cachePath(root))
+		return nil
+	}
+	key, err := hex.DecodeString(fields[1])
+	if err != nil {
+		return nil
+	}
+	return key
+}
+
+// cacheKey writes the key of a ledger to the key cache until Timeout passes
+func cacheKey(root string, key []byte) error {
+	if Timeout <= 0 {
+		return nil
+	}
+
+	if err := checkRuntimeDir(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(keysDir(), 0700); err != nil {
+		return fmt.Errorf("failed to create key cache: %v", err)
+	}
+	if err := checkPrivate(keysDir()); err != nil {
+		return fmt.Errorf("passphrase is not remembered: %v", err)
+	}
+
+	// The key is written to a new 0600 file and renamed into place, a file left
+	// by an earlier run would keep its mode if it were rewritten
+	tmp, err := os.CreateTemp(keysDir(), ".key-*")
+	if err != nil {
+		return fmt.Errorf("failed to write key cache: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	data := fmt.Sprintf("%d %s\n", time.Now().Add(Timeout).Unix(), hex.EncodeToString(key))
+	_, err = tmp.WriteString(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), cachePath(root))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write key cache: %v", err)
+	}
+	return nil
+}
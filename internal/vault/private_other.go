@@ -0,0 +1,12 @@
+//go:build !unix
+
+package vault
+
+import "os"
+
+// checkPrivate only checks that path exists, the per-user application data
+// directory is private on systems without unix permissions
+func checkPrivate(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
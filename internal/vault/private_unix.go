@@ -0,0 +1,26 @@
+//go:build unix
+
+package vault
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkPrivate returns an error unless path is owned by the current user
+// and neither its group nor others have any access
+func checkPrivate(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || int(stat.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is not owned by the current user", path)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return fmt.Errorf("%s is accessible by other users (mode %o)", path, info.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,273 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/scrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// stateDir is the ledger state directory holding the settings file
+const stateDir = ".spendgrid"
+
+// SettingsFile is the name of the encryption settings inside the state directory
+const SettingsFile = "encryption"
+
+// magic starts every encrypted file, followed by the nonce and the sealed content
+var magic = []byte("SGENC1\n")
+
+// checkText is sealed with the key to tell a wrong passphrase from a damaged file
+const checkText = "spendgrid"
+
+// scrypt parameters of new keys, about 100ms and 32MB per derivation
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrPassphrase is returned when the passphrase does not open the ledger
+var ErrPassphrase = errors.New("wrong passphrase")
+
+// Settings are the key derivation parameters of an encrypted ledger
+// The passphrase and key are never stored in the ledger
+type Settings struct {
+	Cipher string `yaml:"cipher"` // aes-256-gcm
+	KDF    string `yaml:"kdf"`    // scrypt
+	N      int    `yaml:"scrypt_n"`
+	R      int    `yaml:"scrypt_r"`
+	P      int    `yaml:"scrypt_p"`
+	Salt   string `yaml:"salt"`  // base64
+	Check  string `yaml:"check"` // base64, checkText sealed with the key
+}
+
+// Root returns the ledger directory a file belongs to, or "" outside a ledger
+func Root(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ""
+	}
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		if info, err := os.Stat(filepath.Join(dir, stateDir)); err == nil && info.IsDir() {
+			return dir
+		}
+		if filepath.Dir(dir) == dir {
+			return ""
+		}
+	}
+}
+
+// Enabled returns true if the ledger in root is encrypted
+func Enabled(root string) bool {
+	_, err := os.Stat(settingsPath(root))
+	return err == nil
+}
+
+func settingsPath(root string) string {
+	return filepath.Join(root, stateDir, SettingsFile)
+}
+
+// LoadSettings reads the encryption settings of a ledger
+func LoadSettings(root string) (*Settings, error) {
+	data, err := os.ReadFile(settingsPath(root))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read encryption settings: %v", err)
+	}
+
+	var s Settings
+	if err := yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to parse encryption settings: %v", err)
+	}
+	if s.Cipher != "aes-256-gcm" || s.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported encryption %s with %s", s.Cipher, s.KDF)
+	}
+	return &s, nil
+}
+
+// NewSettings derives a key from a passphrase with a fresh salt
+// The settings are not saved, see Save
+func NewSettings(passphrase string) (*Settings, []byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to create salt: %v", err)
+	}
+
+	s := &Settings{
+		Cipher: "aes-256-gcm",
+		KDF:    "scrypt",
+		N:      scryptN,
+		R:      scryptR,
+		P:      scryptP,
+		Salt:   base64.StdEncoding.EncodeToString(salt),
+	}
+	key, err := s.derive(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	check, err := Seal(key, SettingsFile, []byte(checkText))
+	if err != nil {
+		return nil, nil, err
+	}
+	s.Check = base64.StdEncoding.EncodeToString(check)
+	return s, key, nil
+}
+
+// Save writes the settings, which turns on encryption for the ledger in root
+func (s *Settings) Save(root string) error {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal encryption settings: %v", err)
+	}
+	if err := os.WriteFile(settingsPath(root), data, 0644); err != nil {
+		return fmt.Errorf("failed to write encryption settings: %v", err)
+	}
+	return nil
+}
+
+// Disable removes the settings and forgets the key of the ledger in root
+func Disable(root string) error {
+	if err := os.Remove(settingsPath(root)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove encryption settings: %v", err)
+	}
+	Forget(root)
+	return nil
+}
+
+// Key derives the key from a passphrase, it fails with ErrPassphrase if the passphrase is wrong
+func (s *Settings) Key(passphrase string) ([]byte, error) {
+	key, err := s.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if !s.Opens(key) {
+		return nil, ErrPassphrase
+	}
+	return key, nil
+}
+
+// Opens returns true if key is the key of the ledger
+func (s *Settings) Opens(key []byte) bool {
+	check, err := base64.StdEncoding.DecodeString(s.Check)
+	if err != nil {
+		return false
+	}
+	text, err := Open(key, SettingsFile, check)
+	return err == nil && string(text) == checkText
+}
+
+func (s *Settings) derive(passphrase string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %v", err)
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, s.N, s.R, s.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	return key, nil
+}
+
+// Seal encrypts data with AES-GCM, binding it to name so files cannot be swapped
+func Seal(key []byte, name string, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to create nonce: %v", err)
+	}
+
+	out := append([]byte(nil), magic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, []byte(name)), nil
+}
+
+// IsSealed returns true if data was written by Seal
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Open decrypts data written by Seal for the same name
+func Open(key []byte, name string, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(data, magic) || len(data) < len(magic)+gcm.NonceSize() {
+		return nil, fmt.Errorf("%s is not an encrypted spendgrid file", name)
+	}
+	data = data[len(magic):]
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: file is damaged or belongs elsewhere", name)
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %v", err)
+	}
+	return gcm, nil
+}
+
+// passphraseMagic starts data sealed with SealPassphrase, followed by the salt
+var passphraseMagic = []byte("SGPASS1\n")
+
+// SealPassphrase encrypts data with a key derived from a passphrase,
+// the salt is stored with the data so only the passphrase is needed to open it
+func SealPassphrase(passphrase, name string, data []byte) ([]byte, error) {
+	s, key, err := NewSettings(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption salt: %v", err)
+	}
+
+	sealed, err := Seal(key, name, data)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(nil), passphraseMagic...)
+	out = append(out, salt...)
+	return append(out, sealed...), nil
+}
+
+// OpenPassphrase decrypts data written by SealPassphrase, it fails with ErrPassphrase
+// if the passphrase is wrong
+func OpenPassphrase(passphrase, name string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, passphraseMagic) || len(data) < len(passphraseMagic)+16 {
+		return nil, fmt.Errorf("%s is not encrypted with a passphrase", name)
+	}
+	data = data[len(passphraseMagic):]
+
+	s := &Settings{N: scryptN, R: scryptR, P: scryptP, Salt: base64.StdEncoding.EncodeToString(data[:16])}
+	key, err := s.derive(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := Open(key, name, data[16:])
+	if err != nil {
+		return nil, ErrPassphrase
+	}
+	return plain, nil
+}
@@ -0,0 +1,114 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	settings, key, err := NewSettings("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := NewSettings("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := other.Key("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		openKey  []byte
+		openName string
+		data     []byte
+		tamper   bool
+		wantErr  bool
+	}{
+		{name: "round trip", openKey: key, openName: "2026/01.md", data: []byte("- 1 | market | -120 TRY | #market\n")},
+		{name: "empty content", openKey: key, openName: "2026/01.md", data: []byte{}},
+		{name: "other ledger key", openKey: otherKey, openName: "2026/01.md", data: []byte("data"), wantErr: true},
+		{name: "swapped file", openKey: key, openName: "2026/02.md", data: []byte("data"), wantErr: true},
+		{name: "damaged file", openKey: key, openName: "2026/01.md", data: []byte("data"), tamper: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := Seal(key, "2026/01.md", tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !IsSealed(sealed) {
+				t.Fatal("sealed data is not recognized as sealed")
+			}
+			if tt.tamper {
+				sealed[len(sealed)-1] ^= 0xff
+			}
+
+			plain, err := Open(tt.openKey, tt.openName, sealed)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(plain, tt.data) {
+				t.Errorf("got %q, want %q", plain, tt.data)
+			}
+		})
+	}
+
+	if !settings.Opens(key) {
+		t.Error("settings do not open their own key")
+	}
+	if settings.Opens(otherKey) {
+		t.Error("settings open the key of another ledger")
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	settings, key, err := NewSettings("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := SealPassphrase("secret", "backup.tar.gz", []byte("archive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		wantErr    error
+	}{
+		{name: "right passphrase", passphrase: "secret"},
+		{name: "wrong passphrase", passphrase: "Secret", wantErr: ErrPassphrase},
+		{name: "empty passphrase", passphrase: "", wantErr: ErrPassphrase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := settings.Key(tt.passphrase)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Key: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && !bytes.Equal(got, key) {
+				t.Error("Key returned a different key for the right passphrase")
+			}
+
+			plain, err := OpenPassphrase(tt.passphrase, "backup.tar.gz", sealed)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OpenPassphrase: got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && string(plain) != "archive" {
+				t.Errorf("got %q, want %q", plain, "archive")
+			}
+		})
+	}
+}
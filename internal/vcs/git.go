@@ -0,0 +1,272 @@
+package vcs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"spendgrid/internal/journal"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// ConfigKey is the git config key that turns on automatic commits for a ledger
+const ConfigKey = "spendgrid.autocommit"
+
+// ignored are the .gitignore lines for SpendGrid's local state
+var ignored = []string{
+	".spendgrid/lock",
+	".spendgrid/journal/",
+	".spendgrid/backups/",
+	".*.tmp",
+}
+
+// run runs git in the ledger root and returns its output
+func run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = storage.Path(".")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return stdout.String(), nil
+}
+
+// IsRepo returns true if the current directory is inside a git work tree
+func IsRepo() bool {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false
+	}
+	out, err := run("rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// Enabled returns true if changes of the ledger are committed automatically
+func Enabled() bool {
+	if !IsRepo() {
+		return false
+	}
+	out, err := run("config", "--bool", ConfigKey)
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// Enable turns on automatic commits for the ledger in the current directory
+// A repository is created if there is none, local state is ignored, month files
+// get the merge driver and the ledger files are committed; returns true if that
+// commit was made
+func Enable() (bool, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false, fmt.Errorf("git is not installed")
+	}
+	if !IsRepo() {
+		if _, err := run("init", "-q"); err != nil {
+			return false, err
+		}
+	}
+	if err := ignoreState(); err != nil {
+		return false, err
+	}
+	if err := InstallMergeDriver(); err != nil {
+		return false, err
+	}
+	if _, err := run("config", ConfigKey, "true"); err != nil {
+		return false, err
+	}
+
+	return CommitAll("track ledger")
+}
+
+// CommitAll commits every file of the ledger, for changes that replace the ledger
+// as a whole such as encrypting or restoring it
+// Nothing happens unless automatic commits are enabled; returns true if a commit was made
+func CommitAll(subject string) (bool, error) {
+	if !Enabled() {
+		return false, nil
+	}
+	if _, err := run("add", "-A", "--", "."); err != nil {
+		return false, err
+	}
+	if _, err := run("diff", "--cached", "--quiet", "--", "."); err == nil {
+		return false, nil
+	}
+	if _, err := run("commit", "-q", "-m", "spendgrid: "+subject, "--", "."); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Disable turns off automatic commits, the repository is kept
+func Disable() error {
+	if !Enabled() {
+		return nil
+	}
+	_, err := run("config", "--unset", ConfigKey)
+	return err
+}
+
+// ignoreState adds the missing lines of ignored to .gitignore
+func ignoreState() error {
+	return addLines(".gitignore", ignored)
+}
+
+// addLines appends the lines a file is missing, creating it if needed
+func addLines(path string, lines []string) error {
+	data, err := os.ReadFile(storage.Path(path))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	present := make(map[string]bool)
+	for _, line := range strings.Split(string(data), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+
+	content := string(data)
+	for _, line := range lines {
+		if present[line] {
+			continue
+		}
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		content += line + "\n"
+	}
+	if content == string(data) {
+		return nil
+	}
+	if err := os.WriteFile(storage.Path(path), []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// MergeDriver is the name of the merge driver for month files in git config and .gitattributes
+const MergeDriver = "spendgrid"
+
+// mergeAttributes route the month files, plain and encrypted, to the merge driver
+var mergeAttributes = []string{
+	"[0-9][0-9][0-9][0-9]/[0-9][0-9].md merge=" + MergeDriver,
+	"[0-9][0-9][0-9][0-9]/[0-9][0-9].md.enc merge=" + MergeDriver,
+}
+
+// InstallMergeDriver makes git merge month files with 'spendgrid merge'
+// The driver is set in the repository config, which is not shared, so every
+// clone runs it once; the attributes are committed with the ledger
+func InstallMergeDriver() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find spendgrid executable: %v", err)
+	}
+	driver := fmt.Sprintf("'%s' merge %%A %%B %%O --name %%P", strings.ReplaceAll(exe, "'", `'\''`))
+
+	if _, err := run("config", "merge."+MergeDriver+".name", "SpendGrid month file merge"); err != nil {
+		return err
+	}
+	if _, err := run("config", "merge."+MergeDriver+".driver", driver); err != nil {
+		return err
+	}
+	return addLines(".gitattributes", mergeAttributes)
+}
+
+// Commit commits the files of changes with a message made of the subject and
+// a summary of the rows added, removed and changed in each file
+// On an encrypted ledger the message keeps only the command name and row counts
+// Nothing happens unless automatic commits are enabled
+func Commit(subject string, changes []journal.FileChange) error {
+	if len(changes) == 0 || !Enabled() {
+		return nil
+	}
+
+	encrypted := vault.Enabled(storage.Path("."))
+	if encrypted {
+		subject = commandName(subject)
+	}
+
+	var paths []string
+	var body strings.Builder
+	for _, c := range changes {
+		path := storage.StoredPath(c.Path)
+		if _, err := os.Stat(path); err != nil {
+			// A removed file is only committed if git knows it
+			if out, err := run("ls-files", "--", path); err != nil || strings.TrimSpace(out) == "" {
+				continue
+			}
+		}
+		paths = append(paths, path)
+
+		diff := DiffFile(c.Path, c.Before, c.After, c.Existed, !c.Deleted)
+		summary := diff.Summary()
+		if encrypted {
+			summary = diff.CountSummary()
+		}
+		fmt.Fprintf(&body, "%s: %s\n", c.Path, summary)
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	args := append([]string{"status", "--porcelain", "--"}, paths...)
+	if out, err := run(args...); err != nil || strings.TrimSpace(out) == "" {
+		return err
+	}
+
+	args = append([]string{"add", "-A", "--"}, paths...)
+	if _, err := run(args...); err != nil {
+		return err
+	}
+	args = append([]string{"commit", "-q", "-m", "spendgrid: " + subject, "-m", strings.TrimSpace(body.String()), "--"}, paths...)
+	_, err := run(args...)
+	return err
+}
+
+// commandName cuts a journal summary to its command, e.g. "tui: complete Rent" -> "tui: complete"
+// The arguments may hold descriptions and amounts
+func commandName(subject string) string {
+	fields := strings.Fields(subject)
+	switch {
+	case len(fields) == 0:
+		return subject
+	case strings.HasSuffix(fields[0], ":") && len(fields) > 1:
+		return fields[0] + " " + fields[1]
+	}
+	return fields[0]
+}
+
+// State describes the git integration of the ledger
+type State struct {
+	Repository  bool     `json:"repository" yaml:"repository"`
+	AutoCommit  bool     `json:"autocommit" yaml:"autocommit"`
+	Head        string   `json:"head,omitempty" yaml:"head,omitempty"`
+	Uncommitted []string `json:"uncommitted" yaml:"uncommitted"` // ledger files changed since the last commit
+}
+
+// Status returns the git state of the ledger in the current directory
+func Status() (*State, error) {
+	s := &State{Repository: IsRepo(), Uncommitted: []string{}}
+	if !s.Repository {
+		return s, nil
+	}
+	s.AutoCommit = Enabled()
+	if out, err := run("rev-parse", "--short", "HEAD"); err == nil {
+		s.Head = strings.TrimSpace(out)
+	}
+
+	out, err := run("status", "--porcelain", "--untracked-files=all", "--", ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if len(line) > 3 {
+			s.Uncommitted = append(s.Uncommitted, line[3:])
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,357 @@
+package vcs
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"spendgrid/internal/journal"
+	"spendgrid/internal/output"
+	"spendgrid/internal/storage"
+	"spendgrid/internal/vault"
+)
+
+// ledgerFile matches the committed files a diff looks at: _config, _pool and the month files
+var ledgerFile = regexp.MustCompile(`^(_config|_pool|\d{4})/[^/.][^/]*$`)
+
+// Diff is the semantic difference between two versions of the ledger
+type Diff struct {
+	From  string      `json:"from" yaml:"from"`
+	To    string      `json:"to" yaml:"to"` // empty for the working tree
+	Files []*FileDiff `json:"files" yaml:"files"`
+}
+
+// Table returns one CSV row per added, removed or changed row
+func (d *Diff) Table() interface{} {
+	rows := []RowChange{}
+	for _, f := range d.Files {
+		rows = append(rows, f.Rows()...)
+	}
+	return rows
+}
+
+// FileSummary is what a commit changed in a file
+type FileSummary struct {
+	Path    string             `json:"path" yaml:"path"`
+	Status  string             `json:"status" yaml:"status"` // added, removed or changed
+	Added   int                `json:"added" yaml:"added"`
+	Removed int                `json:"removed" yaml:"removed"`
+	Changed int                `json:"changed" yaml:"changed"`
+	Net     map[string]float64 `json:"net,omitempty" yaml:"net,omitempty"`
+	Summary string             `json:"summary" yaml:"summary"`
+}
+
+// LogEntry is a commit that changed the ledger
+type LogEntry struct {
+	Commit  string         `json:"commit" yaml:"commit"`
+	Time    time.Time      `json:"time" yaml:"time"`
+	Author  string         `json:"author" yaml:"author"`
+	Subject string         `json:"subject" yaml:"subject"`
+	Files   []*FileSummary `json:"files" yaml:"files"`
+}
+
+// Log is the change history of the ledger, newest first
+type Log []*LogEntry
+
+// logRow is a single CSV row of a log
+type logRow struct {
+	Commit  string `json:"commit"`
+	Time    string `json:"time"`
+	Author  string `json:"author"`
+	Subject string `json:"subject"`
+	File    string `json:"file"`
+	Status  string `json:"status"`
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Changed int    `json:"changed"`
+	Net     string `json:"net"`
+}
+
+// Table returns one CSV row per file of each commit
+func (l Log) Table() interface{} {
+	rows := []logRow{}
+	for _, e := range l {
+		for _, f := range e.Files {
+			rows = append(rows, logRow{
+				Commit:  e.Commit[:min(len(e.Commit), 12)],
+				Time:    e.Time.Format(time.RFC3339),
+				Author:  e.Author,
+				Subject: e.Subject,
+				File:    f.Path,
+				Status:  f.Status,
+				Added:   f.Added,
+				Removed: f.Removed,
+				Changed: f.Changed,
+				Net:     FormatNet(f.Net),
+			})
+		}
+	}
+	return rows
+}
+
+// reader reads ledger files as committed, decrypting those of an encrypted ledger
+type reader struct {
+	key []byte
+}
+
+// read returns the plain name and content of a committed file
+func (r *reader) read(rev, name string) (string, string, error) {
+	data, err := run("show", rev+":./"+name)
+	if err != nil {
+		return "", "", err
+	}
+
+	plain := strings.TrimSuffix(name, storage.EncryptedExt)
+	if plain == name {
+		return name, data, nil
+	}
+	if r.key == nil {
+		root, err := storage.Root()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get current directory: %v", err)
+		}
+		if r.key, err = vault.Unlock(root); err != nil {
+			return "", "", err
+		}
+	}
+	content, err := vault.Open(r.key, plain, []byte(data))
+	if err != nil {
+		return "", "", err
+	}
+	return plain, string(content), nil
+}
+
+// Snapshot reads the ledger files of a commit
+func Snapshot(rev string) (journal.Snapshot, error) {
+	out, err := run("ls-tree", "-r", "--name-only", rev, "--", ".")
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reader{}
+	snap := make(journal.Snapshot)
+	for _, name := range strings.Split(strings.TrimSpace(out), "\n") {
+		if !ledgerFile.MatchString(name) {
+			continue
+		}
+		plain, content, err := r.read(rev, name)
+		if err != nil {
+			return nil, err
+		}
+		snap[plain] = content
+	}
+	return snap, nil
+}
+
+// DiffRevs compares the ledger at from with the ledger at to
+// An empty to compares with the working tree, from may also be given as from..to
+func DiffRevs(from, to string) (*Diff, error) {
+	if !IsRepo() {
+		return nil, fmt.Errorf("not a git repository, run 'spendgrid git enable' first")
+	}
+	if a, b, ok := strings.Cut(from, ".."); ok && to == "" {
+		from, to = a, b
+	}
+	if from == "" {
+		from = "HEAD"
+	}
+
+	before, err := Snapshot(from)
+	if err != nil {
+		return nil, err
+	}
+	var after journal.Snapshot
+	if to == "" {
+		if after, err = journal.TakeSnapshot(); err != nil {
+			return nil, err
+		}
+		slashed := make(journal.Snapshot, len(after))
+		for path, content := range after {
+			slashed[filepath.ToSlash(path)] = content
+		}
+		after = slashed
+	} else if after, err = Snapshot(to); err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{From: from, To: to, Files: []*FileDiff{}}
+	for _, c := range before.Changes(after) {
+		diff.Files = append(diff.Files, DiffFile(c.Path, c.Before, c.After, c.Existed, !c.Deleted))
+	}
+	return diff, nil
+}
+
+// History returns the commits that changed the ledger, or only the file path
+// if it is not empty, newest first; limit <= 0 returns all
+func History(path string, limit int) (Log, error) {
+	if !IsRepo() {
+		return nil, fmt.Errorf("not a git repository, run 'spendgrid git enable' first")
+	}
+
+	args := []string{"log", "--format=%H%x1f%aI%x1f%an%x1f%s"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	args = append(args, "--")
+	if path != "" {
+		path = filepath.ToSlash(filepath.Clean(path))
+		args = append(args, path, path+storage.EncryptedExt)
+	} else {
+		args = append(args, ".")
+	}
+
+	out, err := run(args...)
+	if err != nil {
+		// A repository without commits has no history
+		if _, headErr := run("rev-parse", "--verify", "-q", "HEAD"); headErr != nil {
+			return Log{}, nil
+		}
+		return nil, err
+	}
+
+	r := &reader{}
+	log := Log{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, fields[1])
+		entry := &LogEntry{Commit: fields[0], Time: t, Author: fields[2], Subject: fields[3], Files: []*FileSummary{}}
+
+		files, err := commitFiles(r, entry.Commit)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range files {
+			if path != "" && d.Path != path {
+				continue
+			}
+			entry.Files = append(entry.Files, summarize(d))
+		}
+		log = append(log, entry)
+	}
+	return log, nil
+}
+
+// commitFiles returns the diffs of the ledger files a commit changed
+// A file that was encrypted or decrypted by the commit is compared with its other copy
+func commitFiles(r *reader, commit string) ([]*FileDiff, error) {
+	out, err := run("diff-tree", "-r", "--no-commit-id", "--name-status", "--relative", "--root", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*journal.FileChange
+	byPath := make(map[string]*journal.FileChange)
+	exists := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		status, name, ok := strings.Cut(line, "\t")
+		if !ok || !ledgerFile.MatchString(name) {
+			continue
+		}
+
+		plain := strings.TrimSuffix(name, storage.EncryptedExt)
+		c, ok := byPath[plain]
+		if !ok {
+			c = &journal.FileChange{Path: plain}
+			byPath[plain] = c
+			changes = append(changes, c)
+		}
+		if status != "A" {
+			if _, c.Before, err = r.read(commit+"^", name); err != nil {
+				return nil, err
+			}
+			c.Existed = true
+		}
+		if status != "D" {
+			if _, c.After, err = r.read(commit, name); err != nil {
+				return nil, err
+			}
+			exists[plain] = true
+		}
+		c.Deleted = !exists[plain]
+	}
+
+	diffs := make([]*FileDiff, 0, len(changes))
+	for _, c := range changes {
+		if c.Existed && !c.Deleted && c.Before == c.After {
+			continue
+		}
+		diffs = append(diffs, DiffFile(c.Path, c.Before, c.After, c.Existed, !c.Deleted))
+	}
+	return diffs, nil
+}
+
+// summarize returns the counts of a file diff
+func summarize(d *FileDiff) *FileSummary {
+	status := "changed"
+	if d.Created {
+		status = "added"
+	} else if d.Deleted {
+		status = "removed"
+	}
+	return &FileSummary{
+		Path:    d.Path,
+		Status:  status,
+		Added:   len(d.Added),
+		Removed: len(d.Removed),
+		Changed: len(d.Changed),
+		Net:     d.Net(),
+		Summary: d.Summary(),
+	}
+}
+
+// ShowLog prints the change history
+func ShowLog(log Log) {
+	fmt.Println()
+	if len(log) == 0 {
+		fmt.Println("No commits yet")
+		fmt.Println()
+		return
+	}
+
+	for _, e := range log {
+		color.New(color.FgYellow).Printf("%s", e.Commit[:min(len(e.Commit), 8)])
+		fmt.Printf("  %s  %s\n", e.Time.Format("2006-01-02 15:04"), e.Subject)
+		for _, f := range e.Files {
+			fmt.Printf("    %-16s %s\n", f.Path, f.Summary)
+		}
+	}
+	fmt.Println()
+}
+
+// ShowDiff prints the added, removed and changed rows of each file
+func ShowDiff(d *Diff) {
+	fmt.Println()
+	if len(d.Files) == 0 {
+		fmt.Println("No changes")
+		fmt.Println()
+		return
+	}
+
+	added := color.New(color.FgGreen)
+	removed := color.New(color.FgRed)
+	changed := color.New(color.FgYellow)
+	for _, f := range d.Files {
+		color.New(color.Bold).Printf("%s", f.Path)
+		fmt.Printf("  %s\n", f.Summary())
+		for _, r := range f.Rows() {
+			if r.Date == "" {
+				continue
+			}
+			switch r.Change {
+			case "added":
+				added.Printf("  + %s  %-30s %12.2f %s\n", r.Date, output.Truncate(r.Description, 30), r.Amount, r.Currency)
+			case "removed":
+				removed.Printf("  - %s  %-30s %12.2f %s\n", r.Date, output.Truncate(r.Description, 30), r.Amount, r.Currency)
+			default:
+				changed.Printf("  ~ %s  %-30s %s\n", r.Date, output.Truncate(r.Description, 30), r.Details)
+			}
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,279 @@
+package vcs
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"spendgrid/internal/parser"
+)
+
+// monthFile matches the path of a month file, e.g. 2026/03.md
+var monthFile = regexp.MustCompile(`^\d{4}/\d{2}\.md$`)
+
+// RowPair is a row whose content changed, e.g. a new amount or a completed rule
+type RowPair struct {
+	Before  *parser.Transaction `json:"before" yaml:"before"`
+	After   *parser.Transaction `json:"after" yaml:"after"`
+	Details []string            `json:"details" yaml:"details"` // the fields that changed
+}
+
+// FileDiff are the rows added, removed and changed in a file
+// Files other than month files only have Created, Deleted and Modified
+type FileDiff struct {
+	Path     string                `json:"path" yaml:"path"`
+	Created  bool                  `json:"created,omitempty" yaml:"created,omitempty"`
+	Deleted  bool                  `json:"deleted,omitempty" yaml:"deleted,omitempty"`
+	Modified bool                  `json:"modified,omitempty" yaml:"modified,omitempty"` // content changed outside of rows
+	Added    []*parser.Transaction `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed  []*parser.Transaction `json:"removed,omitempty" yaml:"removed,omitempty"`
+	Changed  []*RowPair            `json:"changed,omitempty" yaml:"changed,omitempty"`
+}
+
+// RowChange is a single row of a diff, the CSV form of FileDiff
+type RowChange struct {
+	File        string  `json:"file" yaml:"file"`
+	Change      string  `json:"change" yaml:"change"` // added, removed or changed
+	Date        string  `json:"date" yaml:"date"`
+	Description string  `json:"description" yaml:"description"`
+	Amount      float64 `json:"amount" yaml:"amount"`
+	Currency    string  `json:"currency" yaml:"currency"`
+	OldAmount   float64 `json:"old_amount,omitempty" yaml:"old_amount,omitempty"`
+	OldCurrency string  `json:"old_currency,omitempty" yaml:"old_currency,omitempty"`
+	Details     string  `json:"details,omitempty" yaml:"details,omitempty"`
+}
+
+// DiffFile compares two versions of a ledger file by row
+// existed and exists tell whether the file was there before and after
+func DiffFile(path, before, after string, existed, exists bool) *FileDiff {
+	path = strings.ReplaceAll(path, "\\", "/")
+	d := &FileDiff{Path: path, Created: !existed && exists, Deleted: existed && !exists}
+	if !monthFile.MatchString(path) {
+		d.Modified = existed && exists && before != after
+		return d
+	}
+
+	oldRows, _ := parser.ParseMonthFile(before)
+	newRows, _ := parser.ParseMonthFile(after)
+
+	// Rows found unchanged in both versions cancel out, a row may appear more than once
+	unchanged := make(map[string]int)
+	for _, tx := range oldRows {
+		unchanged[parser.FormatTransaction(tx)]++
+	}
+	for _, tx := range newRows {
+		key := parser.FormatTransaction(tx)
+		if unchanged[key] > 0 {
+			unchanged[key]--
+			continue
+		}
+		d.Added = append(d.Added, tx)
+	}
+	remaining := make(map[string]int)
+	for _, tx := range newRows {
+		remaining[parser.FormatTransaction(tx)]++
+	}
+	for _, tx := range oldRows {
+		key := parser.FormatTransaction(tx)
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		d.Removed = append(d.Removed, tx)
+	}
+
+	// A removed and an added row of the same day and description are one edited row
+	var added []*parser.Transaction
+	for _, tx := range d.Added {
+		matched := -1
+		for i, old := range d.Removed {
+			if old.Day == tx.Day && old.Description == tx.Description && old.IsRule == tx.IsRule {
+				matched = i
+				break
+			}
+		}
+		if matched < 0 {
+			added = append(added, tx)
+			continue
+		}
+		old := d.Removed[matched]
+		d.Removed = append(d.Removed[:matched], d.Removed[matched+1:]...)
+		d.Changed = append(d.Changed, &RowPair{Before: old, After: tx, Details: changedFields(old, tx)})
+	}
+	d.Added = added
+
+	d.Modified = existed && exists && before != after && d.Empty()
+	return d
+}
+
+// changedFields describes the fields that differ between two versions of a row
+func changedFields(before, after *parser.Transaction) []string {
+	var details []string
+	if before.Amount != after.Amount || before.Currency != after.Currency {
+		details = append(details, fmt.Sprintf("amount %.2f %s → %.2f %s", before.Amount, before.Currency, after.Amount, after.Currency))
+	}
+	if before.Rate != after.Rate {
+		details = append(details, fmt.Sprintf("rate %.2f → %.2f", before.Rate, after.Rate))
+	}
+	if strings.Join(before.Tags, " ") != strings.Join(after.Tags, " ") {
+		details = append(details, fmt.Sprintf("tags %s → %s", list(before.Tags, "#"), list(after.Tags, "#")))
+	}
+	if strings.Join(before.Projects, " ") != strings.Join(after.Projects, " ") {
+		details = append(details, fmt.Sprintf("projects %s → %s", list(before.Projects, "@"), list(after.Projects, "@")))
+	}
+	if before.Account != after.Account {
+		details = append(details, fmt.Sprintf("account %s → %s", orNone(before.Account), orNone(after.Account)))
+	}
+	if before.Completed != after.Completed {
+		if after.Completed {
+			details = append(details, "completed")
+		} else {
+			details = append(details, "reopened")
+		}
+	}
+	if fmt.Sprint(before.Meta) != fmt.Sprint(after.Meta) {
+		details = append(details, "meta")
+	}
+	return details
+}
+
+func list(items []string, prefix string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	marked := make([]string, len(items))
+	for i, item := range items {
+		marked[i] = prefix + item
+	}
+	return strings.Join(marked, " ")
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// Empty returns true if no row was added, removed or changed
+func (d *FileDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Net returns the change of the total of the file per currency
+// Rule rows that are not completed do not count
+func (d *FileDiff) Net() map[string]float64 {
+	net := make(map[string]float64)
+	counts := func(tx *parser.Transaction) bool {
+		return !tx.IsRule || tx.Completed
+	}
+	for _, tx := range d.Added {
+		if counts(tx) {
+			net[tx.Currency] += tx.Amount
+		}
+	}
+	for _, tx := range d.Removed {
+		if counts(tx) {
+			net[tx.Currency] -= tx.Amount
+		}
+	}
+	for _, p := range d.Changed {
+		if counts(p.After) {
+			net[p.After.Currency] += p.After.Amount
+		}
+		if counts(p.Before) {
+			net[p.Before.Currency] -= p.Before.Amount
+		}
+	}
+	for currency, amount := range net {
+		if amount > -0.005 && amount < 0.005 {
+			delete(net, currency)
+		}
+	}
+	return net
+}
+
+// Summary returns a one-line description, e.g. "+2 -0 ~1 rows, -150.00 TRY"
+func (d *FileDiff) Summary() string {
+	return d.summary(true)
+}
+
+// CountSummary is Summary without amounts, e.g. "+2 -0 ~1 rows"
+func (d *FileDiff) CountSummary() string {
+	return d.summary(false)
+}
+
+func (d *FileDiff) summary(amounts bool) string {
+	if !monthFile.MatchString(d.Path) || (d.Empty() && !d.Created && !d.Deleted) {
+		switch {
+		case d.Created:
+			return "added"
+		case d.Deleted:
+			return "removed"
+		}
+		return "changed"
+	}
+
+	summary := fmt.Sprintf("+%d -%d ~%d rows", len(d.Added), len(d.Removed), len(d.Changed))
+	if net := FormatNet(d.Net()); amounts && net != "" {
+		summary += ", " + net
+	}
+	if d.Created {
+		summary = "new file, " + summary
+	} else if d.Deleted {
+		summary = "file removed, " + summary
+	}
+	return summary
+}
+
+// FormatNet returns amounts per currency like "+1200.00 TRY, -30.00 USD"
+func FormatNet(net map[string]float64) string {
+	currencies := make([]string, 0, len(net))
+	for currency := range net {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	parts := make([]string, 0, len(currencies))
+	for _, currency := range currencies {
+		parts = append(parts, fmt.Sprintf("%+.2f %s", net[currency], currency))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Rows returns the added, removed and changed rows ordered by date
+func (d *FileDiff) Rows() []RowChange {
+	date := func(tx *parser.Transaction) string {
+		year, month, _ := strings.Cut(strings.TrimSuffix(d.Path, ".md"), "/")
+		return fmt.Sprintf("%s-%s-%02d", year, month, tx.Day)
+	}
+
+	// Files without rows are listed as a whole
+	if !monthFile.MatchString(d.Path) {
+		return []RowChange{{File: d.Path, Change: d.Summary()}}
+	}
+
+	var rows []RowChange
+	for _, tx := range d.Added {
+		rows = append(rows, RowChange{File: d.Path, Change: "added", Date: date(tx), Description: tx.Description, Amount: tx.Amount, Currency: tx.Currency})
+	}
+	for _, tx := range d.Removed {
+		rows = append(rows, RowChange{File: d.Path, Change: "removed", Date: date(tx), Description: tx.Description, Amount: tx.Amount, Currency: tx.Currency})
+	}
+	for _, p := range d.Changed {
+		rows = append(rows, RowChange{
+			File:        d.Path,
+			Change:      "changed",
+			Date:        date(p.After),
+			Description: p.After.Description,
+			Amount:      p.After.Amount,
+			Currency:    p.After.Currency,
+			OldAmount:   p.Before.Amount,
+			OldCurrency: p.Before.Currency,
+			Details:     strings.Join(p.Details, "; "),
+		})
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Date < rows[j].Date })
+	return rows
+}
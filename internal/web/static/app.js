@@ -0,0 +1,698 @@
+'use strict';
+
+// SpendGrid dashboard, talks to the JSON API of `spendgrid serve` under /api/v1
+
+const API = '/api/v1';
+const TOKEN_KEY = 'spendgrid.token';
+const SEPARATOR = ':';
+const MONTHS = ['Jan', 'Feb', 'Mar', 'Apr', 'May', 'Jun', 'Jul', 'Aug', 'Sep', 'Oct', 'Nov', 'Dec'];
+
+const today = new Date();
+const state = {
+  view: 'month',
+  year: today.getFullYear(),
+  month: today.getMonth() + 1,
+  drill: '', // category path the month or year view is drilled into
+  forecastMonths: 12,
+};
+
+// ---- helpers ----
+
+function pad(n) {
+  return String(n).padStart(2, '0');
+}
+
+function period() {
+  return state.year + '-' + pad(state.month);
+}
+
+const numberFormat = new Intl.NumberFormat(undefined, { minimumFractionDigits: 2, maximumFractionDigits: 2 });
+
+function money(amount, currency) {
+  return numberFormat.format(amount) + (currency ? ' ' + currency : '');
+}
+
+// el builds an element, attributes starting with "on" become event listeners
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  setAttrs(node, attrs);
+  append(node, children);
+  return node;
+}
+
+// svg builds an SVG element the same way
+function svg(tag, attrs, ...children) {
+  const node = document.createElementNS('http://www.w3.org/2000/svg', tag);
+  setAttrs(node, attrs);
+  append(node, children);
+  return node;
+}
+
+function setAttrs(node, attrs) {
+  for (const [key, value] of Object.entries(attrs || {})) {
+    if (value === undefined || value === null || value === false) {
+      continue;
+    }
+    if (key.startsWith('on')) {
+      node.addEventListener(key.slice(2), value);
+    } else if (key === 'value' || key === 'checked') {
+      node[key] = value;
+    } else {
+      node.setAttribute(key, value === true ? '' : value);
+    }
+  }
+}
+
+function append(node, children) {
+  for (const child of children.flat()) {
+    if (child === undefined || child === null || child === false) {
+      continue;
+    }
+    node.append(child instanceof Node ? child : document.createTextNode(String(child)));
+  }
+}
+
+// field returns the control of a form with the given name
+function field(form, name) {
+  return form.elements.namedItem(name);
+}
+
+function tooltip(text) {
+  return svg('title', {}, text);
+}
+
+let toastTimer;
+
+function toast(message, isError) {
+  const box = document.getElementById('toast');
+  box.textContent = message;
+  box.className = isError ? 'error' : '';
+  box.hidden = false;
+  clearTimeout(toastTimer);
+  toastTimer = setTimeout(() => { box.hidden = true; }, isError ? 6000 : 2500);
+}
+
+// ---- API ----
+
+function askToken() {
+  const dialog = document.getElementById('token-dialog');
+  dialog.hidden = false;
+  document.getElementById('token-input').focus();
+}
+
+async function api(method, path, body) {
+  const options = { method, headers: { Authorization: 'Bearer ' + (localStorage.getItem(TOKEN_KEY) || '') } };
+  if (body !== undefined) {
+    options.headers['Content-Type'] = 'application/json';
+    options.body = JSON.stringify(body);
+  }
+
+  const response = await fetch(API + path, options);
+  if (response.status === 401) {
+    askToken();
+    throw new Error('missing or wrong token');
+  }
+  const doc = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    const error = new Error(doc.error || response.statusText);
+    error.status = response.status;
+    throw error;
+  }
+  return doc.data;
+}
+
+// run calls fn and reports its error, the view is rendered again after a change
+async function run(fn, message) {
+  try {
+    await fn();
+    if (message) {
+      toast(message);
+    }
+    await render();
+  } catch (err) {
+    toast(err.message, true);
+  }
+}
+
+// ---- charts ----
+
+// barChart draws horizontal bars, items are {label, value, title}
+function barChart(items, onClick) {
+  const rowHeight = 26;
+  const labelWidth = 180;
+  const width = 720;
+  const height = items.length * rowHeight + 8;
+  const max = Math.max(...items.map((item) => Math.abs(item.value)), 1);
+  const chart = svg('svg', { class: 'chart', viewBox: `0 0 ${width} ${height}` });
+
+  items.forEach((item, i) => {
+    const y = i * rowHeight + 4;
+    const barWidth = Math.max(2, (Math.abs(item.value) / max) * (width - labelWidth - 120));
+    const group = svg('g', { class: onClick ? 'clickable' : '' },
+      tooltip(item.title || item.label),
+      svg('text', { x: labelWidth - 8, y: y + 15, 'text-anchor': 'end' }, item.label),
+      svg('rect', { x: labelWidth, y, width: barWidth, height: rowHeight - 8, rx: 3, class: item.value < 0 ? 'bar-expense' : 'bar-income' }),
+      svg('text', { x: labelWidth + barWidth + 6, y: y + 15 }, numberFormat.format(Math.abs(item.value))));
+    if (onClick) {
+      group.addEventListener('click', () => onClick(item));
+    }
+    chart.append(group);
+  });
+  return chart;
+}
+
+// columnChart draws a group of vertical bars per column and an optional line
+// columns are {label, bars: [{value, class, title}], line, onClick}
+function columnChart(columns, lineClass) {
+  const width = 720;
+  const height = 260;
+  const top = 12;
+  const bottom = 24;
+  const left = 64;
+  const values = columns.flatMap((column) => column.bars.map((bar) => bar.value).concat(column.line ?? []));
+  const max = Math.max(...values, 0);
+  const min = Math.min(...values, 0);
+  const span = max - min || 1;
+  const y = (value) => top + ((max - value) / span) * (height - top - bottom);
+  const step = (width - left) / Math.max(columns.length, 1);
+  const chart = svg('svg', { class: 'chart', viewBox: `0 0 ${width} ${height}` });
+
+  for (const value of new Set([max, 0, min])) {
+    chart.append(
+      svg('line', { x1: left, x2: width, y1: y(value), y2: y(value), class: 'axis' }),
+      svg('text', { x: left - 6, y: y(value) + 4, 'text-anchor': 'end' }, numberFormat.format(value)));
+  }
+
+  const points = [];
+  columns.forEach((column, i) => {
+    const x = left + i * step;
+    const barWidth = Math.min(28, (step - 8) / Math.max(column.bars.length, 1));
+    const group = svg('g', { class: column.onClick ? 'clickable' : '' });
+    column.bars.forEach((bar, j) => {
+      const x0 = x + (step - barWidth * column.bars.length) / 2 + j * barWidth;
+      group.append(svg('rect', {
+        x: x0, y: Math.min(y(bar.value), y(0)), width: barWidth - 2, height: Math.max(1, Math.abs(y(bar.value) - y(0))), class: bar.class,
+      }, tooltip(bar.title)));
+    });
+    group.append(svg('text', { x: x + step / 2, y: height - 6, 'text-anchor': 'middle' }, column.label));
+    if (column.onClick) {
+      group.addEventListener('click', column.onClick);
+    }
+    chart.append(group);
+    if (column.line !== undefined) {
+      points.push([x + step / 2, y(column.line), column.lineTitle]);
+    }
+  });
+
+  if (points.length) {
+    chart.append(svg('polyline', { points: points.map((p) => p[0] + ',' + p[1]).join(' '), class: lineClass }));
+    for (const [px, py, title] of points) {
+      chart.append(svg('circle', { cx: px, cy: py, r: 3, class: 'dot' }, tooltip(title)));
+    }
+  }
+  return chart;
+}
+
+function legend(...entries) {
+  return el('div', { class: 'legend' }, entries.map(([cls, label]) => el('span', { class: cls }, label)));
+}
+
+function card(label, value, cls) {
+  return el('div', { class: 'card' }, el('div', { class: 'label' }, label), el('div', { class: 'value ' + (cls || '') }, value));
+}
+
+// ---- categories ----
+
+// categoryItems returns the categories directly below the drilled path, largest first
+function categoryItems(baseByCategory, drill) {
+  const depth = drill ? drill.split(SEPARATOR).length + 1 : 1;
+  return Object.entries(baseByCategory || {})
+    .filter(([path]) => path.split(SEPARATOR).length === depth && (!drill || path.startsWith(drill + SEPARATOR)))
+    .map(([path, value]) => ({ path, value, label: path.split(SEPARATOR).pop(), title: path }))
+    .sort((a, b) => Math.abs(b.value) - Math.abs(a.value));
+}
+
+function categorySection(report, base, rangeQuery) {
+  const section = el('section', {}, el('h2', {}, 'Categories'));
+
+  if (state.drill) {
+    const parts = state.drill.split(SEPARATOR);
+    section.append(el('div', { class: 'crumbs' },
+      el('button', { type: 'button', class: 'link', onclick: () => drill('') }, 'All'),
+      parts.map((part, i) => [' / ', el('button', {
+        type: 'button', class: 'link', onclick: () => drill(parts.slice(0, i + 1).join(SEPARATOR)),
+      }, part)])));
+  }
+
+  const items = categoryItems(report.base_by_category, state.drill);
+  if (items.length) {
+    section.append(barChart(items, (item) => drill(item.path)));
+  } else if (!state.drill) {
+    section.append(el('p', { class: 'empty' }, 'No tagged rows.'));
+  }
+
+  if (state.drill) {
+    const total = (report.base_by_category || {})[state.drill] || 0;
+    section.append(el('p', {}, 'Total of ', el('strong', {}, state.drill), ': ', el('span', { class: total < 0 ? 'expense' : 'income' }, money(total, base))));
+    const list = el('div', {}, el('p', { class: 'empty' }, 'Loading…'));
+    section.append(list);
+    api('GET', '/transactions?' + rangeQuery + '&q=' + encodeURIComponent('tag="' + state.drill + '"'))
+      .then((rows) => list.replaceChildren(transactionTable(rows, { showMonth: state.view === 'year' })))
+      .catch((err) => list.replaceChildren(el('p', { class: 'empty' }, err.message)));
+  }
+  return section;
+}
+
+function drill(path) {
+  state.drill = path;
+  render();
+}
+
+// ---- transactions ----
+
+function transactionTable(rows, options) {
+  if (!rows.length) {
+    return el('p', { class: 'empty' }, 'No rows.');
+  }
+  const body = el('tbody');
+  for (const row of rows) {
+    body.append(transactionRow(row, options));
+  }
+  return el('table', {},
+    el('thead', {}, el('tr', {},
+      el('th', {}, ''), el('th', {}, 'Date'), el('th', {}, 'Description'), el('th', { class: 'num' }, 'Amount'),
+      el('th', {}, 'Tags'), el('th', {}, ''))),
+    body);
+}
+
+function transactionRow(row, options) {
+  const completion = row.rule
+    ? el('input', {
+      type: 'checkbox', checked: row.completed, title: row.completed ? 'Mark as planned' : 'Mark as done',
+      onchange: (event) => run(() => api('PATCH', '/transactions/' + encodeURIComponent(row.id), { completed: event.target.checked }),
+        event.target.checked ? 'Completed ' + row.description : 'Reopened ' + row.description),
+    })
+    : '';
+
+  const tr = el('tr', { class: [row.rule ? 'rule' : '', row.rule && row.completed ? 'done' : ''].join(' ') },
+    el('td', {}, completion),
+    el('td', {}, options && options.showMonth ? row.date : pad(row.day)),
+    el('td', {}, row.description, row.account ? el('span', { class: 'muted' }, ' &' + row.account) : ''),
+    el('td', { class: 'num ' + (row.amount < 0 ? 'expense' : 'income') }, money(row.amount, row.currency)),
+    el('td', {}, (row.tags || []).map((tag) => el('span', { class: 'tag' }, '#' + tag)),
+      (row.projects || []).map((project) => el('span', { class: 'tag' }, '@' + project))),
+    el('td', { class: 'num' },
+      el('button', { type: 'button', class: 'link', onclick: () => tr.replaceWith(editRow(row, tr)) }, 'Edit'),
+      el('button', {
+        type: 'button', class: 'link danger',
+        onclick: () => confirm('Remove "' + row.description + '"?')
+          && run(() => api('DELETE', '/transactions/' + encodeURIComponent(row.id)), 'Removed ' + row.description),
+      }, 'Remove')));
+  return tr;
+}
+
+// editRow replaces a row of the table with inputs, saving sends only the fields of the form
+function editRow(row, original) {
+  const input = (name, value, attrs) => el('input', Object.assign({ name, value: value ?? '' }, attrs));
+  const fields = {
+    date: input('date', row.date, { type: 'date', required: true }),
+    description: input('description', row.description),
+    amount: input('amount', row.amount, { type: 'number', step: 'any', required: true }),
+    currency: input('currency', row.currency, { size: 4 }),
+    tags: input('tags', (row.tags || []).join(' '), { placeholder: 'tag other:child' }),
+  };
+
+  const save = () => {
+    const amount = parseFloat(fields.amount.value);
+    if (Number.isNaN(amount)) {
+      toast('Amount is required', true);
+      return;
+    }
+    run(() => api('PATCH', '/transactions/' + encodeURIComponent(row.id), {
+      date: fields.date.value,
+      description: fields.description.value,
+      amount,
+      currency: fields.currency.value,
+      tags: fields.tags.value.split(/\s+/).filter(Boolean),
+    }), 'Saved ' + fields.description.value);
+  };
+
+  const tr = el('tr', {},
+    el('td', {}),
+    el('td', {}, fields.date),
+    el('td', {}, fields.description),
+    el('td', {}, el('div', { class: 'inline' }, fields.amount, fields.currency)),
+    el('td', {}, fields.tags),
+    el('td', { class: 'num' },
+      el('button', { type: 'button', class: 'link', onclick: save }, 'Save'),
+      el('button', { type: 'button', class: 'link', onclick: () => tr.replaceWith(original) }, 'Cancel')));
+  tr.addEventListener('keydown', (event) => {
+    if (event.key === 'Enter') {
+      save();
+    } else if (event.key === 'Escape') {
+      tr.replaceWith(original);
+    }
+  });
+  return tr;
+}
+
+function addForm() {
+  const day = state.year === today.getFullYear() && state.month === today.getMonth() + 1 ? today.getDate() : 1;
+  const form = el('form', { class: 'inline' },
+    el('input', { name: 'input', placeholder: '-100TL market #mutfak', required: true, autocomplete: 'off' }),
+    el('input', { name: 'date', type: 'date', value: period() + '-' + pad(day), required: true }),
+    el('button', { type: 'submit', class: 'primary' }, 'Add'));
+  form.addEventListener('submit', (event) => {
+    event.preventDefault();
+    run(() => api('POST', '/transactions', { input: field(form, 'input').value, date: field(form, 'date').value }), 'Added');
+  });
+  return el('section', {}, el('h2', {}, 'Add a row'), form);
+}
+
+// ---- views ----
+
+async function monthView() {
+  const base = await baseCurrency();
+  let report = null;
+  try {
+    report = await api('GET', '/reports/monthly?period=' + period());
+  } catch (err) {
+    if (err.status !== 404) {
+      throw err;
+    }
+  }
+
+  const view = [addForm()];
+  if (!report) {
+    view.push(el('section', {}, el('p', { class: 'empty' }, 'There is no month file for ' + period() + ' yet, adding a row creates it.')));
+    return view;
+  }
+
+  // Expenses are positive amounts in reports
+  const net = report.base_income - report.base_expenses;
+  const plannedNet = net + report.base_planned_income - report.base_planned_expenses;
+  view.unshift(el('div', { class: 'cards' },
+    card('Income', money(report.base_income, base), 'income'),
+    card('Expenses', money(report.base_expenses, base), 'expense'),
+    card('Net', money(net, base), net < 0 ? 'expense' : 'income'),
+    card('Planned', money(report.base_planned_income - report.base_planned_expenses, base), 'muted'),
+    card('Net with planned', money(plannedNet, base), plannedNet < 0 ? 'expense' : 'income')));
+
+  view.push(el('section', {}, el('h2', {}, 'Actual and planned'),
+    columnChart([
+      { label: 'Income', bars: [
+        { value: report.base_income, class: 'bar-income', title: 'Income ' + money(report.base_income, base) },
+        { value: report.base_planned_income, class: 'bar-planned', title: 'Planned income ' + money(report.base_planned_income, base) }] },
+      { label: 'Expenses', bars: [
+        { value: report.base_expenses, class: 'bar-expense', title: 'Expenses ' + money(report.base_expenses, base) },
+        { value: report.base_planned_expenses, class: 'bar-planned', title: 'Planned expenses ' + money(report.base_planned_expenses, base) }] },
+    ]),
+    legend(['income', 'Income'], ['expense', 'Expenses'], ['planned', 'Planned'])));
+
+  view.push(categorySection(report, base, 'period=' + period()));
+
+  if (!state.drill) {
+    const rows = await api('GET', '/transactions?period=' + period());
+    const planned = rows.filter((row) => row.rule);
+    view.push(el('section', {}, el('h2', {}, 'Rows'), transactionTable(rows.filter((row) => !row.rule))));
+    view.push(el('section', {}, el('h2', {}, 'Rules'), transactionTable(planned)));
+  }
+  return view;
+}
+
+async function yearView() {
+  const report = await api('GET', '/reports/yearly?year=' + state.year);
+  const base = report.base_currency;
+  const net = report.base_total_income - report.base_total_expenses;
+
+  const byMonth = new Map((report.months || []).map((month) => [month.month, month]));
+  const columns = MONTHS.map((label, i) => {
+    const month = byMonth.get(i + 1);
+    const income = month ? month.base_income : 0;
+    const expenses = month ? month.base_expenses : 0;
+    return {
+      label,
+      bars: [
+        { value: income, class: 'bar-income', title: label + ' income ' + money(income, base) },
+        { value: expenses, class: 'bar-expense', title: label + ' expenses ' + money(expenses, base) },
+      ],
+      line: income - expenses,
+      lineTitle: label + ' net ' + money(income - expenses, base),
+      onClick: () => { location.hash = 'month/' + state.year + '-' + pad(i + 1); },
+    };
+  });
+
+  const table = el('tbody');
+  for (const month of report.months || []) {
+    const monthNet = month.base_income - month.base_expenses;
+    table.append(el('tr', { class: monthNet < 0 ? 'negative' : '' },
+      el('td', {}, el('a', { href: '#month/' + state.year + '-' + pad(month.month) }, MONTHS[month.month - 1])),
+      el('td', { class: 'num income' }, money(month.base_income, base)),
+      el('td', { class: 'num expense' }, money(month.base_expenses, base)),
+      el('td', { class: 'num' }, money(monthNet, base)),
+      el('td', { class: 'num muted' }, money(month.base_planned_income - month.base_planned_expenses, base))));
+  }
+
+  return [
+    el('div', { class: 'cards' },
+      card('Income', money(report.base_total_income, base), 'income'),
+      card('Expenses', money(report.base_total_expenses, base), 'expense'),
+      card('Net', money(net, base), net < 0 ? 'expense' : 'income'),
+      report.missing_rates ? card('Missing rates', String(report.missing_rates), 'expense') : null),
+    el('section', {}, el('h2', {}, 'Income and expenses by month'), columnChart(columns, 'line-net'),
+      legend(['income', 'Income'], ['expense', 'Expenses'], ['', 'Net'])),
+    categorySection(report, base, 'period=' + state.year),
+    el('section', {}, el('h2', {}, 'Months'), el('table', {},
+      el('thead', {}, el('tr', {}, el('th', {}, 'Month'), el('th', { class: 'num' }, 'Income'), el('th', { class: 'num' }, 'Expenses'),
+        el('th', { class: 'num' }, 'Net'), el('th', { class: 'num' }, 'Planned'))),
+      table)),
+  ];
+}
+
+async function forecastView() {
+  const fc = await api('GET', '/forecast?months=' + state.forecastMonths);
+  const base = fc.base_currency;
+
+  const select = el('select', { onchange: (event) => { state.forecastMonths = Number(event.target.value); render(); } },
+    [3, 6, 12, 24].map((n) => el('option', { value: n, selected: n === state.forecastMonths }, n + ' months')));
+
+  const columns = fc.months.map((month) => {
+    const label = MONTHS[month.month - 1] + (month.month === 1 ? ' ' + month.year : '');
+    return {
+      label,
+      bars: [{ value: month.net, class: month.net < 0 ? 'bar-expense' : 'bar-income', title: label + ' net ' + money(month.net, base) }],
+      line: month.balance,
+      lineTitle: label + ' balance ' + money(month.balance, base),
+      onClick: () => { location.hash = 'month/' + month.year + '-' + pad(month.month); },
+    };
+  });
+
+  const table = el('tbody');
+  for (const month of fc.months) {
+    table.append(el('tr', { class: month.balance < 0 ? 'negative' : '' },
+      el('td', {}, month.year + '-' + pad(month.month)),
+      el('td', { class: 'num' }, money(month.actual)),
+      el('td', { class: 'num' }, money(month.planned)),
+      el('td', { class: 'num' }, money(month.rules)),
+      el('td', { class: 'num' }, money(month.pool)),
+      el('td', { class: 'num ' + (month.net < 0 ? 'expense' : 'income') }, money(month.net)),
+      el('td', { class: 'num' }, money(month.balance)),
+      el('td', { class: 'num muted' }, month.missing_rates || '')));
+  }
+
+  const first = fc.first_negative;
+  return [
+    el('div', { class: 'cards' },
+      card('Opening balance', money(fc.opening, base)),
+      card('Closing balance', money(fc.months[fc.months.length - 1].balance, base), fc.months[fc.months.length - 1].balance < 0 ? 'expense' : 'income'),
+      card('First negative month', first ? first.year + '-' + pad(first.month) : 'none', first ? 'expense' : 'income')),
+    el('section', {}, el('h2', {}, 'Projection ', select), columnChart(columns, 'line-balance'),
+      legend(['income', 'Net in'], ['expense', 'Net out'], ['balance', 'Balance'])),
+    el('section', {}, el('h2', {}, 'Months in ' + base), el('table', {},
+      el('thead', {}, el('tr', {}, el('th', {}, 'Month'), el('th', { class: 'num' }, 'Actual'), el('th', { class: 'num' }, 'Planned'),
+        el('th', { class: 'num' }, 'Rules'), el('th', { class: 'num' }, 'Pool'), el('th', { class: 'num' }, 'Net'),
+        el('th', { class: 'num' }, 'Balance'), el('th', { class: 'num' }, 'Missing rates'))),
+      table)),
+  ];
+}
+
+// ruleForm edits a rule, or adds one when rule is null
+function ruleForm(rule, base) {
+  const value = (v) => v ?? '';
+  const form = el('form', { class: 'grid' },
+    el('label', {}, 'Name', el('input', { name: 'name', value: value(rule && rule.name), required: true })),
+    el('label', {}, 'Type', el('select', { name: 'type' },
+      el('option', { value: 'expense', selected: !rule || rule.type === 'expense' }, 'expense'),
+      el('option', { value: 'income', selected: rule && rule.type === 'income' }, 'income'))),
+    el('label', {}, 'Amount', el('input', { name: 'amount', type: 'number', step: 'any', min: 0, value: rule ? Math.abs(rule.amount) : '', required: true })),
+    el('label', {}, 'Currency', el('input', { name: 'currency', value: rule ? rule.currency : base, required: true })),
+    el('label', {}, 'Category', el('input', { name: 'category', value: value(rule && rule.category) })),
+    el('label', {}, 'Day', el('input', { name: 'day', type: 'number', min: 1, max: 31, value: rule ? rule.schedule.day : 1, required: true })),
+    el('label', {}, 'Start (YYYY-MM)', el('input', { name: 'start_date', pattern: '\\d{4}-\\d{2}', value: value(rule && rule.start_date) })),
+    el('label', {}, 'End (YYYY-MM)', el('input', { name: 'end_date', pattern: '\\d{4}-\\d{2}', value: value(rule && rule.end_date) })),
+    el('label', {}, 'Active', el('input', { name: 'active', type: 'checkbox', checked: !rule || rule.active })),
+    el('div', { class: 'actions' },
+      rule ? el('button', { type: 'button', onclick: () => render() }, 'Cancel') : null,
+      el('button', { type: 'submit', class: 'primary' }, rule ? 'Save' : 'Add rule')));
+
+  form.addEventListener('submit', (event) => {
+    event.preventDefault();
+    const body = {
+      name: field(form, 'name').value,
+      type: field(form, 'type').value,
+      amount: parseFloat(field(form, 'amount').value),
+      currency: field(form, 'currency').value,
+      category: field(form, 'category').value,
+      schedule: { frequency: 'monthly', day: Number(field(form, 'day').value) },
+      start_date: field(form, 'start_date').value,
+      end_date: field(form, 'end_date').value,
+      active: field(form, 'active').checked,
+    };
+    if (rule) {
+      run(() => api('PUT', '/rules/' + encodeURIComponent(rule.id), body), 'Saved ' + body.name);
+    } else {
+      run(() => api('POST', '/rules', body), 'Added ' + body.name);
+    }
+  });
+  return form;
+}
+
+async function rulesView() {
+  const [rules, base] = await Promise.all([api('GET', '/rules'), baseCurrency()]);
+  const editor = el('section', {}, el('h2', {}, 'Add a rule'), ruleForm(null, base));
+
+  const body = el('tbody');
+  for (const rule of rules || []) {
+    const sign = rule.type === 'expense' ? -1 : 1;
+    body.append(el('tr', { class: rule.active ? '' : 'done' },
+      el('td', {}, el('input', {
+        type: 'checkbox', checked: rule.active, title: rule.active ? 'Deactivate' : 'Activate',
+        onchange: (event) => run(() => api('PATCH', '/rules/' + encodeURIComponent(rule.id), { active: event.target.checked }),
+          (event.target.checked ? 'Activated ' : 'Deactivated ') + rule.name),
+      })),
+      el('td', {}, rule.name, el('div', { class: 'muted' }, rule.id)),
+      el('td', { class: 'num ' + (sign < 0 ? 'expense' : 'income') }, money(sign * Math.abs(rule.amount), rule.currency)),
+      el('td', {}, rule.category ? el('span', { class: 'tag' }, '#' + rule.category) : ''),
+      el('td', {}, 'day ' + rule.schedule.day),
+      el('td', {}, [rule.start_date, rule.end_date].filter(Boolean).join(' – ')),
+      el('td', { class: 'num' },
+        el('button', {
+          type: 'button', class: 'link',
+          onclick: () => {
+            editor.replaceChildren(el('h2', {}, 'Edit ' + rule.name), ruleForm(rule, base));
+            editor.scrollIntoView({ behavior: 'smooth' });
+          },
+        }, 'Edit'),
+        el('button', {
+          type: 'button', class: 'link danger',
+          onclick: () => confirm('Remove the rule "' + rule.name + '"? Its lines in month files are kept.')
+            && run(() => api('DELETE', '/rules/' + encodeURIComponent(rule.id)), 'Removed ' + rule.name),
+        }, 'Remove'))));
+  }
+
+  const sync = el('button', {
+    type: 'button',
+    onclick: () => run(async () => {
+      const result = await api('POST', '/rules/sync');
+      toast(`Synced: ${result.added} added, ${result.updated} updated, ${result.skipped} skipped`);
+    }),
+  }, 'Sync to month files');
+
+  return [
+    el('section', {}, el('h2', {}, 'Rules ', sync),
+      rules && rules.length
+        ? el('table', {}, el('thead', {}, el('tr', {}, el('th', {}, 'Active'), el('th', {}, 'Name'), el('th', { class: 'num' }, 'Amount'),
+          el('th', {}, 'Category'), el('th', {}, 'Schedule'), el('th', {}, 'Period'), el('th', {}, ''))), body)
+        : el('p', { class: 'empty' }, 'No rules.')),
+    editor,
+  ];
+}
+
+let baseCache = '';
+
+// baseCurrency returns the base currency of the ledger, the yearly report names it
+async function baseCurrency() {
+  if (!baseCache) {
+    const report = await api('GET', '/reports/yearly?year=' + state.year);
+    baseCache = report.base_currency;
+  }
+  return baseCache;
+}
+
+// ---- routing ----
+
+const views = { month: monthView, year: yearView, forecast: forecastView, rules: rulesView };
+
+// route reads the view and period from the location hash: #month/2026-03, #year/2026, #forecast, #rules
+function route() {
+  const [view, value] = location.hash.slice(1).split('/');
+  const previous = state.view + period();
+  state.view = views[view] ? view : 'month';
+  if (state.view === 'month' && /^\d{4}-\d{2}$/.test(value || '')) {
+    state.year = Number(value.slice(0, 4));
+    state.month = Number(value.slice(5));
+  } else if (state.view === 'year' && /^\d{4}$/.test(value || '')) {
+    state.year = Number(value);
+  }
+  if (state.view + period() !== previous) {
+    state.drill = '';
+  }
+}
+
+function step(delta) {
+  if (state.view === 'year') {
+    location.hash = 'year/' + (state.year + delta);
+    return;
+  }
+  const index = state.year * 12 + state.month - 1 + delta;
+  location.hash = 'month/' + Math.floor(index / 12) + '-' + pad(index % 12 + 1);
+}
+
+let rendering = 0;
+
+async function render() {
+  const ticket = ++rendering;
+  for (const link of document.querySelectorAll('nav a')) {
+    link.classList.toggle('active', link.dataset.view === state.view);
+  }
+  const hasPeriod = state.view === 'month' || state.view === 'year';
+  document.getElementById('period').hidden = !hasPeriod;
+  document.getElementById('period-label').textContent = state.view === 'year' ? String(state.year) : MONTHS[state.month - 1] + ' ' + state.year;
+
+  const main = document.getElementById('view');
+  try {
+    const content = await views[state.view]();
+    if (ticket === rendering) {
+      main.replaceChildren(...content.filter(Boolean));
+    }
+  } catch (err) {
+    if (ticket === rendering) {
+      main.replaceChildren(el('section', {}, el('p', { class: 'empty' }, err.message)));
+    }
+  }
+}
+
+function start() {
+  // The URL printed by `spendgrid serve` carries the token, it is kept and removed from the address bar
+  const match = location.hash.match(/^#token=([^&]+)/);
+  if (match) {
+    localStorage.setItem(TOKEN_KEY, decodeURIComponent(match[1]));
+    history.replaceState(null, '', location.pathname + '#month');
+  }
+
+  document.getElementById('token-form').addEventListener('submit', (event) => {
+    event.preventDefault();
+    localStorage.setItem(TOKEN_KEY, document.getElementById('token-input').value.trim());
+    document.getElementById('token-dialog').hidden = true;
+    baseCache = '';
+    render();
+  });
+  document.getElementById('prev').addEventListener('click', () => step(-1));
+  document.getElementById('next').addEventListener('click', () => step(1));
+  window.addEventListener('hashchange', () => { route(); render(); });
+
+  route();
+  render();
+}
+
+start();